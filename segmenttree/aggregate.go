@@ -1,8 +1,215 @@
 package segmenttree
 
-type Aggregate struct {
-	operation        func(Addable, Addable) Addable
-	inverseOperation func(Addable, Addable) Addable
-	additionElement  func(Addable) Addable
-	neutralElement   Addable
+import "errors"
+
+var (
+	ErrUnnamedAggregate = errors.New("aggregate has no name")
+	// ErrNotInvertible reports a tree which requires an invertible aggregate, see isInvertible.
+	ErrNotInvertible = errors.New("aggregate is not invertible")
+)
+
+// An Aggregate without an inverseOperation (e.g. min or max) is supported as well.
+// Deleting from such a tree recomputes it from the inserted tuples.
+type Aggregate[V Addable[V]] struct {
+	operation        func(V, V) V
+	inverseOperation func(V, V) V
+	additionElement  func(V) V
+	neutralElement   V
+	// name identifies the aggregate, e.g. in snapshots, see kind. It is empty unless set with WithName.
+	name string
+	// additive is set if the operation adds the values, see isAdditive.
+	additive bool
+	// monotone is set if the operation is monotone, see isMonotone.
+	monotone bool
+}
+
+// AggregateOption configures an aggregate created by NewAggregate.
+type AggregateOption func(*aggregateOptions)
+
+type aggregateOptions struct {
+	name     string
+	additive bool
+	monotone bool
+}
+
+// WithName names the aggregate. A tree can only be written to a snapshot if its aggregate has a
+// name, which identifies the aggregate when the snapshot is read again. The name has to differ
+// from the names of the other aggregates of the value type, such as "sum" or "max".
+func WithName(name string) AggregateOption {
+	return func(options *aggregateOptions) {
+		options.name = name
+	}
+}
+
+// Additive declares that the operation adds the values, as Sum does. If the value type is Linear as
+// well, the nodes of a tree keep the integrals of their subtrees, see SegmentTreeImpl.Integrate.
+func Additive() AggregateOption {
+	return func(options *aggregateOptions) {
+		options.additive = true
+	}
+}
+
+// Monotone declares that the aggregated value as float does not decrease if the value as float of
+// one of the aggregated values increases, as for Min or Max. The nodes of a tree then keep the
+// extreme values of their subtrees, see SegmentTreeImpl.FindIntervals. Additive aggregates are
+// monotone anyway.
+func Monotone() AggregateOption {
+	return func(options *aggregateOptions) {
+		options.monotone = true
+	}
+}
+
+// NewAggregate creates an aggregate combining the values valid at an instant with operation. A value
+// is transformed by additionElement before it is inserted, e.g. to count it as 1. neutralElement is
+// returned where no value is valid. inverseOperation undoes operation and may be nil.
+func NewAggregate[V Addable[V]](operation func(V, V) V, inverseOperation func(V, V) V, additionElement func(V) V, neutralElement V, options ...AggregateOption) Aggregate[V] {
+	var configured aggregateOptions
+	for _, option := range options {
+		option(&configured)
+	}
+
+	return Aggregate[V]{
+		operation:        operation,
+		inverseOperation: inverseOperation,
+		additionElement:  additionElement,
+		neutralElement:   neutralElement,
+		name:             configured.name,
+		additive:         configured.additive,
+		monotone:         configured.monotone,
+	}
+}
+
+// SumAggregate returns an aggregate of the sum of all values valid at an instant.
+// The zero value of V is returned where no value is valid.
+func SumAggregate[V Addable[V]]() Aggregate[V] {
+	var zero V
+	return NewAggregate(Sum[V], InverseSum[V], Identity[V], zero, WithName("sum"), Additive())
+}
+
+// CountAggregate returns an aggregate of the number of values valid at an instant,
+// regardless of the values themselves.
+func CountAggregate() Aggregate[Float] {
+	return NewAggregate(Count[Float], InverseCount[Float], countAsOne, Float(0), WithName("count"), Additive())
+}
+
+func countAsOne(Float) Float {
+	return 1
+}
+
+// AverageAggregate returns an aggregate of the average of all values valid at an instant. The
+// value of an inserted AverageTuple is its Sum, its Count is ignored.
+func AverageAggregate() Aggregate[AverageTuple] {
+	return NewAggregate(Average[AverageTuple], InverseAverage[AverageTuple], countOnce, AverageTuple{}, WithName("average"), Additive())
+}
+
+func countOnce(value AverageTuple) AverageTuple {
+	return AverageTuple{Sum: value.Sum, Count: 1}
+}
+
+// MomentsAggregate returns an aggregate of the moments of all values valid at an instant, whose
+// AsFloat64 is their variance. The value of an inserted MomentsTuple is its Sum, the other fields
+// are ignored.
+func MomentsAggregate() Aggregate[MomentsTuple] {
+	return NewAggregate(Sum[MomentsTuple], InverseSum[MomentsTuple], momentsOnce, MomentsTuple{}, WithName("moments"), Additive())
+}
+
+func momentsOnce(value MomentsTuple) MomentsTuple {
+	return NewMomentsTuple(value.Sum)
+}
+
+// DistinctCountAggregate returns an aggregate of the estimated number of distinct ids valid at an
+// instant. Insert a tuple with the value NewDistinctSketch(id) to add the id over its interval.
+func DistinctCountAggregate() Aggregate[DistinctSketch] {
+	return NewAggregate(Sum[DistinctSketch], InverseSum[DistinctSketch], Identity[DistinctSketch], DistinctSketch{}, WithName("distinct-count"), Additive())
+}
+
+// HistogramAggregate returns an aggregate of the histogram of all values valid at an instant, whose
+// AsFloat64 is their number. Insert a tuple with the value buckets.Histogram(value) to count the value
+// in its bucket.
+func HistogramAggregate() Aggregate[Histogram] {
+	return NewAggregate(Sum[Histogram], InverseSum[Histogram], Identity[Histogram], Histogram{}, WithName("histogram"), Additive())
+}
+
+// MinAggregate returns an aggregate of the minimum of all values valid at an instant.
+// highest is returned where no value is valid, e.g. Float(math.Inf(1)).
+func MinAggregate[V interface {
+	Addable[V]
+	Comparable[V]
+}](highest V) Aggregate[V] {
+	return NewAggregate(Min[V], nil, Identity[V], highest, WithName("min"), Monotone())
+}
+
+// MaxAggregate returns an aggregate of the maximum of all values valid at an instant.
+// lowest is returned where no value is valid, e.g. Float(math.Inf(-1)).
+func MaxAggregate[V interface {
+	Addable[V]
+	Comparable[V]
+}](lowest V) Aggregate[V] {
+	return NewAggregate(Max[V], nil, Identity[V], lowest, WithName("max"), Monotone())
+}
+
+func (aggregate Aggregate[V]) GetOperation() func(V, V) V {
+	return aggregate.operation
+}
+
+func (aggregate Aggregate[V]) GetInverseOperation() func(V, V) V {
+	return aggregate.inverseOperation
+}
+
+func (aggregate Aggregate[V]) GetAdditionElement() func(V) V {
+	return aggregate.additionElement
+}
+
+func (aggregate Aggregate[V]) GetNeutralElement() V {
+	return aggregate.neutralElement
+}
+
+func (aggregate Aggregate[V]) GetName() string {
+	return aggregate.name
+}
+
+func (aggregate Aggregate[V]) isInvertible() bool {
+	return aggregate.inverseOperation != nil
+}
+
+// combine applies the operation to the value of a piece and the value to add. If the value to add
+// cancels the value of the piece up to the tolerance of the value type (see Equatable), e.g. when
+// a tuple is deleted again, the result is the neutral element rather than a rounding residue,
+// which would keep the piece from merging with its neighbours.
+func (aggregate Aggregate[V]) combine(value V, delta V) V {
+	if aggregate.isInvertible() && equalValues(value, aggregate.inverseOperation(aggregate.neutralElement, delta)) {
+		return aggregate.neutralElement
+	}
+
+	return aggregate.operation(value, delta)
+}
+
+// kind identifies the aggregate by its value type and its name, e.g. to make sure that a snapshot
+// is only loaded into a tree with the same aggregate. It returns ErrUnnamedAggregate if the
+// aggregate has no name, as aggregates without a name can not be told apart.
+func (aggregate Aggregate[V]) kind() (string, error) {
+	if aggregate.name == "" {
+		return "", ErrUnnamedAggregate
+	}
+
+	return valueType[V]().String() + "," + aggregate.name, nil
+}
+
+// isAdditive reports whether the aggregated value as float is the sum of the values as float, i.e.
+// the operation adds the values (see Additive) and the value type is Linear. For such an aggregate, the integral
+// of a subtree is the sum of the integrals of its parts.
+func (aggregate Aggregate[V]) isAdditive() bool {
+	var value V
+	if linear, ok := any(value).(Linear); !ok || !linear.IsLinear() {
+		return false
+	}
+
+	return aggregate.additive
+}
+
+// isMonotone reports whether the aggregated value as float does not decrease if the value as float
+// of one of the aggregated values increases, as for an additive aggregate, min or max (see Monotone).
+// For such an aggregate, the extreme values of a subtree follow from the extreme values of its parts.
+func (aggregate Aggregate[V]) isMonotone() bool {
+	return aggregate.monotone || aggregate.isAdditive()
 }
@@ -0,0 +1,215 @@
+package segmenttree
+
+import (
+	"errors"
+	"sort"
+)
+
+var (
+	ErrFactNotFound         = errors.New("no current fact with the given value and valid time")
+	ErrTransactionTimeOrder = errors.New("transaction time is before the last transaction")
+)
+
+// BitemporalTuple is a value with a valid time (when it is true in the modelled world) and a
+// transaction time (when it was believed by the system). A fact which is still believed has a
+// transaction time ending at MaxInstant.
+type BitemporalTuple[V Addable[V], T Timestamp] struct {
+	value       V
+	valid       Interval[T]
+	transaction Interval[T]
+}
+
+// BitemporalTree answers what the system believed the aggregate at a valid time to be, as of a
+// transaction time.
+//
+// Transaction time only moves forward, so the facts believed at a transaction time are exactly
+// the content of a PersistentSegmentTree (over valid time) at the version of the last change up
+// to that time. Deleting a fact does not remove it from the history, it only closes its
+// transaction time.
+type BitemporalTree[V Addable[V], T Timestamp] struct {
+	tree *PersistentSegmentTree[V, T]
+	// transactions holds the transaction times of all changes in increasing order and
+	// versions the version of the tree after the last change at the same index.
+	transactions []T
+	versions     []uint64
+	facts        []BitemporalTuple[V, T]
+}
+
+func (tuple BitemporalTuple[V, T]) GetValue() V {
+	return tuple.value
+}
+
+func (tuple BitemporalTuple[V, T]) GetValidInterval() Interval[T] {
+	return tuple.valid
+}
+
+func (tuple BitemporalTuple[V, T]) GetTransactionInterval() Interval[T] {
+	return tuple.transaction
+}
+
+func NewBitemporalTree[V Addable[V], T Timestamp](branchingFactor uint32, aggregate Aggregate[V]) *BitemporalTree[V, T] {
+	return &BitemporalTree[V, T]{
+		tree: NewPersistentSegmentTree[V, T](branchingFactor, aggregate),
+	}
+}
+
+// Insert records the fact that the value is valid within the tuple's interval, believed from the transaction time on.
+func (bitemporal *BitemporalTree[V, T]) Insert(value ValueIntervalTuple[V, T], transactionTime T) error {
+	if err := bitemporal.checkTransactionTime(transactionTime); err != nil {
+		return err
+	}
+
+	version, err := bitemporal.tree.Insert(value)
+	if err != nil {
+		return err
+	}
+
+	bitemporal.facts = append(bitemporal.facts, BitemporalTuple[V, T]{
+		value:       value.value,
+		valid:       value.interval,
+		transaction: NewInterval(transactionTime, MaxInstant[T]()),
+	})
+	bitemporal.record(transactionTime, version)
+
+	return nil
+}
+
+// Delete logically deletes a current fact with the tuple's value and valid time. The fact is
+// no longer believed from the transaction time on, but stays visible for earlier transaction times.
+func (bitemporal *BitemporalTree[V, T]) Delete(value ValueIntervalTuple[V, T], transactionTime T) error {
+	if err := bitemporal.checkTransactionTime(transactionTime); err != nil {
+		return err
+	}
+
+	for i := len(bitemporal.facts) - 1; i >= 0; i-- {
+		fact := &bitemporal.facts[i]
+
+		if fact.transaction.end == MaxInstant[T]() && fact.value == value.value && fact.valid == value.interval {
+			version, err := bitemporal.tree.Delete(value)
+			if err != nil {
+				return err
+			}
+
+			fact.transaction.end = transactionTime
+			bitemporal.record(transactionTime, version)
+			return nil
+		}
+	}
+
+	return ErrFactNotFound
+}
+
+// GetAtInstant returns the aggregate at the valid time as believed at the transaction time.
+func (bitemporal *BitemporalTree[V, T]) GetAtInstant(validTime T, transactionTime T) (V, error) {
+	return bitemporal.asOf(transactionTime).GetAtInstant(validTime)
+}
+
+// GetWithinValidInterval returns the aggregate within the valid interval as believed at the transaction time.
+func (bitemporal *BitemporalTree[V, T]) GetWithinValidInterval(valid Interval[T], transactionTime T) ([]ValueIntervalTuple[V, T], error) {
+	return bitemporal.asOf(transactionTime).GetWithinInterval(valid)
+}
+
+// GetWithinIntervals returns the aggregate within the valid interval for every belief within the
+// transaction interval. The result consists of rectangles sorted by transaction and valid time.
+func (bitemporal *BitemporalTree[V, T]) GetWithinIntervals(valid Interval[T], transaction Interval[T]) ([]BitemporalTuple[V, T], error) {
+	if err := checkIntervals(valid, transaction); err != nil {
+		return nil, err
+	}
+
+	result := []BitemporalTuple[V, T]{}
+
+	if transaction.GetLength() == 0 {
+		return result, nil
+	}
+
+	// The transaction times of the changes within the transaction interval split it into periods
+	// in which the belief does not change.
+	starts := []T{transaction.start}
+	for _, transactionTime := range bitemporal.transactions {
+		if transactionTime > transaction.start && transactionTime < transaction.end {
+			starts = append(starts, transactionTime)
+		}
+	}
+
+	var previous []ValueIntervalTuple[V, T]
+	periodStart := transaction.start
+
+	for i, start := range starts {
+		pieces, err := bitemporal.asOf(start).GetWithinInterval(valid)
+		if err != nil {
+			return nil, err
+		}
+
+		if i > 0 && !equalPieces(previous, pieces) {
+			result = appendRectangles(result, previous, NewInterval(periodStart, start))
+			periodStart = start
+		}
+		previous = pieces
+	}
+
+	return appendRectangles(result, previous, NewInterval(periodStart, transaction.end)), nil
+}
+
+// Facts returns all facts ever inserted, including the logically deleted ones.
+func (bitemporal *BitemporalTree[V, T]) Facts() []BitemporalTuple[V, T] {
+	return append([]BitemporalTuple[V, T]{}, bitemporal.facts...)
+}
+
+func (bitemporal *BitemporalTree[V, T]) checkTransactionTime(transactionTime T) error {
+	if last := len(bitemporal.transactions) - 1; last >= 0 && transactionTime < bitemporal.transactions[last] {
+		return ErrTransactionTimeOrder
+	}
+
+	return nil
+}
+
+func (bitemporal *BitemporalTree[V, T]) record(transactionTime T, version uint64) {
+	if last := len(bitemporal.transactions) - 1; last >= 0 && bitemporal.transactions[last] == transactionTime {
+		bitemporal.versions[last] = version
+		return
+	}
+
+	bitemporal.transactions = append(bitemporal.transactions, transactionTime)
+	bitemporal.versions = append(bitemporal.versions, version)
+}
+
+// asOf returns the version of the tree believed at the transaction time.
+func (bitemporal *BitemporalTree[V, T]) asOf(transactionTime T) TreeSnapshot[V, T] {
+	i := sort.Search(len(bitemporal.transactions), func(i int) bool {
+		return bitemporal.transactions[i] > transactionTime
+	})
+
+	version := uint64(0)
+	if i > 0 {
+		version = bitemporal.versions[i-1]
+	}
+
+	snapshot, _ := bitemporal.tree.AtVersion(version)
+	return snapshot
+}
+
+func equalPieces[V Addable[V], T Timestamp](a []ValueIntervalTuple[V, T], b []ValueIntervalTuple[V, T]) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].interval != b[i].interval || !equalValues(a[i].value, b[i].value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func appendRectangles[V Addable[V], T Timestamp](result []BitemporalTuple[V, T], pieces []ValueIntervalTuple[V, T], transaction Interval[T]) []BitemporalTuple[V, T] {
+	for _, piece := range pieces {
+		result = append(result, BitemporalTuple[V, T]{
+			value:       piece.value,
+			valid:       piece.interval,
+			transaction: transaction,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,140 @@
+package segmenttree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitemporalGetAtInstant(t *testing.T) {
+	// Arrange
+	testData := []struct {
+		validTime       uint32
+		transactionTime uint32
+		expectedValue   Float
+	}{
+		{19, 50, Float(0)},
+		{19, 100, Float(6)},
+		{27, 150, Float(7)},
+		{19, 200, Float(6)},
+		{27, 200, Float(4)},
+		{27, 1000, Float(4)},
+		{42, 199, Float(5)},
+		{42, 300, Float(1)},
+	}
+
+	tree := setupBitemporalTree(t)
+
+	for _, td := range testData {
+		// Act
+		res := must(tree.GetAtInstant(td.validTime, td.transactionTime))
+
+		// Assert
+		assert.Equal(t, td.expectedValue, res, "valid time %d, transaction time %d", td.validTime, td.transactionTime)
+	}
+}
+
+func TestBitemporalGetWithinValidInterval(t *testing.T) {
+	// Arrange
+	tree := setupBitemporalTree(t)
+
+	// Act
+	before := must(tree.GetWithinValidInterval(NewInterval[uint32](20, 35), 150))
+	after := must(tree.GetWithinValidInterval(NewInterval[uint32](20, 35), 250))
+
+	// Assert
+	assert.Equal(t, []ValueIntervalTuple[Float, uint32]{
+		{interval: NewInterval[uint32](20, 30), value: Float(7)},
+		{interval: NewInterval[uint32](30, 35), value: Float(4)},
+	}, before)
+	assert.Equal(t, []ValueIntervalTuple[Float, uint32]{
+		{interval: NewInterval[uint32](20, 25), value: Float(7)},
+		{interval: NewInterval[uint32](25, 35), value: Float(4)},
+	}, after)
+}
+
+func TestBitemporalGetWithinIntervals(t *testing.T) {
+	// Arrange
+	tree := setupBitemporalTree(t)
+
+	// Act
+	result := must(tree.GetWithinIntervals(NewInterval[uint32](22, 28), NewInterval[uint32](0, 400)))
+
+	// Assert
+	assert.Equal(t, []BitemporalTuple[Float, uint32]{
+		{value: Float(0), valid: NewInterval[uint32](22, 28), transaction: NewInterval[uint32](0, 100)},
+		{value: Float(7), valid: NewInterval[uint32](22, 28), transaction: NewInterval[uint32](100, 200)},
+		{value: Float(7), valid: NewInterval[uint32](22, 25), transaction: NewInterval[uint32](200, 400)},
+		{value: Float(4), valid: NewInterval[uint32](25, 28), transaction: NewInterval[uint32](200, 400)},
+	}, result)
+}
+
+func TestBitemporalGetWithinIntervalsWithUnchangedPeriod(t *testing.T) {
+	// Arrange
+	tree := setupBitemporalTree(t)
+
+	// Act
+	// The change at transaction time 200 does not affect the valid time 0 to 5.
+	result := must(tree.GetWithinIntervals(NewInterval[uint32](0, 5), NewInterval[uint32](150, 400)))
+
+	// Assert
+	assert.Equal(t, []BitemporalTuple[Float, uint32]{
+		{value: Float(0), valid: NewInterval[uint32](0, 5), transaction: NewInterval[uint32](150, 400)},
+	}, result)
+}
+
+func TestBitemporalDeleteClosesTransactionTime(t *testing.T) {
+	// Arrange
+	tree := setupBitemporalTree(t)
+
+	// Act
+	facts := tree.Facts()
+
+	// Assert
+	assert.Len(t, facts, 7)
+	assert.Equal(t, BitemporalTuple[Float, uint32]{value: Float(3), valid: NewInterval[uint32](10, 30), transaction: NewInterval[uint32](100, 200)}, facts[1])
+	assert.Equal(t, BitemporalTuple[Float, uint32]{value: Float(4), valid: NewInterval[uint32](35, 45), transaction: NewInterval[uint32](100, 200)}, facts[4])
+	assert.Equal(t, BitemporalTuple[Float, uint32]{value: Float(3), valid: NewInterval[uint32](10, 25), transaction: NewInterval[uint32](200, MaxInstant[uint32]())}, facts[6])
+}
+
+func TestBitemporalDeleteUnknownFact(t *testing.T) {
+	// Arrange
+	tree := setupBitemporalTree(t)
+
+	// Act
+	alreadyDeleted := tree.Delete(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](10, 30), value: Float(3)}, 300)
+	otherValue := tree.Delete(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](10, 40), value: Float(3)}, 300)
+
+	// Assert
+	assert.ErrorIs(t, alreadyDeleted, ErrFactNotFound)
+	assert.ErrorIs(t, otherValue, ErrFactNotFound)
+	assert.Equal(t, Float(6), must(tree.GetAtInstant(19, 300)))
+}
+
+func TestBitemporalTransactionTimeBeforeLastTransaction(t *testing.T) {
+	// Arrange
+	tree := setupBitemporalTree(t)
+
+	// Act
+	err := tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](0, 5), value: Float(1)}, 150)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrTransactionTimeOrder)
+	assert.Equal(t, Float(0), must(tree.GetAtInstant(2, 300)))
+}
+
+// setupBitemporalTree inserts the dosage scenario at transaction time 100. At transaction
+// time 200, the tuple [10, 30) is corrected to [10, 25) and the tuple [35, 45) is deleted.
+func setupBitemporalTree(t *testing.T) *BitemporalTree[Float, uint32] {
+	tree := NewBitemporalTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+
+	for _, tuple := range dosageTestData[Float]() {
+		assert.NoError(t, tree.Insert(tuple, 100))
+	}
+
+	assert.NoError(t, tree.Delete(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](10, 30), value: Float(3)}, 200))
+	assert.NoError(t, tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](10, 25), value: Float(3)}, 200))
+	assert.NoError(t, tree.Delete(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](35, 45), value: Float(4)}, 200))
+
+	return tree
+}
@@ -0,0 +1,311 @@
+package segmenttree
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// BufferPool keeps the recently used nodes of a tree backed by a NodeStore in memory.
+//
+// An interior node references its children by their page ids (see link). A child in memory is
+// linked directly as well, and when it is evicted, only its page id is kept. A node is only
+// evicted once all of its children are evicted, so that the parent of a node in memory is always
+// in memory as well and can be referenced by a pointer. The root is never evicted.
+//
+// A tree operation pins the nodes it uses while it uses them (see Node.child and Node.pin), so
+// that they are not evicted. Whenever a node is loaded and at the end of every operation, the least
+// recently used unpinned nodes are evicted until at most capacity nodes are left in memory. Nodes
+// modified by an operation (see Node.modified) are dirty and written back to the store when they
+// are evicted or the pool is flushed. During a modifying operation, only clean nodes are evicted,
+// as the dirty ones may be halfway modified, e.g. overfull until their parent splits them. So the
+// pool may exceed its capacity until the operation ends.
+//
+// Several trees may share a pool and its store, see NewPagedGroupedSegmentTree. Only the root of
+// the owner is recorded as the root of the store, the roots of the other trees stay in memory.
+//
+// Pages for new nodes are allocated when they are written back or the operation ends, and the
+// pages of removed nodes are freed when the operation ends. Errors of the store are returned as
+// ErrCorruptTree wrapping the error, see SegmentTreeImpl.run.
+type BufferPool[V Addable[V], T Timestamp] struct {
+	store    NodeStore[V, T]
+	capacity int
+	owner    *SegmentTreeImpl[V, T]
+	frames   map[*Node[V, T]]*frame[V, T]
+	lru      *list.List // unpinned frames, the most recently used one at the front
+	// pins is the number of pins which were not released yet
+	pins int
+	// created contains the nodes created by the current operation, and freed the pages of the
+	// nodes removed by it
+	created   []*Node[V, T]
+	freed     []PageID
+	modifying bool
+}
+
+type frame[V Addable[V], T Timestamp] struct {
+	node     *Node[V, T]
+	pinCount int
+	dirty    bool
+	element  *list.Element // nil while pinned
+}
+
+func newBufferPool[V Addable[V], T Timestamp](store NodeStore[V, T], capacity int) *BufferPool[V, T] {
+	return &BufferPool[V, T]{
+		store:    store,
+		capacity: capacity,
+		frames:   make(map[*Node[V, T]]*frame[V, T]),
+		lru:      list.New(),
+	}
+}
+
+// Len returns the number of nodes in memory.
+func (pool *BufferPool[V, T]) Len() int {
+	return len(pool.frames)
+}
+
+func (pool *BufferPool[V, T]) begin(modifying bool) {
+	pool.modifying = modifying
+}
+
+func (pool *BufferPool[V, T]) end(root *Node[V, T]) error {
+	modifying, created, freed := pool.modifying, pool.created, pool.freed
+	pool.modifying, pool.created, pool.freed = false, nil, nil
+
+	if pool.pins > 0 {
+		// The operation failed before releasing its pins
+		for _, f := range pool.frames {
+			if f.pinCount > 0 {
+				f.pinCount = 0
+				f.element = pool.lru.PushFront(f)
+			}
+		}
+		pool.pins = 0
+	}
+
+	if modifying {
+		for _, node := range created {
+			if _, ok := pool.frames[node]; ok {
+				if err := pool.allocate(node); err != nil {
+					return err
+				}
+			}
+		}
+		for _, id := range freed {
+			if err := pool.store.Free(id); err != nil {
+				return corrupt(err)
+			}
+		}
+		if root.tree == pool.owner && pool.store.Root() != root.id {
+			if err := pool.store.SetRoot(root.id); err != nil {
+				return corrupt(err)
+			}
+		}
+	}
+
+	return pool.evict(pool.capacity)
+}
+
+// fetch returns the child of the parent at index, which is loaded if it is not in memory, and pins it.
+func (pool *BufferPool[V, T]) fetch(parent *Node[V, T], index int) (*Node[V, T], error) {
+	child := parent.children[index].node
+
+	if child == nil {
+		// Makes room for the child first, so that it is not evicted itself
+		if err := pool.evict(pool.capacity - 1); err != nil {
+			return nil, err
+		}
+
+		var err error
+		if child, err = pool.load(parent.children[index].id, parent.tree, parent, parent.getIntervalWithin(parent.bounds, uint32(index))); err != nil {
+			return nil, err
+		}
+		parent.children[index].node = child
+	}
+
+	pool.pin(child)
+
+	return child, nil
+}
+
+func (pool *BufferPool[V, T]) pin(node *Node[V, T]) {
+	f := pool.frame(node)
+
+	if f.element != nil {
+		pool.lru.Remove(f.element)
+		f.element = nil
+	}
+	f.pinCount++
+	pool.pins++
+}
+
+func (pool *BufferPool[V, T]) unpin(node *Node[V, T]) {
+	f, ok := pool.frames[node]
+	if !ok || f.pinCount == 0 {
+		return // the node was released during the operation
+	}
+
+	f.pinCount--
+	pool.pins--
+	if f.pinCount == 0 {
+		f.element = pool.lru.PushFront(f)
+	}
+}
+
+// markDirty marks a node modified by the current operation, so that it is written back.
+func (pool *BufferPool[V, T]) markDirty(node *Node[V, T]) {
+	pool.frame(node).dirty = true
+}
+
+// frame returns the frame of the node. A node created by the current operation gets a new frame.
+func (pool *BufferPool[V, T]) frame(node *Node[V, T]) *frame[V, T] {
+	f, ok := pool.frames[node]
+
+	if !ok {
+		f = &frame[V, T]{node: node, dirty: true}
+		f.element = pool.lru.PushFront(f)
+		pool.frames[node] = f
+		pool.created = append(pool.created, node)
+	}
+
+	return f
+}
+
+// release removes a node, which is not part of the tree anymore. Its page is freed at the end of
+// the operation.
+func (pool *BufferPool[V, T]) release(node *Node[V, T]) {
+	if f, ok := pool.frames[node]; ok {
+		if f.element != nil {
+			pool.lru.Remove(f.element)
+		}
+		pool.pins -= f.pinCount
+		delete(pool.frames, node)
+	}
+
+	if node.id != NoPage {
+		pool.freed = append(pool.freed, node.id)
+		node.id = NoPage
+	}
+}
+
+// Flush writes all dirty nodes back to the store and flushes the store.
+func (pool *BufferPool[V, T]) Flush() error {
+	for _, f := range pool.frames {
+		if err := pool.writeBack(f); err != nil {
+			return err
+		}
+	}
+
+	return pool.store.Flush()
+}
+
+// allocate allocates a page for a node created by the current operation.
+func (pool *BufferPool[V, T]) allocate(node *Node[V, T]) error {
+	if node.id != NoPage {
+		return nil
+	}
+
+	id, err := pool.store.Allocate()
+	if err != nil {
+		return corrupt(err)
+	}
+	node.id = id
+
+	return nil
+}
+
+// evict evicts the least recently used nodes until at most limit nodes are left in memory or all
+// remaining nodes are pinned, the root, have children in memory or are dirty during a modifying
+// operation.
+func (pool *BufferPool[V, T]) evict(limit int) error {
+	for len(pool.frames) > limit {
+		evicted := false
+
+		for element := pool.lru.Back(); element != nil && len(pool.frames) > limit; {
+			previous := element.Prev()
+			f := element.Value.(*frame[V, T])
+			node := f.node
+
+			if node.parent != nil && !node.hasLoadedChildren() && !(pool.modifying && f.dirty) {
+				if err := pool.allocate(node); err != nil {
+					return err
+				}
+				if err := pool.writeBack(f); err != nil {
+					return corrupt(err)
+				}
+				pool.lru.Remove(element)
+				delete(pool.frames, node)
+				node.parent.children[node.parent.findChildIndex(node)] = link[V, T]{id: node.id}
+				node.evict()
+				evicted = true
+			}
+
+			element = previous
+		}
+
+		if !evicted {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (pool *BufferPool[V, T]) writeBack(f *frame[V, T]) error {
+	if !f.dirty {
+		return nil
+	}
+
+	node := f.node
+	page := NodePage[V, T]{
+		Keys:   node.keys,
+		Values: node.values,
+		IsLeaf: node.isLeaf,
+	}
+
+	if !node.isLeaf {
+		page.Children = make([]PageID, len(node.children))
+		for i, child := range node.children {
+			page.Children[i] = child.id
+			if child.node != nil {
+				page.Children[i] = child.node.id
+			}
+		}
+	}
+
+	if err := pool.store.Write(node.id, page); err != nil {
+		return err
+	}
+	f.dirty = false
+
+	return nil
+}
+
+// load reads the node from its page. Its children are linked by their page ids only.
+func (pool *BufferPool[V, T]) load(id PageID, tree *SegmentTreeImpl[V, T], parent *Node[V, T], bounds Interval[T]) (*Node[V, T], error) {
+	page, err := pool.store.Read(id)
+	if err != nil {
+		return nil, corrupt(fmt.Errorf("reading page %d: %w", id, err))
+	}
+
+	node := &Node[V, T]{
+		keys:   page.Keys,
+		values: page.Values,
+		isLeaf: page.IsLeaf,
+		parent: parent,
+		tree:   tree,
+		id:     id,
+		bounds: bounds,
+	}
+
+	if !node.isLeaf {
+		node.children = make([]link[V, T], len(page.Children))
+		for i, child := range page.Children {
+			node.children[i] = link[V, T]{id: child}
+		}
+	}
+
+	f := &frame[V, T]{node: node}
+	f.element = pool.lru.PushFront(f)
+	pool.frames[node] = f
+
+	return node, nil
+}
@@ -0,0 +1,384 @@
+package segmenttree
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPagedTreeDosageScenario(t *testing.T) {
+	// Arrange
+	store := NewMemoryNodeStore[Float, uint32]()
+	tree := must(NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float](), store, 2))
+
+	// Act
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+
+	// Assert
+	for _, td := range testDataGetAtInstant {
+		assert.Equal(t, td.expectedValue, must(tree.GetAtInstant(td.instant)), "instant %d", td.instant)
+	}
+	assert.LessOrEqual(t, tree.pool.Len(), 2)
+}
+
+func TestPagedTreeMatchesInMemoryTree(t *testing.T) {
+	forEachValueType(t, testPagedTreeMatchesInMemoryTree[Float], testPagedTreeMatchesInMemoryTree[Float64], testPagedTreeMatchesInMemoryTree[Int64], testPagedTreeMatchesInMemoryTree[Decimal])
+}
+
+func testPagedTreeMatchesInMemoryTree[V scalarValue[V]](t *testing.T) {
+	for _, capacity := range []int{1, 3, 10} {
+		// Arrange
+		random := rand.New(rand.NewSource(int64(capacity)))
+		aggregate := SumAggregate[V]()
+		store := NewMemoryNodeStore[V, uint32]()
+		paged := must(NewPagedSegmentTree[V, uint32](BRANCHING_FACTOR, aggregate, store, capacity))
+		reference := NewSegmentTree[V, uint32](BRANCHING_FACTOR, aggregate)
+		inserted := []ValueIntervalTuple[V, uint32]{}
+
+		for step := 0; step < 300; step++ {
+			// Act
+			if len(inserted) > 0 && random.Intn(3) == 0 {
+				i := random.Intn(len(inserted))
+				paged.Delete(inserted[i])
+				reference.Delete(inserted[i])
+				inserted = append(inserted[:i], inserted[i+1:]...)
+			} else {
+				start := uint32(random.Intn(100))
+				tuple := ValueIntervalTuple[V, uint32]{
+					value:    scalar[V](random.Intn(5) + 1),
+					interval: NewInterval(start, start+1+uint32(random.Intn(10))),
+				}
+				paged.Insert(tuple)
+				reference.Insert(tuple)
+				inserted = append(inserted, tuple)
+			}
+
+			// Assert
+			assert.LessOrEqual(t, paged.pool.Len(), capacity+1, "capacity %d, step %d", capacity, step)
+			if !assert.Equal(t, must(reference.GetWithinInterval(NewInterval[uint32](0, 120))), must(paged.GetWithinInterval(NewInterval[uint32](0, 120))), "capacity %d, step %d", capacity, step) {
+				return
+			}
+		}
+
+		assert.NoError(t, paged.Flush())
+		assert.Equal(t, countNodes(reference.root), store.PageCount(), "capacity %d", capacity)
+	}
+}
+
+func TestPagedTreeReopenFromFile(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "tree.db")
+	aggregate := SumAggregate[Float]()
+
+	store, _ := OpenFileNodeStore[Float, uint32](path, DefaultPageSize, FloatCodec{})
+	tree := must(NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, aggregate, store, 4))
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+	expected := must(tree.GetWithinInterval(NewInterval[uint32](0, 60)))
+	assert.NoError(t, tree.Close())
+
+	// Act
+	reopenedStore, err := OpenFileNodeStore[Float, uint32](path, DefaultPageSize, FloatCodec{})
+	assert.NoError(t, err)
+	reopened := must(NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, aggregate, reopenedStore, 4))
+	defer reopened.Close()
+
+	// Assert
+	assert.Equal(t, expected, must(reopened.GetWithinInterval(NewInterval[uint32](0, 60))))
+	for _, td := range testDataGetAtInstant {
+		assert.Equal(t, td.expectedValue, must(reopened.GetAtInstant(td.instant)), "instant %d", td.instant)
+	}
+}
+
+func TestPagedTreeQueryKeepsFewNodesInMemory(t *testing.T) {
+	// Arrange
+	const capacity = 3
+	store := &countingNodeStore{MemoryNodeStore: NewMemoryNodeStore[Float, uint32]()}
+	tree := must(NewPagedSegmentTree[Float, uint32](4, SumAggregate[Float](), store, capacity))
+	reference := NewSegmentTree[Float, uint32](4, SumAggregate[Float]())
+	for i := uint32(0); i < 200; i++ {
+		tuple := NewValueIntervalTuple(Float(i%7+1), NewInterval(2*i, 2*i+1))
+		tree.Insert(tuple)
+		reference.Insert(tuple)
+	}
+	assert.NoError(t, tree.Flush())
+	// Both trees have the same shape
+	height := 1
+	for node := reference.root; !node.isLeaf; node = node.children[0].node {
+		height++
+	}
+	resident := 0
+	store.onRead = func() { resident = max(resident, tree.pool.Len()) }
+	store.writes = 0
+
+	// Act
+	pieces, err := tree.GetWithinInterval(NewInterval[uint32](0, 400))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, pieces, 400)
+	assert.LessOrEqual(t, resident, capacity+height)
+	assert.LessOrEqual(t, tree.pool.Len(), capacity)
+	// Nodes which were only read are not written back
+	assert.NoError(t, tree.Flush())
+	assert.Zero(t, store.writes)
+}
+
+// A query of a few pieces only reads the nodes on the path to them, not the whole tree.
+func TestPagedTreeQueryReadsOnlyNodesWithinInterval(t *testing.T) {
+	// Arrange
+	store := &countingNodeStore{MemoryNodeStore: NewMemoryNodeStore[Float, uint32]()}
+	tree := must(NewPagedSegmentTree[Float, uint32](4, SumAggregate[Float](), store, 1000))
+	reference := NewSegmentTree[Float, uint32](4, SumAggregate[Float]())
+	for i := uint32(0); i < 500; i++ {
+		tuple := NewValueIntervalTuple(Float(i%7+1), NewInterval(2*i, 2*i+1))
+		tree.Insert(tuple)
+		reference.Insert(tuple)
+	}
+	assert.NoError(t, tree.Flush())
+	height := 1
+	for node := reference.root; !node.isLeaf; node = node.children[0].node {
+		height++
+	}
+	reopened := must(NewPagedSegmentTree[Float, uint32](4, SumAggregate[Float](), store, 1000))
+	reads := 0
+	store.onRead = func() { reads++ }
+
+	// Act
+	pieces, err := reopened.GetWithinInterval(NewInterval[uint32](500, 520))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, pieces, 20)
+	// Every leaf holds at least one piece, so that at most 20 leaves and their ancestors are read
+	assert.LessOrEqual(t, reads, 2*height+20)
+	assert.Less(t, reads, countNodes(reference.root)/5)
+}
+
+func TestPagedTreeIterationKeepsFewNodesInMemory(t *testing.T) {
+	// Arrange
+	const capacity = 3
+	tree := must(NewPagedSegmentTree[Float, uint32](4, SumAggregate[Float](), NewMemoryNodeStore[Float, uint32](), capacity))
+	for i := uint32(0); i < 200; i++ {
+		tree.Insert(NewValueIntervalTuple(Float(i%7+1), NewInterval(2*i, 2*i+1)))
+	}
+	count := 0
+
+	// Act
+	for interval, value := range tree.Iterate(NewInterval[uint32](0, 400)) {
+		// Assert
+		if count%2 == 0 {
+			assert.Equal(t, NewInterval(uint32(count), uint32(count+1)), interval)
+			assert.Equal(t, Float(count/2%7+1), value)
+		}
+		assert.LessOrEqual(t, tree.pool.Len(), capacity)
+		count++
+	}
+	assert.Equal(t, 400, count)
+}
+
+func TestNewPagedTreeWithTooLargeBranchingFactor(t *testing.T) {
+	// Arrange
+	store, _ := OpenFileNodeStore[Float, uint32](filepath.Join(t.TempDir(), "tree.db"), 64, FloatCodec{})
+	defer store.Close()
+
+	// Act
+	tree, err := NewPagedSegmentTree[Float, uint32](64, SumAggregate[Float](), store, 4)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrPageOverflow)
+	assert.Nil(t, tree)
+}
+
+func TestNewPagedTreeWithNonInvertibleAggregate(t *testing.T) {
+	// Act
+	tree, err := NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, MaxAggregate(Float(math.Inf(-1))), NewMemoryNodeStore[Float, uint32](), 4)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrNotInvertible)
+	assert.Nil(t, tree)
+}
+
+func TestNewPagedTreeWithFailingStore(t *testing.T) {
+	// Arrange
+	store := &failingNodeStore{MemoryNodeStore: NewMemoryNodeStore[Float, uint32](), failAllocate: true}
+
+	// Act
+	tree, err := NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float](), store, 4)
+
+	// Assert
+	assert.ErrorIs(t, err, errAllocate)
+	assert.Nil(t, tree)
+}
+
+func TestPagedTreeWithFailingStore(t *testing.T) {
+	// Arrange
+	store := &failingNodeStore{MemoryNodeStore: NewMemoryNodeStore[Float, uint32]()}
+	tree := must(NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float](), store, 2))
+	tree.Insert(dosageTestData[Float]()[0])
+	store.failAllocate = true
+
+	// Act
+	var err error
+	for _, tuple := range dosageTestData[Float]()[1:] {
+		if err = tree.Insert(tuple); err != nil {
+			break
+		}
+	}
+
+	// Assert
+	assert.ErrorIs(t, err, ErrCorruptTree)
+	assert.ErrorIs(t, err, errAllocate)
+	_, queryErr := tree.GetAtInstant(12)
+	assert.ErrorIs(t, queryErr, errAllocate)
+	assert.ErrorIs(t, tree.Flush(), errAllocate)
+}
+
+func TestNewPagedTreeWithCorruptRoot(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "tree.db")
+	store, _ := OpenFileNodeStore[Float, uint32](path, DefaultPageSize, FloatCodec{})
+	tree := must(NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float](), store, 4))
+	root := store.Root()
+	tree.Close()
+	file, _ := os.OpenFile(path, os.O_RDWR, 0644)
+	file.WriteAt([]byte{0xff, 0xff}, int64(root)*DefaultPageSize+1)
+	file.Close()
+	store, _ = OpenFileNodeStore[Float, uint32](path, DefaultPageSize, FloatCodec{})
+	defer store.Close()
+
+	// Act
+	tree, err := NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float](), store, 4)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrCorruptTree)
+	assert.ErrorIs(t, err, ErrCorruptPage)
+	assert.Nil(t, tree)
+}
+
+func TestPagedTreeWritesNoPagesOfFailedInsert(t *testing.T) {
+	// Arrange
+	store := &failingNodeStore{MemoryNodeStore: NewMemoryNodeStore[Float, uint32]()}
+	tree := must(NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float](), store, 2))
+	for i := uint32(0); i < 200; i++ {
+		tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval(2*i, 2*i+1), value: Float(i%5 + 1)})
+	}
+	assert.NoError(t, tree.Flush())
+	expected := must(tree.GetWithinInterval(NewInterval[uint32](0, 400)))
+
+	// Act
+	// The insert modifies the leaf of its start before it fails to load the nodes of its end
+	store.readBudget = 6
+	insertErr := tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](1, 399), value: Float(1)})
+	store.failRead = false
+	reopened, err := NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float](), store.MemoryNodeStore, 2)
+
+	// Assert
+	assert.ErrorIs(t, insertErr, errRead)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, must(reopened.GetWithinInterval(NewInterval[uint32](0, 400))))
+}
+
+func TestPagedTreeWithFailingReads(t *testing.T) {
+	// Arrange
+	store := &failingNodeStore{MemoryNodeStore: NewMemoryNodeStore[Float, uint32]()}
+	tree := must(NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float](), store, 2))
+	for i := uint32(0); i < 100; i++ {
+		tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval(2*i, 2*i+1), value: Float(i%5 + 1)})
+	}
+
+	// Act
+	store.failRead = true
+	_, queryErr := tree.GetAtInstant(12)
+	_, cursorErr := tree.GetWithinInterval(NewInterval[uint32](0, 200))
+	store.failRead = false
+	value, afterQueryErr := tree.GetAtInstant(12)
+	store.failRead = true
+	insertErr := tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](150, 160), value: Float(1)})
+	store.failRead = false
+	_, afterInsertErr := tree.GetAtInstant(12)
+
+	// Assert
+	assert.ErrorIs(t, queryErr, ErrCorruptTree)
+	assert.ErrorIs(t, queryErr, errRead)
+	assert.ErrorIs(t, cursorErr, errRead)
+	// A failed query leaves the tree unchanged, but a failed insert may have modified it partially
+	assert.NoError(t, afterQueryErr)
+	assert.Equal(t, Float(2), value)
+	assert.ErrorIs(t, insertErr, errRead)
+	assert.ErrorIs(t, afterInsertErr, errRead)
+}
+
+var (
+	errAllocate = errors.New("no space left")
+	errRead     = errors.New("bad sector")
+)
+
+// failingNodeStore fails to allocate pages once failAllocate is set and to read them once
+// failRead is set. Setting readBudget sets failRead after that many reads.
+type failingNodeStore struct {
+	*MemoryNodeStore[Float, uint32]
+	failAllocate bool
+	failRead     bool
+	readBudget   int
+}
+
+func (store *failingNodeStore) Read(id PageID) (NodePage[Float, uint32], error) {
+	if store.failRead {
+		return NodePage[Float, uint32]{}, errRead
+	}
+	if store.readBudget > 0 {
+		store.readBudget--
+		store.failRead = store.readBudget == 0
+	}
+
+	return store.MemoryNodeStore.Read(id)
+}
+
+func (store *failingNodeStore) Allocate() (PageID, error) {
+	if store.failAllocate {
+		return NoPage, errAllocate
+	}
+
+	return store.MemoryNodeStore.Allocate()
+}
+
+func countNodes[V Addable[V], T Timestamp](node *Node[V, T]) int {
+	count := 1
+	if !node.isLeaf {
+		for _, child := range node.children {
+			count += countNodes(child.node)
+		}
+	}
+
+	return count
+}
+
+// countingNodeStore counts the written pages and calls onRead before every read.
+type countingNodeStore struct {
+	*MemoryNodeStore[Float, uint32]
+	writes int
+	onRead func()
+}
+
+func (store *countingNodeStore) Read(id PageID) (NodePage[Float, uint32], error) {
+	if store.onRead != nil {
+		store.onRead()
+	}
+
+	return store.MemoryNodeStore.Read(id)
+}
+
+func (store *countingNodeStore) Write(id PageID, page NodePage[Float, uint32]) error {
+	store.writes++
+
+	return store.MemoryNodeStore.Write(id, page)
+}
@@ -1,48 +1,168 @@
 package segmenttree
 
-func createAndSortValueTimeTuples(aggregate Aggregate, values []ValueIntervalTuple) []ValueTimeTuple {
-	result := make([]ValueTimeTuple, 0, 2*len(values))
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+)
+
+var ErrInvalidFillFactor = errors.New("fill factor is not within (0, 1]")
+
+// DefaultFillFactor is the fill factor of a tree unless set with SetFillFactor. It leaves room
+// for later inserts, so that they do not split every node they touch.
+const DefaultFillFactor = 0.75
+
+// SetFillFactor sets the share of the branching factor to which the nodes are filled when the
+// tree is built at once, i.e. by InsertRange, MergeRange and Merge. Nodes are at least half full
+// anyway, as required for all nodes but the root.
+func (tree *SegmentTreeImpl[V, T]) SetFillFactor(fillFactor float64) error {
+	if !(fillFactor > 0 && fillFactor <= 1) {
+		return fmt.Errorf("%w: %v", ErrInvalidFillFactor, fillFactor)
+	}
+
+	tree.fillFactor = fillFactor
+	return nil
+}
+
+// createAndSortValueTimeTuples returns the start and end points of the values sorted by time.
+// The points at the same time are combined into one, which is dropped if they cancel out.
+func createAndSortValueTimeTuples[V Addable[V], T Timestamp](aggregate Aggregate[V], values []ValueIntervalTuple[V, T]) []ValueTimeTuple[V, T] {
+	points := make([]ValueTimeTuple[V, T], 0, 2*len(values))
 
 	for _, value := range values {
-		positiveTuple := ValueTimeTuple{
-			value: value.value,
-			time:  value.interval.start,
-		}
+		valueToInsert := aggregate.additionElement(value.value)
+
+		points = append(points,
+			ValueTimeTuple[V, T]{value: valueToInsert, time: value.interval.start},
+			ValueTimeTuple[V, T]{value: aggregate.inverseOperation(aggregate.neutralElement, valueToInsert), time: value.interval.end},
+		)
+	}
+
+	slices.SortFunc(points, func(x, y ValueTimeTuple[V, T]) int {
+		return cmp.Compare(x.time, y.time)
+	})
+
+	result := points[:0]
+	for _, point := range points {
+		last := len(result) - 1
 
-		negativeTuple := ValueTimeTuple{
-			value: aggregate.inverseOperation(aggregate.neutralElement, value.value),
-			time:  value.interval.end,
+		if last < 0 || result[last].time != point.time {
+			result = append(result, point)
+			continue
 		}
 
-		result = insertInOrder(aggregate, positiveTuple, result)
-		result = insertInOrder(aggregate, negativeTuple, result)
+		result[last].value = aggregate.combine(point.value, result[last].value)
+		if equalValues(result[last].value, aggregate.neutralElement) {
+			result = result[:last]
+		}
 	}
 
 	return result
 }
 
-func insertInOrder(aggregate Aggregate, toInsert ValueTimeTuple, values []ValueTimeTuple) []ValueTimeTuple {
-	for i := 0; i < len(values); i++ {
-		if toInsert.time > values[i].time {
-			continue
-		} else if toInsert.time == values[i].time {
-			additionResult := aggregate.operation(toInsert.value, values[i].value)
-
-			if additionResult == aggregate.neutralElement {
-				// Remove element
-				return append(values[:i], values[i+1:]...)
-			} else {
-				// Replace existing element with combined element
-				values[i] = ValueTimeTuple{value: additionResult, time: toInsert.time}
-				return values
+// deltaPieces returns the pieces covering the whole timeline whose values aggregate the values
+// containing them. The aggregate has to be invertible, as the value is removed again at the end
+// of its interval.
+func deltaPieces[V Addable[V], T Timestamp](aggregate Aggregate[V], values []ValueIntervalTuple[V, T]) []ValueIntervalTuple[V, T] {
+	pieces := []ValueIntervalTuple[V, T]{}
+	current, start := aggregate.neutralElement, T(0)
+
+	for _, point := range createAndSortValueTimeTuples(aggregate, values) {
+		if point.time > start {
+			pieces = appendPiece(pieces, NewValueIntervalTuple(current, NewInterval(start, point.time)))
+			start = point.time
+		}
+		current = aggregate.combine(current, point.value)
+	}
+	if start < MaxInstant[T]() {
+		pieces = appendPiece(pieces, NewValueIntervalTuple(current, NewInterval(start, MaxInstant[T]())))
+	}
+
+	return pieces
+}
+
+// build replaces the nodes of the tree by new nodes holding the pieces. The pieces have to be
+// consecutive and cover the whole timeline, as returned by GetWithinInterval.
+//
+// The tree is built bottom-up: the pieces are distributed among the leaves, then the leaves among
+// their parents and so on, until a single node is left as the root.
+func (tree *SegmentTreeImpl[V, T]) build(pieces []ValueIntervalTuple[V, T]) error {
+	if err := tree.discardSubtree(tree.root); err != nil {
+		return err
+	}
+
+	nodes := make([]*Node[V, T], 0, len(pieces)/int(tree.branchingFactor)+1)
+
+	for start, size := range tree.partition(len(pieces)) {
+		leaf := tree.newNode()
+
+		for i, piece := range pieces[start : start+size] {
+			if i > 0 {
+				leaf.keys = append(leaf.keys, piece.interval.start)
+			}
+			leaf.values = append(leaf.values, piece.value)
+		}
+		leaf.bounds = NewInterval(pieces[start].interval.start, pieces[start+size-1].interval.end)
+
+		nodes = append(nodes, leaf)
+	}
+
+	for len(nodes) > 1 {
+		parents := make([]*Node[V, T], 0, len(nodes)/int(tree.branchingFactor)+1)
+
+		for start, size := range tree.partition(len(nodes)) {
+			parent := tree.newNode()
+			parent.isLeaf = false
+
+			for i, child := range nodes[start : start+size] {
+				if i > 0 {
+					parent.keys = append(parent.keys, child.bounds.start)
+				}
+				parent.values = append(parent.values, tree.aggregate.neutralElement)
+				parent.children = append(parent.children, link[V, T]{node: child})
+				child.parent = parent
 			}
-		} else {
-			// Insert element
-			values = append(values[:i+1], values[i:]...)
-			values[i] = toInsert
-			return values
+			parent.bounds = NewInterval(nodes[start].bounds.start, nodes[start+size-1].bounds.end)
+
+			parents = append(parents, parent)
 		}
+
+		nodes = parents
 	}
 
-	return append(values, toInsert)
+	tree.root = nodes[0]
+
+	return nil
+}
+
+// partition distributes count entries among consecutive nodes of one level of the tree and
+// yields the index of the first entry and the number of entries of every node. The nodes are
+// filled up to the fill factor and have about the same size.
+func (tree *SegmentTreeImpl[V, T]) partition(count int) iter.Seq2[int, int] {
+	branchingFactor := int(tree.branchingFactor)
+	// A node other than the root is merged if it is less than half full, see nmerge.
+	minimum := max(2, branchingFactor/2)
+	capacity := int(math.Ceil(cmp.Or(tree.fillFactor, DefaultFillFactor) * float64(branchingFactor)))
+	capacity = min(branchingFactor, max(minimum, capacity))
+
+	nodes := max(1, min((count+capacity-1)/capacity, count/minimum))
+
+	return func(yield func(int, int) bool) {
+		start := 0
+
+		for i := 0; i < nodes; i++ {
+			size := count / nodes
+			if i < count%nodes {
+				size++
+			}
+
+			if !yield(start, size) {
+				return
+			}
+			start += size
+		}
+	}
 }
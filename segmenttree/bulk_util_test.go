@@ -1,148 +1,209 @@
 package segmenttree
 
 import (
+	"fmt"
+	"math"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestInsertSortedWhenSortedAscending(t *testing.T) {
+func TestSplitAndSort(t *testing.T) {
 	// Arrange
-	aggregate := Aggregate{Sum, InverseSum, Identity, Float(0)}
-	list := make([]ValueTimeTuple, 0, 10)
+	aggregate := SumAggregate[Float]()
+
+	var testData []ValueIntervalTuple[Float, uint32] = []ValueIntervalTuple[Float, uint32]{
+		{interval: NewInterval[uint32](10, 40), value: Float(2)},
+		{interval: NewInterval[uint32](10, 30), value: Float(3)},
+		{interval: NewInterval[uint32](20, 40), value: Float(1)},
+		{interval: NewInterval[uint32](5, 15), value: Float(2)},
+		{interval: NewInterval[uint32](35, 45), value: Float(4)},
+		{interval: NewInterval[uint32](10, 50), value: Float(1)},
+	}
 
 	// Act
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(1), time: 1}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(2), time: 2}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(3), time: 3}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(4), time: 4}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(5), time: 5}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(6), time: 6}, list)
+	result := createAndSortValueTimeTuples(aggregate, testData)
 
 	// Assert
-	assert.Len(t, list, 6)
-	assert.Equal(t, Float(1), list[0].value)
-	assert.Equal(t, Float(2), list[1].value)
-	assert.Equal(t, Float(3), list[2].value)
-	assert.Equal(t, Float(4), list[3].value)
-	assert.Equal(t, Float(5), list[4].value)
-	assert.Equal(t, Float(6), list[5].value)
+	assert.Len(t, result, 9)
+	assert.Equal(t, ValueTimeTuple[Float, uint32]{time: 5, value: Float(2)}, result[0])
+	assert.Equal(t, ValueTimeTuple[Float, uint32]{time: 10, value: Float(6)}, result[1])
+	assert.Equal(t, ValueTimeTuple[Float, uint32]{time: 15, value: Float(-2)}, result[2])
+	assert.Equal(t, ValueTimeTuple[Float, uint32]{time: 20, value: Float(1)}, result[3])
+	assert.Equal(t, ValueTimeTuple[Float, uint32]{time: 30, value: Float(-3)}, result[4])
+	assert.Equal(t, ValueTimeTuple[Float, uint32]{time: 35, value: Float(4)}, result[5])
+	assert.Equal(t, ValueTimeTuple[Float, uint32]{time: 40, value: Float(-3)}, result[6])
+	assert.Equal(t, ValueTimeTuple[Float, uint32]{time: 45, value: Float(-4)}, result[7])
+	assert.Equal(t, ValueTimeTuple[Float, uint32]{time: 50, value: Float(-1)}, result[8])
 }
 
-func TestInsertSortedWhenSortedDescending(t *testing.T) {
+func TestSplitAndSortDropsCancellingPoints(t *testing.T) {
 	// Arrange
-	aggregate := Aggregate{Sum, InverseSum, Identity, Float(0)}
-	list := make([]ValueTimeTuple, 0, 10)
+	aggregate := SumAggregate[Float]()
+
+	var testData []ValueIntervalTuple[Float, uint32] = []ValueIntervalTuple[Float, uint32]{
+		{interval: NewInterval[uint32](3, 5), value: Float(1)},
+		{interval: NewInterval[uint32](1, 3), value: Float(1)},
+	}
 
 	// Act
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(6), time: 6}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(5), time: 5}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(4), time: 4}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(3), time: 3}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(2), time: 2}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(1), time: 1}, list)
+	result := createAndSortValueTimeTuples(aggregate, testData)
 
 	// Assert
-	assert.Len(t, list, 6)
-	assert.Equal(t, Float(1), list[0].value)
-	assert.Equal(t, Float(2), list[1].value)
-	assert.Equal(t, Float(3), list[2].value)
-	assert.Equal(t, Float(4), list[3].value)
-	assert.Equal(t, Float(5), list[4].value)
-	assert.Equal(t, Float(6), list[5].value)
+	assert.Equal(t, []ValueTimeTuple[Float, uint32]{{time: 1, value: Float(1)}, {time: 5, value: Float(-1)}}, result)
 }
 
-func TestInsertSortedWhenRandomOrder(t *testing.T) {
+func TestInsertRangeFillsNodes(t *testing.T) {
+	testData := []struct {
+		fillFactor       float64
+		expectedLeafSize float64
+	}{
+		{0.1, 5},
+		{0.5, 5},
+		{0.75, 8},
+		{1, 10},
+	}
+
+	for _, td := range testData {
+		// Arrange
+		random := rand.New(rand.NewSource(1))
+		tree := NewSegmentTree[Int64, uint32](10, SumAggregate[Int64]())
+		tree.SetFillFactor(td.fillFactor)
+		naive := NewNaiveSegmentTree[Int64, uint32](SumAggregate[Int64]())
+		values := []ValueIntervalTuple[Int64, uint32]{}
+
+		for i := 0; i < 1000; i++ {
+			start := uint32(random.Intn(5000))
+			value := NewValueIntervalTuple(Int64(random.Intn(41)-20), NewInterval(start, start+1+uint32(random.Intn(100))))
+			values = append(values, value)
+			naive.Insert(value)
+		}
+
+		// Act
+		err := tree.InsertRange(values)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, must(naive.GetWithinInterval(NewInterval[uint32](0, 6000))), must(tree.GetWithinInterval(NewInterval[uint32](0, 6000))), td.fillFactor)
+		assertBackPointers(t, tree, tree.root, nil)
+		assertBounds(t, tree.root, NewInterval(0, MaxInstant[uint32]()), td.fillFactor)
+		assertExtrema(t, tree, tree.root, NewInterval(0, MaxInstant[uint32]()))
+		leafSizes := []int{}
+		assertFill(t, tree.root, true, &leafSizes, td.fillFactor)
+		assert.InDelta(t, td.expectedLeafSize, float64(len(must(tree.GetWithinInterval(NewInterval(0, MaxInstant[uint32]())))))/float64(len(leafSizes)), 0.1, td.fillFactor)
+
+		// The tree can still be modified.
+		for _, value := range values[:100] {
+			tree.Delete(value)
+			naive.Delete(value)
+		}
+		assert.Equal(t, must(naive.GetWithinInterval(NewInterval[uint32](0, 6000))), must(tree.GetWithinInterval(NewInterval[uint32](0, 6000))), td.fillFactor)
+	}
+}
+
+func TestSetFillFactorInvalid(t *testing.T) {
 	// Arrange
-	aggregate := Aggregate{Sum, InverseSum, Identity, Float(0)}
-	list := make([]ValueTimeTuple, 0, 6)
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
 
-	// Act
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(6), time: 6}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(1), time: 1}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(4), time: 4}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(5), time: 5}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(3), time: 3}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(2), time: 2}, list)
+	for _, fillFactor := range []float64{0, -0.5, 1.5, math.NaN()} {
+		// Act
+		err := tree.SetFillFactor(fillFactor)
 
-	// Assert
-	assert.Len(t, list, 6)
-	assert.Equal(t, Float(1), list[0].value)
-	assert.Equal(t, Float(2), list[1].value)
-	assert.Equal(t, Float(3), list[2].value)
-	assert.Equal(t, Float(4), list[3].value)
-	assert.Equal(t, Float(5), list[4].value)
-	assert.Equal(t, Float(6), list[5].value)
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidFillFactor, fillFactor)
+	}
+	assert.NoError(t, tree.SetFillFactor(1))
 }
 
-func TestInsertSortedWhenMerge(t *testing.T) {
-	// Arrange
-	aggregate := Aggregate{Sum, InverseSum, Identity, Float(0)}
-	list := make([]ValueTimeTuple, 0, 6)
+// assertFill checks that all leaves have the same depth and that all nodes but the root are at
+// least half full, and collects the number of values of the leaves. It returns the height of the node.
+func assertFill[V Addable[V], T Timestamp](t *testing.T, node *Node[V, T], isRoot bool, leafSizes *[]int, msgAndArgs ...interface{}) int {
+	if !isRoot {
+		assert.GreaterOrEqual(t, len(node.values), int(node.tree.branchingFactor/2), msgAndArgs...)
+		assert.LessOrEqual(t, len(node.values), int(node.tree.branchingFactor), msgAndArgs...)
+	}
+	if node.isLeaf {
+		*leafSizes = append(*leafSizes, len(node.values))
+		return 0
+	}
 
-	// Act
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(1), time: 1}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(2), time: 2}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(3), time: 3}, list) // will be merged
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(4), time: 3}, list) // will be merged
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(5), time: 5}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(6), time: 6}, list)
+	height := assertFill(t, node.children[0].node, false, leafSizes, msgAndArgs...)
+	for _, child := range node.children[1:] {
+		assert.Equal(t, height, assertFill(t, child.node, false, leafSizes, msgAndArgs...), msgAndArgs...)
+	}
 
-	// Assert
-	assert.Len(t, list, 5)
-	assert.Equal(t, Float(1), list[0].value)
-	assert.Equal(t, Float(2), list[1].value)
-	assert.Equal(t, Float(7), list[2].value)
-	assert.Equal(t, Float(5), list[3].value)
-	assert.Equal(t, Float(6), list[4].value)
+	return height + 1
 }
 
-func TestInsertSortedWhenInverse(t *testing.T) {
-	// Arrange
-	aggregate := Aggregate{Sum, InverseSum, Identity, Float(0)}
-	list := make([]ValueTimeTuple, 0, 6)
+func BenchmarkInsertRange(b *testing.B) {
+	values := benchmarkValues(10_000_000, 1)
 
-	// Act
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(1), time: 1}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(2), time: 2}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(3), time: 3}, list)  // will be removed
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(-3), time: 3}, list) // will be removed
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(5), time: 5}, list)
-	list = insertInOrder(aggregate, ValueTimeTuple{value: Float(6), time: 6}, list)
+	for _, fillFactor := range []float64{0.5, DefaultFillFactor, 1} {
+		b.Run(fmt.Sprintf("10M intervals, fill factor %v", fillFactor), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tree := NewSegmentTree[Float64, uint32](128, SumAggregate[Float64]())
+				tree.SetFillFactor(fillFactor)
 
-	// Assert
-	assert.Len(t, list, 4)
-	assert.Equal(t, Float(1), list[0].value)
-	assert.Equal(t, Float(2), list[1].value)
-	assert.Equal(t, Float(5), list[2].value)
-	assert.Equal(t, Float(6), list[3].value)
+				if err := tree.InsertRange(values); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
 }
 
-func TestSplitAndSort(t *testing.T) {
-	// Arrange
-	aggregate := Aggregate{Sum, InverseSum, Identity, Float(0)}
-
-	var testData []ValueIntervalTuple = []ValueIntervalTuple{
-		{interval: NewInterval(10, 40), value: Float(2)},
-		{interval: NewInterval(10, 30), value: Float(3)},
-		{interval: NewInterval(20, 40), value: Float(1)},
-		{interval: NewInterval(5, 15), value: Float(2)},
-		{interval: NewInterval(35, 45), value: Float(4)},
-		{interval: NewInterval(10, 50), value: Float(1)},
+func BenchmarkInsert(b *testing.B) {
+	values := benchmarkValues(100_000, 1)
+
+	for _, copyOnWrite := range []bool{false, true} {
+		b.Run(fmt.Sprintf("100K intervals, copy on write %v", copyOnWrite), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tree := NewSegmentTree[Float64, uint32](32, SumAggregate[Float64]())
+				tree.SetCopyOnWrite(copyOnWrite)
+
+				for _, value := range values {
+					if err := tree.Insert(value); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
 	}
+}
 
-	// Act
-	result := createAndSortValueTimeTuples(aggregate, testData)
+func BenchmarkMergeRange(b *testing.B) {
+	tree := NewSegmentTree[Float64, uint32](128, SumAggregate[Float64]())
+	tree.SetCopyOnWrite(true)
+	if err := tree.InsertRange(benchmarkValues(10_000_000, 1)); err != nil {
+		b.Fatal(err)
+	}
+	root := tree.root
+
+	for _, size := range []int{1_000, 1_000_000} {
+		batch := benchmarkValues(size, 2)
+
+		b.Run(fmt.Sprintf("%d into 10M intervals", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := tree.MergeRange(batch); err != nil {
+					b.Fatal(err)
+				}
+				// The tree copies on write, so that the previous root still holds the 10M intervals.
+				tree.root = root
+			}
+		})
+	}
+}
 
-	// Assert
-	assert.Len(t, result, 9)
-	assert.Equal(t, ValueTimeTuple{time: 5, value: Float(2)}, result[0])
-	assert.Equal(t, ValueTimeTuple{time: 10, value: Float(6)}, result[1])
-	assert.Equal(t, ValueTimeTuple{time: 15, value: Float(-2)}, result[2])
-	assert.Equal(t, ValueTimeTuple{time: 20, value: Float(1)}, result[3])
-	assert.Equal(t, ValueTimeTuple{time: 30, value: Float(-3)}, result[4])
-	assert.Equal(t, ValueTimeTuple{time: 35, value: Float(4)}, result[5])
-	assert.Equal(t, ValueTimeTuple{time: 40, value: Float(-3)}, result[6])
-	assert.Equal(t, ValueTimeTuple{time: 45, value: Float(-4)}, result[7])
-	assert.Equal(t, ValueTimeTuple{time: 50, value: Float(-1)}, result[8])
+// benchmarkValues returns random intervals of up to a day within a year, in seconds.
+func benchmarkValues(count int, seed int64) []ValueIntervalTuple[Float64, uint32] {
+	random := rand.New(rand.NewSource(seed))
+	values := make([]ValueIntervalTuple[Float64, uint32], count)
+
+	for i := range values {
+		start := uint32(random.Intn(365 * 24 * 3600))
+		values[i] = NewValueIntervalTuple(Float64(random.Intn(100)+1), NewInterval(start, start+1+uint32(random.Intn(24*3600))))
+	}
+
+	return values
 }
@@ -0,0 +1,73 @@
+package segmenttree
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ConcurrentSegmentTree is a SegmentTree which can be used by several goroutines at once.
+//
+// Writers are serialized by a lock, readers do not take any lock at all. Every write copies the
+// nodes it modifies instead of changing them in place (see SegmentTreeImpl.copyOnWrite) and then
+// publishes the new root. A reader works on the root published when it started, so it never sees
+// a partially modified tree and is not blocked by writers.
+type ConcurrentSegmentTree[V Addable[V], T Timestamp] struct {
+	// tree is only accessed by the writer holding the lock. Readers only use its aggregate.
+	tree *SegmentTreeImpl[V, T]
+	root atomic.Pointer[Node[V, T]]
+	lock sync.Mutex
+}
+
+func NewConcurrentSegmentTree[V Addable[V], T Timestamp](branchingFactor uint32, aggregate Aggregate[V]) *ConcurrentSegmentTree[V, T] {
+	tree := NewSegmentTree[V, T](branchingFactor, aggregate)
+	tree.copyOnWrite = true
+
+	concurrent := &ConcurrentSegmentTree[V, T]{tree: tree}
+	concurrent.root.Store(tree.root)
+
+	return concurrent
+}
+
+func (concurrent *ConcurrentSegmentTree[V, T]) GetAtInstant(instant T) (V, error) {
+	return concurrent.tree.lookupFrom(concurrent.root.Load(), instant)
+}
+
+func (concurrent *ConcurrentSegmentTree[V, T]) GetWithinInterval(interval Interval[T]) ([]ValueIntervalTuple[V, T], error) {
+	return concurrent.tree.rangeQueryFrom(concurrent.root.Load(), interval)
+}
+
+func (concurrent *ConcurrentSegmentTree[V, T]) Integrate(interval Interval[T]) (float64, error) {
+	return concurrent.tree.integrateFrom(concurrent.root.Load(), interval)
+}
+
+func (concurrent *ConcurrentSegmentTree[V, T]) Insert(value ValueIntervalTuple[V, T]) error {
+	return concurrent.write(func() error {
+		return concurrent.tree.Insert(value)
+	})
+}
+
+func (concurrent *ConcurrentSegmentTree[V, T]) Delete(value ValueIntervalTuple[V, T]) error {
+	return concurrent.write(func() error {
+		return concurrent.tree.Delete(value)
+	})
+}
+
+func (concurrent *ConcurrentSegmentTree[V, T]) InsertRange(values []ValueIntervalTuple[V, T]) error {
+	return concurrent.write(func() error {
+		return concurrent.tree.InsertRange(values)
+	})
+}
+
+// write runs the operation holding the lock and publishes the new root. If the operation
+// fails, the tree is reverted and the published root stays the same.
+func (concurrent *ConcurrentSegmentTree[V, T]) write(operation func() error) error {
+	concurrent.lock.Lock()
+	defer concurrent.lock.Unlock()
+
+	if err := operation(); err != nil {
+		return err
+	}
+	concurrent.root.Store(concurrent.tree.root)
+
+	return nil
+}
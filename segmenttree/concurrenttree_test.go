@@ -0,0 +1,212 @@
+package segmenttree
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentTreeDosageScenario(t *testing.T) {
+	// Arrange
+	tree := NewConcurrentSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+
+	// Act
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+
+	// Assert
+	for _, td := range testDataGetAtInstant {
+		assert.Equal(t, td.expectedValue, must(tree.GetAtInstant(td.instant)), "instant %d", td.instant)
+	}
+	assert.Equal(t, must(setupTree().GetWithinInterval(NewInterval[uint32](0, math.MaxUint32))), must(tree.GetWithinInterval(NewInterval[uint32](0, math.MaxUint32))))
+}
+
+func TestConcurrentTreeKeepsOldRootsUnchanged(t *testing.T) {
+	// Arrange
+	random := rand.New(rand.NewSource(1))
+	tree := NewConcurrentSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+	oldRoot := tree.root.Load()
+	expected := must(tree.GetWithinInterval(NewInterval[uint32](0, 200)))
+
+	// Act
+	for i := 0; i < 200; i++ {
+		start := uint32(random.Intn(150))
+		tree.Insert(ValueIntervalTuple[Float, uint32]{
+			value:    Float(random.Intn(5) + 1),
+			interval: NewInterval(start, start+1+uint32(random.Intn(20))),
+		})
+	}
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Delete(tuple)
+	}
+
+	// Assert
+	assert.Equal(t, expected, must(tree.tree.rangeQuery(oldRoot, NewInterval(0, MaxInstant[uint32]()), NewInterval[uint32](0, 200), Float(0))))
+}
+
+func TestConcurrentTreeReadersAlongsideInsertingWriters(t *testing.T) {
+	// Arrange
+	// Every tuple covers the instant 500, so the value there counts the inserted tuples.
+	tree := NewConcurrentSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	const writers, insertsPerWriter, readers = 4, 200, 8
+	var done atomic.Bool
+	var wg sync.WaitGroup
+
+	// Act
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := Float(0)
+
+			for !done.Load() {
+				count := must(tree.GetAtInstant(500))
+				assert.GreaterOrEqual(t, count, last)
+				last = count
+
+				assertContiguous(t, must(tree.GetWithinInterval(NewInterval[uint32](400, 600))), NewInterval[uint32](400, 600))
+			}
+		}()
+	}
+
+	var writersDone sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		writersDone.Add(1)
+		go func(seed int64) {
+			defer writersDone.Done()
+			random := rand.New(rand.NewSource(seed))
+
+			for i := 0; i < insertsPerWriter; i++ {
+				start := uint32(random.Intn(500))
+				tree.Insert(ValueIntervalTuple[Float, uint32]{
+					value:    Float(1),
+					interval: NewInterval(start, 501+uint32(random.Intn(500))),
+				})
+			}
+		}(int64(w))
+	}
+	writersDone.Wait()
+	done.Store(true)
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, Float(writers*insertsPerWriter), must(tree.GetAtInstant(500)))
+}
+
+func TestConcurrentTreeReadersAlongsideMixedWriters(t *testing.T) {
+	// Arrange
+	aggregate := SumAggregate[Float]()
+	tree := NewConcurrentSegmentTree[Float, uint32](BRANCHING_FACTOR, aggregate)
+	const writers, operationsPerWriter, readers = 4, 300, 8
+	var done atomic.Bool
+	var wg sync.WaitGroup
+	remaining := make([][]ValueIntervalTuple[Float, uint32], writers)
+
+	// Act
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			random := rand.New(rand.NewSource(seed))
+
+			for !done.Load() {
+				start := uint32(random.Intn(200))
+				interval := NewInterval(start, start+1+uint32(random.Intn(100)))
+				result := must(tree.GetWithinInterval(interval))
+
+				assertContiguous(t, result, interval)
+				for _, piece := range result {
+					// All values are positive integers and every tuple is deleted at most once
+					assert.GreaterOrEqual(t, piece.value, Float(0))
+					assert.Equal(t, float64(piece.value), math.Round(float64(piece.value)))
+				}
+				must(tree.GetAtInstant(start))
+			}
+		}(int64(r))
+	}
+
+	var writersDone sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		writersDone.Add(1)
+		go func(w int) {
+			defer writersDone.Done()
+			random := rand.New(rand.NewSource(int64(100 + w)))
+			inserted := []ValueIntervalTuple[Float, uint32]{}
+
+			for i := 0; i < operationsPerWriter; i++ {
+				if len(inserted) > 0 && random.Intn(3) == 0 {
+					j := random.Intn(len(inserted))
+					tree.Delete(inserted[j])
+					inserted = append(inserted[:j], inserted[j+1:]...)
+				} else {
+					start := uint32(random.Intn(250))
+					tuple := ValueIntervalTuple[Float, uint32]{
+						value:    Float(random.Intn(5) + 1),
+						interval: NewInterval(start, start+1+uint32(random.Intn(30))),
+					}
+					tree.Insert(tuple)
+					inserted = append(inserted, tuple)
+				}
+			}
+			remaining[w] = inserted
+		}(w)
+	}
+	writersDone.Wait()
+	done.Store(true)
+	wg.Wait()
+
+	// Assert
+	reference := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, aggregate)
+	for _, tuples := range remaining {
+		for _, tuple := range tuples {
+			reference.Insert(tuple)
+		}
+	}
+	for instant := uint32(0); instant < 300; instant++ {
+		assert.Equal(t, must(reference.GetAtInstant(instant)), must(tree.GetAtInstant(instant)), "instant %d", instant)
+	}
+}
+
+func TestConcurrentTreeWithNonInvertibleAggregate(t *testing.T) {
+	// Arrange
+	tree := NewConcurrentSegmentTree[Float, uint32](BRANCHING_FACTOR, MaxAggregate(Float(math.Inf(-1))))
+	var wg sync.WaitGroup
+
+	// Act
+	for _, tuple := range dosageTestData[Float]() {
+		wg.Add(1)
+		go func(tuple ValueIntervalTuple[Float, uint32]) {
+			defer wg.Done()
+			tree.Insert(tuple)
+			must(tree.GetWithinInterval(NewInterval[uint32](0, 60)))
+		}(tuple)
+	}
+	wg.Wait()
+	tree.Delete(dosageTestData[Float]()[4])
+
+	// Assert
+	assert.Equal(t, Float(3), must(tree.GetAtInstant(12)))
+	assert.Equal(t, Float(2), must(tree.GetAtInstant(37)))
+	assert.Equal(t, Float(1), must(tree.GetAtInstant(42)))
+}
+
+// assertContiguous asserts that the pieces of a query result exactly cover the queried interval.
+func assertContiguous[V Addable[V], T Timestamp](t *testing.T, pieces []ValueIntervalTuple[V, T], interval Interval[T]) {
+	if !assert.NotEmpty(t, pieces) {
+		return
+	}
+
+	assert.Equal(t, interval.start, pieces[0].interval.start)
+	for i := 1; i < len(pieces); i++ {
+		assert.Equal(t, pieces[i-1].interval.end, pieces[i].interval.start)
+	}
+	assert.Equal(t, interval.end, pieces[len(pieces)-1].interval.end)
+}
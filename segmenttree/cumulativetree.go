@@ -0,0 +1,206 @@
+package segmenttree
+
+import "errors"
+
+var ErrWindowMismatch = errors.New("the window of a fixed window cumulative tree can not be changed")
+
+// CumulativeTree answers cumulative temporal aggregates: the aggregate over all
+// tuples valid at any instant within the window [t - window, t] (Yang et. al 2003).
+// A window of 0 yields the instantaneous aggregate.
+//
+// For invertible aggregates a pair of SB-trees is used (JSB-tree), one indexing
+// the tuples by start and one by end. The window can then be chosen per query.
+// Non-invertible aggregates (e.g. min and max) use a single tree in which every
+// tuple is extended by the window (MSB-tree), so the window is fixed.
+type CumulativeTree[V Addable[V], T Timestamp] struct {
+	aggregate Aggregate[V]
+	// starts contains every tuple as [start, MaxInstant), i.e. all tuples started until an instant.
+	// For a fixed window it contains every tuple as [start, end + window).
+	starts *SegmentTreeImpl[V, T]
+	// ends contains every tuple as [end, MaxInstant), i.e. all tuples ended until an instant.
+	// It is nil for a fixed window.
+	ends   *SegmentTreeImpl[V, T]
+	window T
+}
+
+// NewCumulativeTree creates a tree whose window is chosen per query. It returns ErrNotInvertible
+// for an aggregate which is not invertible, use NewFixedWindowCumulativeTree for such aggregates.
+func NewCumulativeTree[V Addable[V], T Timestamp](branchingFactor uint32, aggregate Aggregate[V]) (*CumulativeTree[V, T], error) {
+	if !aggregate.isInvertible() {
+		return nil, ErrNotInvertible
+	}
+
+	return &CumulativeTree[V, T]{
+		aggregate: aggregate,
+		starts:    NewSegmentTree[V, T](branchingFactor, aggregate),
+		ends:      NewSegmentTree[V, T](branchingFactor, aggregate),
+	}, nil
+}
+
+func NewFixedWindowCumulativeTree[V Addable[V], T Timestamp](branchingFactor uint32, aggregate Aggregate[V], window T) *CumulativeTree[V, T] {
+	return &CumulativeTree[V, T]{
+		aggregate: aggregate,
+		starts:    NewSegmentTree[V, T](branchingFactor, aggregate),
+		window:    window,
+	}
+}
+
+func (tree *CumulativeTree[V, T]) Insert(value ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(value); err != nil {
+		return err
+	}
+
+	if tree.ends == nil {
+		return tree.starts.Insert(tree.extendByWindow(value))
+	}
+
+	started, ended := tree.splitAtEnd(value)
+	if err := tree.starts.Insert(started); err != nil {
+		return err
+	}
+	if err := tree.ends.Insert(ended); err != nil {
+		// Keep both trees consistent. The aggregate is invertible, so Delete undoes the Insert.
+		return errors.Join(err, tree.starts.Delete(started))
+	}
+
+	return nil
+}
+
+func (tree *CumulativeTree[V, T]) Delete(value ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(value); err != nil {
+		return err
+	}
+
+	if tree.ends == nil {
+		return tree.starts.Delete(tree.extendByWindow(value))
+	}
+
+	started, ended := tree.splitAtEnd(value)
+	if err := tree.starts.Delete(started); err != nil {
+		return err
+	}
+	if err := tree.ends.Delete(ended); err != nil {
+		return errors.Join(err, tree.starts.Insert(started))
+	}
+
+	return nil
+}
+
+func (tree *CumulativeTree[V, T]) GetCumulativeAtInstant(instant T, window T) (V, error) {
+	if tree.ends == nil {
+		if err := tree.checkWindow(window); err != nil {
+			var zero V
+			return zero, err
+		}
+		return tree.starts.GetAtInstant(instant)
+	}
+
+	started, err := tree.starts.GetAtInstant(instant)
+	if err != nil || instant < window {
+		// No tuple can have ended before the beginning of the timeline
+		return started, err
+	}
+
+	ended, err := tree.ends.GetAtInstant(instant - window)
+	if err != nil {
+		return ended, err
+	}
+
+	return tree.aggregate.inverseOperation(started, ended), nil
+}
+
+func (tree *CumulativeTree[V, T]) GetCumulativeWithinInterval(interval Interval[T], window T) ([]ValueIntervalTuple[V, T], error) {
+	if err := checkIntervals(interval); err != nil {
+		return nil, err
+	}
+
+	if tree.ends == nil {
+		if err := tree.checkWindow(window); err != nil {
+			return nil, err
+		}
+		return tree.starts.GetWithinInterval(interval)
+	}
+
+	started, err := tree.starts.GetWithinInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+	ended, err := tree.getEndedWithinInterval(interval, window)
+	if err != nil {
+		return nil, err
+	}
+
+	// Both lists cover the interval without gaps, so we can walk through them in parallel
+	result := make([]ValueIntervalTuple[V, T], 0, len(started)+len(ended))
+
+	for i, j := 0, 0; i < len(started) && j < len(ended); {
+		intersection := started[i].interval.IntersectionWith(ended[j].interval)
+		value := tree.aggregate.inverseOperation(started[i].value, ended[j].value)
+
+		result = appendPiece(result, ValueIntervalTuple[V, T]{value: value, interval: intersection})
+
+		if started[i].interval.end == intersection.end {
+			i++
+		}
+		if ended[j].interval.end == intersection.end {
+			j++
+		}
+	}
+
+	return result, nil
+}
+
+func (tree *CumulativeTree[V, T]) getEndedWithinInterval(interval Interval[T], window T) ([]ValueIntervalTuple[V, T], error) {
+	// Returns the aggregate of all tuples ended until instant - window for every instant within the interval.
+	result := make([]ValueIntervalTuple[V, T], 0)
+
+	if interval.start < window {
+		beforeTimeline := NewInterval(interval.start, MinTime(interval.end, window))
+
+		if beforeTimeline.GetLength() > 0 {
+			result = append(result, ValueIntervalTuple[V, T]{value: tree.aggregate.neutralElement, interval: beforeTimeline})
+		}
+	}
+
+	if interval.end > window {
+		shiftedInterval := NewInterval(MaxTime(interval.start, window)-window, interval.end-window)
+
+		ended, err := tree.ends.GetWithinInterval(shiftedInterval)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tuple := range ended {
+			result = append(result, ValueIntervalTuple[V, T]{
+				value:    tuple.value,
+				interval: NewInterval(tuple.interval.start+window, tuple.interval.end+window),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+func (tree *CumulativeTree[V, T]) splitAtEnd(value ValueIntervalTuple[V, T]) (ValueIntervalTuple[V, T], ValueIntervalTuple[V, T]) {
+	started := ValueIntervalTuple[V, T]{value: value.value, interval: NewInterval(value.interval.start, MaxInstant[T]())}
+	ended := ValueIntervalTuple[V, T]{value: value.value, interval: NewInterval(value.interval.end, MaxInstant[T]())}
+
+	return started, ended
+}
+
+func (tree *CumulativeTree[V, T]) extendByWindow(value ValueIntervalTuple[V, T]) ValueIntervalTuple[V, T] {
+	end := MaxInstant[T]()
+	if value.interval.end < end-tree.window {
+		end = value.interval.end + tree.window
+	}
+
+	return ValueIntervalTuple[V, T]{value: value.value, interval: NewInterval(value.interval.start, end)}
+}
+
+func (tree *CumulativeTree[V, T]) checkWindow(window T) error {
+	if window != tree.window {
+		return ErrWindowMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package segmenttree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCumulativeAtInstant(t *testing.T) {
+	// Arrange
+	testData := []struct {
+		instant       uint32
+		window        uint32
+		expectedValue Float
+	}{
+		{12, 0, Float(8)},
+		{47, 0, Float(1)},
+		{47, 5, Float(5)},
+		{50, 10, Float(5)},
+		{3, 10, Float(0)},
+		{60, 100, Float(13)},
+	}
+
+	tree := setupCumulativeTree()
+
+	for _, td := range testData {
+		// Act
+		res := must(tree.GetCumulativeAtInstant(td.instant, td.window))
+
+		// Assert
+		assert.Equal(t, td.expectedValue, res, "instant %d, window %d", td.instant, td.window)
+	}
+}
+
+func TestGetCumulativeWithinInterval(t *testing.T) {
+	// Arrange
+	tree := setupCumulativeTree()
+
+	// Act
+	result := must(tree.GetCumulativeWithinInterval(NewInterval[uint32](40, 60), 5))
+
+	// Assert
+	assert.Len(t, result, 4)
+	assert.Equal(t, ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](40, 45), value: Float(8)}, result[0])
+	assert.Equal(t, ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](45, 50), value: Float(5)}, result[1])
+	assert.Equal(t, ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](50, 55), value: Float(1)}, result[2])
+	assert.Equal(t, ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](55, 60), value: Float(0)}, result[3])
+}
+
+func TestGetCumulativeWithinIntervalAtBeginningOfTimeline(t *testing.T) {
+	// Arrange
+	tree := setupCumulativeTree()
+
+	// Act
+	result := must(tree.GetCumulativeWithinInterval(NewInterval[uint32](0, 20), 30))
+
+	// Assert
+	assert.Len(t, result, 3)
+	assert.Equal(t, ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](0, 5), value: Float(0)}, result[0])
+	assert.Equal(t, ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](5, 10), value: Float(2)}, result[1])
+	assert.Equal(t, ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](10, 20), value: Float(8)}, result[2])
+}
+
+func TestCumulativeDelete(t *testing.T) {
+	// Arrange
+	tree := setupCumulativeTree()
+
+	// Act
+	tree.Delete(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](35, 45), value: Float(4)})
+
+	// Assert
+	assert.Equal(t, Float(1), must(tree.GetCumulativeAtInstant(47, 5)))
+	assert.Equal(t, Float(9), must(tree.GetCumulativeAtInstant(60, 100)))
+}
+
+func TestNewCumulativeTreeWithNonInvertibleAggregate(t *testing.T) {
+	// Act
+	tree, err := NewCumulativeTree[Float, uint32](BRANCHING_FACTOR, MaxAggregate(Float(math.Inf(-1))))
+
+	// Assert
+	assert.ErrorIs(t, err, ErrNotInvertible)
+	assert.Nil(t, tree)
+}
+
+func TestFixedWindowCumulativeMax(t *testing.T) {
+	// Arrange
+	tree := NewFixedWindowCumulativeTree[Float, uint32](BRANCHING_FACTOR, MaxAggregate(Float(math.Inf(-1))), 5)
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+
+	// Act
+	tree.Delete(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](10, 30), value: Float(3)})
+
+	// Assert
+	assert.Equal(t, Float(math.Inf(-1)), must(tree.GetCumulativeAtInstant(4, 5)))
+	assert.Equal(t, Float(2), must(tree.GetCumulativeAtInstant(33, 5)))
+	assert.Equal(t, Float(4), must(tree.GetCumulativeAtInstant(49, 5)))
+	assert.Equal(t, Float(1), must(tree.GetCumulativeAtInstant(54, 5)))
+	assert.Equal(t, Float(math.Inf(-1)), must(tree.GetCumulativeAtInstant(55, 5)))
+
+	result := must(tree.GetCumulativeWithinInterval(NewInterval[uint32](50, 60), 5))
+	assert.Len(t, result, 2)
+	assert.Equal(t, ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](50, 55), value: Float(1)}, result[0])
+	assert.Equal(t, ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](55, 60), value: Float(math.Inf(-1))}, result[1])
+}
+
+func TestFixedWindowCumulativeWithOtherWindow(t *testing.T) {
+	// Arrange
+	tree := NewFixedWindowCumulativeTree[Float, uint32](BRANCHING_FACTOR, MaxAggregate(Float(math.Inf(-1))), 5)
+
+	// Act
+	_, atInstantErr := tree.GetCumulativeAtInstant(10, 6)
+	_, withinIntervalErr := tree.GetCumulativeWithinInterval(NewInterval[uint32](0, 10), 6)
+
+	// Assert
+	assert.ErrorIs(t, atInstantErr, ErrWindowMismatch)
+	assert.ErrorIs(t, withinIntervalErr, ErrWindowMismatch)
+}
+
+func TestCumulativeMatchesReference(t *testing.T) {
+	// Arrange
+	random := rand.New(rand.NewSource(1))
+	tree := must(NewCumulativeTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]()))
+	tuples := make([]ValueIntervalTuple[Float, uint32], 100)
+
+	for i := range tuples {
+		start := uint32(random.Intn(200))
+		tuples[i] = ValueIntervalTuple[Float, uint32]{
+			value:    Float(random.Intn(5) + 1),
+			interval: NewInterval(start, start+1+uint32(random.Intn(20))),
+		}
+		tree.Insert(tuples[i])
+	}
+
+	for _, window := range []uint32{0, 1, 7, 50} {
+		// Act
+		result := must(tree.GetCumulativeWithinInterval(NewInterval[uint32](0, 250), window))
+
+		// Assert
+		for instant := uint32(0); instant < 250; instant++ {
+			expected := Float(0)
+			for _, tuple := range tuples {
+				if tuple.interval.start <= instant && int64(tuple.interval.end) > int64(instant)-int64(window) {
+					expected += tuple.value
+				}
+			}
+
+			assert.Equal(t, expected, must(tree.GetCumulativeAtInstant(instant, window)))
+			for _, piece := range result {
+				if piece.interval.start <= instant && instant < piece.interval.end {
+					assert.Equal(t, expected, piece.value)
+				}
+			}
+		}
+	}
+}
+
+func setupCumulativeTree() *CumulativeTree[Float, uint32] {
+	tree := must(NewCumulativeTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]()))
+
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+
+	return tree
+}
@@ -0,0 +1,89 @@
+package segmenttree
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// DecimalPlaces is the number of decimal places of a Decimal.
+const DecimalPlaces = 4
+
+const decimalScale = 10000
+
+// Decimal is a fixed-point number with DecimalPlaces decimal places, e.g. a dose in milligrams.
+// It is kept as an integer number of 1/10000, so that sums of decimals are exact.
+type Decimal int64
+
+// NewDecimal returns the decimal closest to the value.
+func NewDecimal(value float64) Decimal {
+	return Decimal(math.Round(value * decimalScale))
+}
+
+// ParseDecimal parses a decimal such as "-12.5". It rejects numbers with more than DecimalPlaces
+// decimal places instead of rounding them.
+func ParseDecimal(text string) (Decimal, error) {
+	integer, fraction, _ := strings.Cut(text, ".")
+
+	if len(fraction) > DecimalPlaces || strings.ContainsAny(fraction, "+-") || integer == "" || integer == "-" || integer == "+" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDecimal, text)
+	}
+
+	units, err := strconv.ParseInt(integer+fraction+strings.Repeat("0", DecimalPlaces-len(fraction)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDecimal, text)
+	}
+	return Decimal(units), nil
+}
+
+func (x Decimal) String() string {
+	sign := ""
+	units := uint64(x)
+	if x < 0 {
+		sign = "-"
+		units = uint64(-x)
+	}
+
+	integer := strconv.FormatUint(units/decimalScale, 10)
+	fraction := strings.TrimRight(fmt.Sprintf("%0*d", DecimalPlaces, units%decimalScale), "0")
+	if fraction == "" {
+		return sign + integer
+	}
+
+	return sign + integer + "." + fraction
+}
+
+func (x Decimal) Add(y Decimal) Decimal {
+	return x + y
+}
+
+func (x Decimal) Inverse() Decimal {
+	return -x
+}
+
+func (x Decimal) Subtract(y Decimal) Decimal {
+	return x - y
+}
+
+func (x Decimal) AsFloat64() float64 {
+	return float64(x) / decimalScale
+}
+
+func (x Decimal) IsLinear() bool {
+	return true
+}
+
+func (x Decimal) Compare(y Decimal) int {
+	if x > y {
+		return 1
+	}
+	if x < y {
+		return -1
+	}
+
+	return 0
+}
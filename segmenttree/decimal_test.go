@@ -0,0 +1,68 @@
+package segmenttree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	// Arrange
+	testData := []struct {
+		text     string
+		expected Decimal
+		output   string
+	}{
+		{"12.5", Decimal(125000), "12.5"},
+		{"-0.0001", Decimal(-1), "-0.0001"},
+		{"-3", Decimal(-30000), "-3"},
+		{"+7.25", Decimal(72500), "7.25"},
+		{"0.1000", Decimal(1000), "0.1"},
+	}
+
+	for _, td := range testData {
+		// Act
+		result, err := ParseDecimal(td.text)
+
+		// Assert
+		assert.NoError(t, err, td.text)
+		assert.Equal(t, td.expected, result, td.text)
+		assert.Equal(t, td.output, result.String(), td.text)
+	}
+}
+
+func TestParseInvalidDecimal(t *testing.T) {
+	for _, text := range []string{"", "-", ".5", "1.23456", "1.-5", "1e3", "abc", "99999999999999999"} {
+		// Act
+		_, err := ParseDecimal(text)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidDecimal, text)
+	}
+}
+
+func TestNewDecimal(t *testing.T) {
+	// Assert
+	assert.Equal(t, Decimal(1000), NewDecimal(0.1))
+	assert.Equal(t, Decimal(-12346), NewDecimal(-1.23456))
+	assert.Equal(t, 0.1, NewDecimal(0.1).AsFloat64())
+}
+
+// Sums of decimals are exact, so that the intervals are merged again once all tuples are deleted.
+func TestDecimalSumsCancelOut(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Decimal, uint32](BRANCHING_FACTOR, SumAggregate[Decimal]())
+	tuples := []ValueIntervalTuple[Decimal, uint32]{}
+	for i := uint32(0); i < 30; i++ {
+		tuples = append(tuples, ValueIntervalTuple[Decimal, uint32]{interval: NewInterval(i, i+20), value: NewDecimal(0.1 + float64(i)/100)})
+	}
+
+	// Act
+	tree.InsertRange(tuples)
+	for i := range tuples {
+		tree.Delete(tuples[(7*i)%len(tuples)])
+	}
+
+	// Assert
+	assert.Equal(t, []ValueIntervalTuple[Decimal, uint32]{{interval: NewInterval[uint32](0, 60), value: Decimal(0)}}, must(tree.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
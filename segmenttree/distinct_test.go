@@ -0,0 +1,125 @@
+package segmenttree
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func medicationTestData() []ValueIntervalTuple[DistinctSketch, uint32] {
+	return []ValueIntervalTuple[DistinctSketch, uint32]{
+		{value: NewDistinctSketch(1), interval: NewInterval[uint32](0, 30)},
+		{value: NewDistinctSketch(2), interval: NewInterval[uint32](10, 40)},
+		{value: NewDistinctSketch(1), interval: NewInterval[uint32](20, 50)},
+		{value: NewDistinctSketch(3), interval: NewInterval[uint32](35, 45)},
+	}
+}
+
+func TestDistinctCountAtInstant(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[DistinctSketch, uint32](BRANCHING_FACTOR, DistinctCountAggregate())
+	tree.InsertRange(medicationTestData())
+
+	testData := []struct {
+		instant  uint32
+		expected float64
+	}{
+		{5, 1},
+		{15, 2},
+		{25, 2},
+		{37, 3},
+		{42, 2},
+		{47, 1},
+		{55, 0},
+	}
+
+	for _, td := range testData {
+		// Act
+		result := must(tree.GetAtInstant(td.instant))
+
+		// Assert
+		assert.InDelta(t, td.expected, result.Estimate(), 0.1, td.instant)
+	}
+}
+
+func TestDistinctCountAfterDelete(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[DistinctSketch, uint32](BRANCHING_FACTOR, DistinctCountAggregate())
+	tree.InsertRange(medicationTestData())
+
+	// Act
+	tree.Delete(ValueIntervalTuple[DistinctSketch, uint32]{value: NewDistinctSketch(1), interval: NewInterval[uint32](0, 30)})
+
+	// Assert
+	assert.InDelta(t, 0, must(tree.GetAtInstant(5)).Estimate(), 0.1)
+	// Medication 1 is still taken from 20 on
+	assert.True(t, must(tree.GetAtInstant(25)).Contains(1))
+	assert.InDelta(t, 2, must(tree.GetAtInstant(25)).Estimate(), 0.1)
+	assert.Equal(t, []ValueIntervalTuple[DistinctSketch, uint32]{
+		{value: DistinctSketch{}, interval: NewInterval[uint32](0, 10)},
+		{value: NewDistinctSketch(2), interval: NewInterval[uint32](10, 20)},
+	}, must(tree.GetWithinInterval(NewInterval[uint32](0, 20))))
+}
+
+func TestDistinctCountWithinInterval(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[DistinctSketch, uint32](BRANCHING_FACTOR, DistinctCountAggregate())
+	tree.InsertRange(medicationTestData())
+
+	// Act
+	result := DistinctSketch{}
+	for _, piece := range must(tree.GetWithinInterval(NewInterval[uint32](0, 36))) {
+		result = result.Add(piece.value)
+	}
+
+	// Assert
+	assert.InDelta(t, 3, result.Estimate(), 0.1)
+	assert.True(t, result.Contains(3))
+}
+
+func TestDistinctSketchEstimate(t *testing.T) {
+	for _, count := range []int{1, 10, 30, 64} {
+		// Arrange
+		sketch := DistinctSketch{}
+
+		// Act
+		for id := 0; id < count; id++ {
+			sketch = sketch.Add(NewDistinctSketch(uint64(id))).Add(NewDistinctSketch(uint64(id)))
+		}
+
+		// Assert
+		assert.InEpsilon(t, float64(count), sketch.Estimate(), 0.1, count)
+	}
+}
+
+func TestDistinctCountMatchesNaiveTree(t *testing.T) {
+	// Arrange
+	random := rand.New(rand.NewSource(1))
+	tree := NewSegmentTree[DistinctSketch, uint32](BRANCHING_FACTOR, DistinctCountAggregate())
+	reference := NewNaiveSegmentTree[DistinctSketch, uint32](DistinctCountAggregate())
+	inserted := []ValueIntervalTuple[DistinctSketch, uint32]{}
+
+	// Act
+	for step := 0; step < 300; step++ {
+		if len(inserted) > 0 && random.Intn(3) == 0 {
+			i := random.Intn(len(inserted))
+			tree.Delete(inserted[i])
+			reference.Delete(inserted[i])
+			inserted = append(inserted[:i], inserted[i+1:]...)
+		} else {
+			start := uint32(random.Intn(100))
+			tuple := ValueIntervalTuple[DistinctSketch, uint32]{
+				value:    NewDistinctSketch(uint64(random.Intn(20))),
+				interval: NewInterval(start, start+1+uint32(random.Intn(30))),
+			}
+			tree.Insert(tuple)
+			reference.Insert(tuple)
+			inserted = append(inserted, tuple)
+		}
+	}
+
+	// Assert
+	interval := NewInterval(uint32(0), MaxInstant[uint32]())
+	assert.Equal(t, must(reference.GetWithinInterval(interval)), must(tree.GetWithinInterval(interval)))
+}
@@ -0,0 +1,344 @@
+package segmenttree
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+)
+
+var ErrInvalidCheckpoint = errors.New("invalid checkpoint file")
+
+var checkpointMagic = []byte("SBCKPT02")
+
+const (
+	checkpointFileName = "checkpoint"
+	logFileName        = "wal"
+)
+
+const (
+	// The checkpoint holds the pieces of the aggregated timeline.
+	checkpointPieces byte = iota
+	// The checkpoint holds the inserted tuples, as a tree with a non-invertible
+	// aggregate needs them to recompute itself on delete.
+	checkpointTuples
+)
+
+// DurableSegmentTree is an in-memory segment tree whose operations are made durable by a
+// WriteAheadLog. Every Insert and Delete is appended to the log before it is applied.
+//
+// Checkpoint writes the content of the tree to a checkpoint file and empties the log. When
+// the tree is opened again, it is restored from the last checkpoint and the operations
+// logged after it are replayed.
+type DurableSegmentTree[V Addable[V], T Timestamp] struct {
+	tree  *SegmentTreeImpl[V, T]
+	log   *WriteAheadLog[V, T]
+	codec ValueCodec[V]
+	dir   string
+}
+
+// OpenDurableSegmentTree opens the tree stored in the directory dir or creates a new one.
+// branchingFactor and aggregate have to be the same every time the tree is opened. The aggregate
+// has to have a name (see WithName), which is recorded in the checkpoint and checked on open.
+func OpenDurableSegmentTree[V Addable[V], T Timestamp](dir string, branchingFactor uint32, aggregate Aggregate[V], codec ValueCodec[V], policy SyncPolicy) (*DurableSegmentTree[V, T], error) {
+	if _, err := aggregate.kind(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	durable := &DurableSegmentTree[V, T]{
+		tree:  NewSegmentTree[V, T](branchingFactor, aggregate),
+		codec: codec,
+		dir:   dir,
+	}
+	// A failed operation is reverted and removed from the log, so that the tree stays usable.
+	durable.tree.copyOnWrite = true
+
+	checkpointLSN, err := durable.readCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	log, records, err := OpenWriteAheadLog[V, T](filepath.Join(dir, logFileName), codec, policy)
+	if err != nil {
+		return nil, err
+	}
+	durable.log = log
+
+	for _, record := range records {
+		if record.LSN > checkpointLSN {
+			if err := durable.apply(record); err != nil {
+				log.Close()
+				return nil, err
+			}
+		}
+	}
+
+	if log.NextLSN() <= checkpointLSN {
+		// The log was emptied by the checkpoint or only holds operations contained in it
+		if err := log.Reset(checkpointLSN + 1); err != nil {
+			log.Close()
+			return nil, err
+		}
+	}
+
+	return durable, nil
+}
+
+func (durable *DurableSegmentTree[V, T]) GetAtInstant(instant T) (V, error) {
+	return durable.tree.GetAtInstant(instant)
+}
+
+func (durable *DurableSegmentTree[V, T]) GetWithinInterval(interval Interval[T]) ([]ValueIntervalTuple[V, T], error) {
+	return durable.tree.GetWithinInterval(interval)
+}
+
+func (durable *DurableSegmentTree[V, T]) Integrate(interval Interval[T]) (float64, error) {
+	return durable.tree.Integrate(interval)
+}
+
+func (durable *DurableSegmentTree[V, T]) Insert(value ValueIntervalTuple[V, T]) error {
+	return durable.logAndApply(LogInsert, value)
+}
+
+func (durable *DurableSegmentTree[V, T]) Delete(value ValueIntervalTuple[V, T]) error {
+	return durable.logAndApply(LogDelete, value)
+}
+
+// InsertRange inserts the values one by one, as every one of them is logged. If one of them
+// can not be inserted, the values inserted before are removed from the tree and the log again.
+func (durable *DurableSegmentTree[V, T]) InsertRange(values []ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(values...); err != nil {
+		return err
+	}
+	if durable.tree.root.size() > 0 {
+		return ErrTreeNotEmpty
+	}
+
+	empty := durable.tree.root
+	firstLSN := durable.log.NextLSN()
+
+	for _, value := range values {
+		if err := durable.Insert(value); err != nil {
+			durable.tree.root = empty
+			durable.tree.tuples = nil
+			return errors.Join(err, durable.log.truncate(firstLSN))
+		}
+	}
+
+	return nil
+}
+
+// Checkpoint writes the content of the tree to the checkpoint file and empties the log.
+// The new checkpoint file replaces the old one atomically.
+func (durable *DurableSegmentTree[V, T]) Checkpoint() error {
+	lsn := durable.log.NextLSN() - 1
+
+	if err := durable.writeCheckpoint(lsn); err != nil {
+		return err
+	}
+
+	return durable.log.Reset(lsn + 1)
+}
+
+// Close syncs and closes the log. It does not write a checkpoint.
+func (durable *DurableSegmentTree[V, T]) Close() error {
+	return durable.log.Close()
+}
+
+func (durable *DurableSegmentTree[V, T]) logAndApply(operation LogOperation, value ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(value); err != nil {
+		return err
+	}
+
+	lsn, err := durable.log.Append(operation, value)
+	if err != nil {
+		return err
+	}
+
+	if err := durable.apply(LogRecord[V, T]{LSN: lsn, Operation: operation, Tuple: value}); err != nil {
+		// The failed operation was reverted, so it must not be replayed either
+		return errors.Join(err, durable.log.truncate(lsn))
+	}
+
+	return nil
+}
+
+func (durable *DurableSegmentTree[V, T]) apply(record LogRecord[V, T]) error {
+	switch record.Operation {
+	case LogInsert:
+		return durable.tree.Insert(record.Tuple)
+	case LogDelete:
+		return durable.tree.Delete(record.Tuple)
+	}
+
+	return nil
+}
+
+// Layout of the checkpoint file:
+//
+//	magic (8 byte) | LSN (8 byte) | content (1 byte) | aggregate kind length (2 byte) | aggregate kind | neutral element
+//	number of tuples n (8 byte) | n tuples | checksum (4 byte)
+//
+// The LSN is the one of the last operation contained in the checkpoint. The aggregate kind and the
+// neutral element identify the aggregate as in a snapshot, see Aggregate.kind. The checksum is a
+// CRC-32C over everything before it.
+func (durable *DurableSegmentTree[V, T]) writeCheckpoint(lsn uint64) error {
+	content, tuples, err := durable.checkpointContent()
+	if err != nil {
+		return err
+	}
+	kind, err := durable.tree.aggregate.kind()
+	if err != nil {
+		return err
+	}
+	size := tupleSize[V, T](durable.codec)
+	header := checkpointHeaderSize(len(kind), durable.codec)
+
+	buffer := make([]byte, header+len(tuples)*size+4)
+	copy(buffer, checkpointMagic)
+	binary.LittleEndian.PutUint64(buffer[8:], lsn)
+	buffer[16] = content
+	binary.LittleEndian.PutUint16(buffer[17:], uint16(len(kind)))
+	copy(buffer[19:], kind)
+	durable.codec.Encode(buffer[19+len(kind):], durable.tree.aggregate.neutralElement)
+	binary.LittleEndian.PutUint64(buffer[header-8:], uint64(len(tuples)))
+
+	position := header
+	for _, tuple := range tuples {
+		encodeTuple(buffer[position:], tuple, durable.codec)
+		position += size
+	}
+	binary.LittleEndian.PutUint32(buffer[position:], crc32.Checksum(buffer[:position], crcTable))
+
+	path := filepath.Join(durable.dir, checkpointFileName)
+	if err := writeFileAtomically(path, buffer); err != nil {
+		return err
+	}
+
+	return syncDir(durable.dir)
+}
+
+// readCheckpoint restores the tree from the checkpoint file and returns the LSN of the checkpoint.
+func (durable *DurableSegmentTree[V, T]) readCheckpoint() (uint64, error) {
+	buffer, err := os.ReadFile(filepath.Join(durable.dir, checkpointFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	size := tupleSize[V, T](durable.codec)
+
+	if len(buffer) < 19 || string(buffer[:8]) != string(checkpointMagic) {
+		return 0, ErrInvalidCheckpoint
+	}
+
+	kindLength := int(binary.LittleEndian.Uint16(buffer[17:]))
+	header := checkpointHeaderSize(kindLength, durable.codec)
+	if len(buffer) < header+4 {
+		return 0, ErrInvalidCheckpoint
+	}
+
+	count := binary.LittleEndian.Uint64(buffer[header-8:])
+	if uint64(len(buffer)-header-4) != count*uint64(size) {
+		return 0, ErrInvalidCheckpoint
+	}
+
+	end := len(buffer) - 4
+	if binary.LittleEndian.Uint32(buffer[end:]) != crc32.Checksum(buffer[:end], crcTable) {
+		return 0, ErrInvalidCheckpoint
+	}
+
+	kind, err := durable.tree.aggregate.kind()
+	if err != nil {
+		return 0, err
+	}
+	neutralElement := durable.codec.Decode(buffer[19+kindLength:])
+	if string(buffer[19:19+kindLength]) != kind || neutralElement != durable.tree.aggregate.neutralElement {
+		return 0, ErrAggregateMismatch
+	}
+
+	tuples := make([]ValueIntervalTuple[V, T], count)
+	for i := range tuples {
+		tuples[i] = decodeTuple[V, T](buffer[header+i*size:], durable.codec)
+	}
+
+	switch buffer[16] {
+	case checkpointPieces:
+		err = durable.tree.restore(tuples)
+	case checkpointTuples:
+		for i := 0; i < len(tuples) && err == nil; i++ {
+			err = durable.tree.Insert(tuples[i])
+		}
+	default:
+		return 0, ErrInvalidCheckpoint
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint64(buffer[8:]), nil
+}
+
+// checkpointHeaderSize returns the size of the checkpoint up to the first tuple.
+func checkpointHeaderSize[V Addable[V]](kindLength int, codec ValueCodec[V]) int {
+	return 19 + kindLength + codec.Size() + 8
+}
+
+func (durable *DurableSegmentTree[V, T]) checkpointContent() (byte, []ValueIntervalTuple[V, T], error) {
+	if !durable.tree.aggregate.isInvertible() {
+		return checkpointTuples, durable.tree.tuples.all(nil), nil
+	}
+
+	all, err := durable.tree.GetWithinInterval(NewInterval(0, MaxInstant[T]()))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Adjacent pieces with the same value are stored as one
+	pieces := []ValueIntervalTuple[V, T]{}
+	for _, piece := range all {
+		pieces = appendPiece(pieces, piece)
+	}
+
+	return checkpointPieces, pieces, nil
+}
+
+// writeFileAtomically writes the content to a temporary file, which then replaces the file at path.
+func writeFileAtomically(path string, content []byte) error {
+	temporaryPath := path + ".tmp"
+
+	file, err := os.Create(temporaryPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryPath, path)
+}
+
+// syncDir makes a rename within the directory durable.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return file.Sync()
+}
@@ -0,0 +1,271 @@
+package segmenttree
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDurableTreeRecoversFromLog(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	tree := openTestDurableTree(t, dir)
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+	crash(tree)
+
+	// Act
+	recovered := openTestDurableTree(t, dir)
+	defer recovered.Close()
+
+	// Assert
+	for _, td := range testDataGetAtInstant {
+		assert.Equal(t, td.expectedValue, must(recovered.GetAtInstant(td.instant)), "instant %d", td.instant)
+	}
+}
+
+func TestDurableTreeRecoversFromCheckpointAndLog(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	tree := openTestDurableTree(t, dir)
+	for _, tuple := range dosageTestData[Float]()[:3] {
+		tree.Insert(tuple)
+	}
+	assert.NoError(t, tree.Checkpoint())
+	for _, tuple := range dosageTestData[Float]()[3:] {
+		tree.Insert(tuple)
+	}
+	tree.Delete(dosageTestData[Float]()[0])
+	expected := must(tree.GetWithinInterval(NewInterval[uint32](0, 60)))
+	crash(tree)
+
+	// Act
+	recovered := openTestDurableTree(t, dir)
+	defer recovered.Close()
+
+	// Assert
+	assert.Equal(t, expected, must(recovered.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
+
+func TestDurableTreeRestoresLargeCheckpoint(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	random := rand.New(rand.NewSource(1))
+	tree := openTestDurableTree(t, dir)
+	for i := 0; i < 200; i++ {
+		start := uint32(random.Intn(500))
+		tree.Insert(ValueIntervalTuple[Float, uint32]{
+			value:    Float(random.Intn(5) + 1),
+			interval: NewInterval(start, start+1+uint32(random.Intn(30))),
+		})
+	}
+	assert.NoError(t, tree.Checkpoint())
+	crash(tree)
+
+	// Act
+	recovered := openTestDurableTree(t, dir)
+	defer recovered.Close()
+
+	// Assert
+	for instant := uint32(0); instant < 550; instant++ {
+		assert.Equal(t, must(tree.GetAtInstant(instant)), must(recovered.GetAtInstant(instant)), "instant %d", instant)
+	}
+}
+
+func TestDurableTreeDoesNotReplayOperationsInCheckpoint(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	tree := openTestDurableTree(t, dir)
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+
+	// Crash after the checkpoint was written, but before the log was emptied
+	lsn := tree.log.NextLSN() - 1
+	assert.NoError(t, tree.writeCheckpoint(lsn))
+	tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](60, 65), value: Float(1)})
+	crash(tree)
+
+	// Act
+	recovered := openTestDurableTree(t, dir)
+	defer recovered.Close()
+
+	// Assert
+	assert.Equal(t, Float(1), must(recovered.GetAtInstant(62)))
+	for _, td := range testDataGetAtInstant {
+		assert.Equal(t, td.expectedValue, must(recovered.GetAtInstant(td.instant)), "instant %d", td.instant)
+	}
+	assert.Equal(t, lsn+2, recovered.log.NextLSN())
+}
+
+func TestDurableTreeWithTornLastOperation(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	tree := openTestDurableTree(t, dir)
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+	assert.NoError(t, tree.Checkpoint())
+	tree.Delete(dosageTestData[Float]()[5])
+	tree.Delete(dosageTestData[Float]()[4])
+	crash(tree)
+
+	// The second delete was only written partially
+	path := filepath.Join(dir, logFileName)
+	info, _ := os.Stat(path)
+	os.Truncate(path, info.Size()-3)
+
+	// Act
+	recovered := openTestDurableTree(t, dir)
+	defer recovered.Close()
+
+	// Assert
+	assert.Equal(t, Float(0), must(recovered.GetAtInstant(47)))
+	assert.Equal(t, Float(4), must(recovered.GetAtInstant(42)))
+	assert.Equal(t, Float(7), must(recovered.GetAtInstant(37)))
+}
+
+func TestDurableTreeIgnoresUnfinishedCheckpoint(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	tree := openTestDurableTree(t, dir)
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+	crash(tree)
+	os.WriteFile(filepath.Join(dir, checkpointFileName+".tmp"), []byte("SBCKPT01 torn"), 0644)
+
+	// Act
+	recovered := openTestDurableTree(t, dir)
+	defer recovered.Close()
+
+	// Assert
+	for _, td := range testDataGetAtInstant {
+		assert.Equal(t, td.expectedValue, must(recovered.GetAtInstant(td.instant)), "instant %d", td.instant)
+	}
+}
+
+func TestDurableTreeWithCorruptedCheckpoint(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	tree := openTestDurableTree(t, dir)
+	tree.Insert(dosageTestData[Float]()[0])
+	assert.NoError(t, tree.Checkpoint())
+	tree.Close()
+
+	path := filepath.Join(dir, checkpointFileName)
+	content, _ := os.ReadFile(path)
+	content[30] ^= 1
+	os.WriteFile(path, content, 0644)
+
+	// Act
+	_, err := OpenDurableSegmentTree[Float, uint32](dir, BRANCHING_FACTOR, SumAggregate[Float](), FloatCodec{}, SyncAlways)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidCheckpoint)
+}
+
+func TestDurableTreeWithOtherAggregate(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	tree := openTestDurableTree(t, dir)
+	tree.Insert(dosageTestData[Float]()[0])
+	assert.NoError(t, tree.Checkpoint())
+	tree.Close()
+
+	// Act
+	_, err := OpenDurableSegmentTree[Float, uint32](dir, BRANCHING_FACTOR, MaxAggregate(Float(math.Inf(-1))), FloatCodec{}, SyncAlways)
+	_, unnamedErr := OpenDurableSegmentTree[Float, uint32](dir, BRANCHING_FACTOR, NewAggregate(Sum[Float], InverseSum[Float], Identity[Float], Float(0)), FloatCodec{}, SyncAlways)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrAggregateMismatch)
+	assert.ErrorIs(t, unnamedErr, ErrUnnamedAggregate)
+}
+
+func TestDurableTreeWithNonInvertibleAggregate(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	aggregate := MaxAggregate(Float(math.Inf(-1)))
+	tree, _ := OpenDurableSegmentTree[Float, uint32](dir, BRANCHING_FACTOR, aggregate, FloatCodec{}, SyncAlways)
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+	assert.NoError(t, tree.Checkpoint())
+	crash(tree)
+
+	// Act
+	recovered, err := OpenDurableSegmentTree[Float, uint32](dir, BRANCHING_FACTOR, aggregate, FloatCodec{}, SyncAlways)
+	defer recovered.Close()
+	recovered.Delete(dosageTestData[Float]()[4])
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, Float(3), must(recovered.GetAtInstant(12)))
+	assert.Equal(t, Float(2), must(recovered.GetAtInstant(37)))
+	assert.Equal(t, Float(1), must(recovered.GetAtInstant(42)))
+}
+
+func TestDurableTreeRemovesFailedOperationFromLog(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	aggregate := rejectingSumAggregate(99)
+	tree, _ := OpenDurableSegmentTree[Float, uint32](dir, BRANCHING_FACTOR, aggregate, FloatCodec{}, SyncAlways)
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+
+	// Act
+	err := tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](3, 62), value: Float(99)})
+	tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](0, 60), value: Float(1)})
+	crash(tree)
+	recovered, openErr := OpenDurableSegmentTree[Float, uint32](dir, BRANCHING_FACTOR, aggregate, FloatCodec{}, SyncAlways)
+	defer recovered.Close()
+
+	// Assert
+	assert.ErrorIs(t, err, ErrCorruptTree)
+	assert.NoError(t, openErr)
+	assert.Equal(t, Float(9), must(recovered.GetAtInstant(12)))
+	assert.Equal(t, Float(1), must(recovered.GetAtInstant(55)))
+}
+
+func TestDurableTreeWithFailingSyncDoesNotReplayFailedInsert(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	tree := openTestDurableTree(t, dir)
+	tree.Insert(dosageTestData[Float]()[0])
+	file := &failingSyncFile{File: tree.log.file.(*os.File), fail: true}
+	tree.log.file = file
+
+	// Act
+	err := tree.Insert(NewValueIntervalTuple(Float(10), NewInterval[uint32](0, 100)))
+	file.fail = false
+	value := must(tree.GetAtInstant(50))
+	crash(tree)
+	recovered := openTestDurableTree(t, dir)
+	defer recovered.Close()
+
+	// Assert
+	assert.ErrorIs(t, err, errSync)
+	assert.Equal(t, Float(0), value)
+	assert.Equal(t, Float(0), must(recovered.GetAtInstant(50)))
+	assert.Equal(t, must(tree.GetWithinInterval(NewInterval[uint32](0, 100))), must(recovered.GetWithinInterval(NewInterval[uint32](0, 100))))
+}
+
+func openTestDurableTree(t *testing.T, dir string) *DurableSegmentTree[Float, uint32] {
+	tree, err := OpenDurableSegmentTree[Float, uint32](dir, BRANCHING_FACTOR, SumAggregate[Float](), FloatCodec{}, SyncAlways)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tree
+}
+
+// crash closes the log file without syncing or writing a checkpoint.
+func crash[V Addable[V], T Timestamp](tree *DurableSegmentTree[V, T]) {
+	tree.log.file.Close()
+}
@@ -0,0 +1,131 @@
+package segmenttree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrEmptyInterval = errors.New("interval is empty")
+
+// extremeSearch is the state of a search for the first interval with the largest (or smallest)
+// aggregated value within an interval.
+type extremeSearch[V Addable[V], T Timestamp] struct {
+	// better reports whether x is more extreme than y.
+	better func(x float64, y float64) bool
+	// bound returns the most extreme value of a subtree, see Node.minimum and Node.maximum.
+	bound func(node *Node[V, T]) V
+	found bool
+	value V
+	// interval is the first interval found with the value. extending is set while the pieces
+	// visited are adjacent to it and have the same value, so that they extend it.
+	interval  Interval[T]
+	extending bool
+}
+
+// MaxWithin returns the first interval within the interval during which the aggregated value as
+// float is the largest, together with this value, e.g. when the total dose was highest within
+// a week. Adjacent pieces with the same value are merged into one interval.
+//
+// If the aggregate is monotone (see Aggregate.isMonotone), every node keeps the extreme values
+// within its subtree, and subtrees which can not contain a larger value are not visited.
+// Otherwise, all pieces within the interval are visited.
+func (tree *SegmentTreeImpl[V, T]) MaxWithin(interval Interval[T]) (Interval[T], V, error) {
+	return tree.extremeWithin(interval, &extremeSearch[V, T]{
+		better: func(x float64, y float64) bool {
+			return x > y
+		},
+		bound: func(node *Node[V, T]) V {
+			return node.maximum
+		},
+	})
+}
+
+// MinWithin returns the first interval within the interval during which the aggregated value as
+// float is the smallest, together with this value, see MaxWithin.
+func (tree *SegmentTreeImpl[V, T]) MinWithin(interval Interval[T]) (Interval[T], V, error) {
+	return tree.extremeWithin(interval, &extremeSearch[V, T]{
+		better: func(x float64, y float64) bool {
+			return x < y
+		},
+		bound: func(node *Node[V, T]) V {
+			return node.minimum
+		},
+	})
+}
+
+func (tree *SegmentTreeImpl[V, T]) extremeWithin(interval Interval[T], search *extremeSearch[V, T]) (Interval[T], V, error) {
+	if err := checkIntervals(interval); err != nil {
+		return Interval[T]{}, tree.aggregate.neutralElement, err
+	}
+	if interval.GetLength() == 0 {
+		return Interval[T]{}, tree.aggregate.neutralElement, fmt.Errorf("%w: [%d, %d)", ErrEmptyInterval, interval.start, interval.end)
+	}
+
+	err := tree.run(false, func() error {
+		return tree.searchExtreme(tree.root, NewInterval(0, MaxInstant[T]()), interval, tree.aggregate.neutralElement, search)
+	})
+	if err != nil {
+		return Interval[T]{}, tree.aggregate.neutralElement, err
+	}
+
+	return search.interval, search.value, nil
+}
+
+// searchExtreme visits the pieces of the node within the interval in order. value is the
+// aggregated value of the node's ancestors, as in rangeQuery.
+func (tree *SegmentTreeImpl[V, T]) searchExtreme(node *Node[V, T], bounds Interval[T], interval Interval[T], value V, search *extremeSearch[V, T]) error {
+	for index, nodeInterval := range node.getIntervalsWithin(bounds) {
+		intersection := interval.IntersectionWith(nodeInterval)
+
+		if intersection.GetLength() == 0 {
+			continue
+		}
+
+		aggregated := tree.aggregate.operation(node.values[index], value)
+
+		if node.isLeaf {
+			search.visit(aggregated, intersection)
+			continue
+		}
+
+		if tree.extrema && search.found {
+			// The bound is computed in another order than the values of the pieces, so that
+			// it may differ from them by rounding errors. It is thus compared with equalValues.
+			bound := tree.aggregate.operation(search.bound(node.children[index].node), aggregated)
+			same := equalValues(bound, search.value)
+
+			if (same && !search.extending) || (!same && !search.better(bound.AsFloat64(), search.value.AsFloat64())) {
+				search.extending = false
+				continue
+			}
+		}
+
+		child, err := node.child(index)
+		if err != nil {
+			return err
+		}
+		err = tree.searchExtreme(child, nodeInterval, interval, aggregated, search)
+		child.unpin()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// visit updates the search with the next piece.
+func (search *extremeSearch[V, T]) visit(value V, interval Interval[T]) {
+	switch {
+	case search.found && equalValues(value, search.value):
+		if search.extending && search.interval.end == interval.start {
+			search.interval.end = interval.end
+		} else {
+			search.extending = false
+		}
+	case !search.found || search.better(value.AsFloat64(), search.value.AsFloat64()):
+		search.found, search.value, search.interval, search.extending = true, value, interval, true
+	default:
+		search.extending = false
+	}
+}
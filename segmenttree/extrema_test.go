@@ -0,0 +1,142 @@
+package segmenttree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxWithinDosageScenario(t *testing.T) {
+	// Arrange
+	tree := setupTree()
+
+	testData := []struct {
+		interval         Interval[uint32]
+		expectedInterval Interval[uint32]
+		expectedValue    Float
+	}{
+		{NewInterval[uint32](0, 60), NewInterval[uint32](10, 15), 8},
+		{NewInterval[uint32](12, 37), NewInterval[uint32](12, 15), 8},
+		{NewInterval[uint32](15, 35), NewInterval[uint32](20, 30), 7},
+		{NewInterval[uint32](50, 200), NewInterval[uint32](50, 200), 0},
+	}
+
+	for _, td := range testData {
+		// Act
+		interval, value, err := tree.MaxWithin(td.interval)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, td.expectedInterval, interval, td.interval)
+		assert.Equal(t, td.expectedValue, value, td.interval)
+	}
+}
+
+func TestMinWithinDosageScenario(t *testing.T) {
+	// Arrange
+	tree := setupTree()
+
+	testData := []struct {
+		interval         Interval[uint32]
+		expectedInterval Interval[uint32]
+		expectedValue    Float
+	}{
+		{NewInterval[uint32](0, 60), NewInterval[uint32](0, 5), 0},
+		{NewInterval[uint32](12, 37), NewInterval[uint32](30, 35), 4},
+		{NewInterval[uint32](5, 45), NewInterval[uint32](5, 10), 2},
+	}
+
+	for _, td := range testData {
+		// Act
+		interval, value, err := tree.MinWithin(td.interval)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, td.expectedInterval, interval, td.interval)
+		assert.Equal(t, td.expectedValue, value, td.interval)
+	}
+}
+
+func TestMaxWithinEmptyInterval(t *testing.T) {
+	// Arrange
+	tree := setupTree()
+
+	// Act
+	_, _, err := tree.MaxWithin(NewInterval[uint32](10, 10))
+	_, _, invalidErr := tree.MinWithin(NewInterval[uint32](10, 5))
+
+	// Assert
+	assert.ErrorIs(t, err, ErrEmptyInterval)
+	assert.ErrorIs(t, invalidErr, ErrInvalidInterval)
+}
+
+// Compares MaxWithin and MinWithin with scanning the pieces of GetWithinInterval, while the tree is
+// split and merged by inserts and deletes.
+func TestExtremaWithinMatchPieces(t *testing.T) {
+	aggregates := map[string]Aggregate[Float]{
+		"sum":     SumAggregate[Float](),
+		"max":     MaxAggregate(Float(math.Inf(-1))),
+		"min":     MinAggregate(Float(math.Inf(1))),
+		"average": NewAggregate(func(x, y Float) Float { return (x + y) / 2 }, nil, Identity[Float], Float(0)),
+	}
+
+	for name, aggregate := range aggregates {
+		// Arrange
+		random := rand.New(rand.NewSource(1))
+		tree := NewSegmentTree[Float, uint32](3, aggregate)
+		inserted := []ValueIntervalTuple[Float, uint32]{}
+
+		for step := 0; step < 400; step++ {
+			if len(inserted) > 0 && random.Intn(3) == 0 {
+				i := random.Intn(len(inserted))
+				tree.Delete(inserted[i])
+				inserted = append(inserted[:i], inserted[i+1:]...)
+			} else {
+				start := uint32(random.Intn(200))
+				tuple := NewValueIntervalTuple(Float(random.Intn(20)), NewInterval(start, start+1+uint32(random.Intn(40))))
+				tree.Insert(tuple)
+				inserted = append(inserted, tuple)
+			}
+
+			if step%10 != 0 {
+				continue
+			}
+			if tree.extrema {
+				assertExtrema(t, tree, tree.root, NewInterval(0, MaxInstant[uint32]()))
+			}
+
+			start := uint32(random.Intn(250))
+			interval := NewInterval(start, start+1+uint32(random.Intn(100)))
+
+			// Act
+			maxInterval, maxValue, maxErr := tree.MaxWithin(interval)
+			minInterval, minValue, minErr := tree.MinWithin(interval)
+
+			// Assert
+			pieces := must(tree.GetWithinInterval(interval))
+			expectedMaxInterval, expectedMaxValue := firstExtremePiece(pieces, func(x, y float64) bool { return x > y })
+			expectedMinInterval, expectedMinValue := firstExtremePiece(pieces, func(x, y float64) bool { return x < y })
+			assert.NoError(t, maxErr)
+			assert.NoError(t, minErr)
+			assert.Equal(t, expectedMaxInterval, maxInterval, "%s, step %d", name, step)
+			assert.Equal(t, expectedMaxValue, maxValue, "%s, step %d", name, step)
+			assert.Equal(t, expectedMinInterval, minInterval, "%s, step %d", name, step)
+			assert.Equal(t, expectedMinValue, minValue, "%s, step %d", name, step)
+		}
+	}
+}
+
+// firstExtremePiece returns the first of the pieces with the most extreme value.
+func firstExtremePiece[V Addable[V], T Timestamp](pieces []ValueIntervalTuple[V, T], better func(x, y float64) bool) (Interval[T], V) {
+	var result ValueIntervalTuple[V, T]
+
+	for i, piece := range pieces {
+		if i == 0 || better(piece.value.AsFloat64(), result.value.AsFloat64()) {
+			result = piece
+		}
+	}
+
+	return result.interval, result.value
+}
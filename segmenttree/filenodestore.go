@@ -0,0 +1,307 @@
+package segmenttree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const DefaultPageSize = 4096
+
+var (
+	ErrInvalidStoreFile = errors.New("not a segment tree store file")
+	ErrPageOverflow     = errors.New("node does not fit into a page")
+	ErrCorruptPage      = errors.New("page is corrupt")
+)
+
+var fileNodeStoreMagic = []byte("SBTREE01")
+
+// Layout of the file: a sequence of fixed-size pages. Page 0 is the header:
+//
+//	magic (8 byte) | page size (4 byte) | root (4 byte) | page count (4 byte) | head of the free list (4 byte)
+//
+// Every other page either holds one node:
+//
+//	is leaf (1 byte) | number of keys n (2 byte) | n keys | n+1 values | n+1 child page ids (interior nodes only)
+//
+// or is free and holds the page id of the next free page in its first 4 bytes.
+//
+// A freed page is only added to the free list by the next Flush, once the header written before
+// it, which belongs to a tree no longer referencing the page, is synced. Until then, the page is
+// neither overwritten nor reused, so that it stays intact while the header on disk may still
+// reference it.
+//
+// All integers are little endian. Keys take the size of T, values the size given by the ValueCodec.
+type FileNodeStore[V Addable[V], T Timestamp] struct {
+	file      *os.File
+	codec     ValueCodec[V]
+	pageSize  int
+	root      PageID
+	pageCount uint32 // including the header page
+	freeHead  PageID
+	// pending contains the pages freed since the last Flush, which are not in the free list yet
+	pending []PageID
+}
+
+func OpenFileNodeStore[V Addable[V], T Timestamp](path string, pageSize int, codec ValueCodec[V]) (*FileNodeStore[V, T], error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &FileNodeStore[V, T]{
+		file:      file,
+		codec:     codec,
+		pageSize:  pageSize,
+		pageCount: 1,
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if info.Size() == 0 {
+		err = store.writeHeader()
+	} else {
+		err = store.readHeader()
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// MaxBranchingFactor returns the largest branching factor for which a node still fits into a page,
+// including a node which an insert has split into b+2 intervals before it is rebalanced.
+func (store *FileNodeStore[V, T]) MaxBranchingFactor() uint32 {
+	// 3 + (b+1) * key size + (b+2) * (value size + 4) <= page size
+	keySize := timestampSize[T]()
+	entrySize := store.codec.Size() + 4
+	return uint32((store.pageSize - 3 - keySize - 2*entrySize) / (keySize + entrySize))
+}
+
+func (store *FileNodeStore[V, T]) Allocate() (PageID, error) {
+	if store.freeHead != NoPage {
+		id := store.freeHead
+
+		buffer := make([]byte, 4)
+		if _, err := store.file.ReadAt(buffer, store.offset(id)); err != nil {
+			return NoPage, err
+		}
+		store.freeHead = PageID(binary.LittleEndian.Uint32(buffer))
+
+		return id, nil
+	}
+
+	id := PageID(store.pageCount)
+	store.pageCount++
+
+	return id, nil
+}
+
+func (store *FileNodeStore[V, T]) Read(id PageID) (NodePage[V, T], error) {
+	if id == NoPage || uint32(id) >= store.pageCount {
+		return NodePage[V, T]{}, ErrPageNotFound
+	}
+
+	buffer := make([]byte, store.pageSize)
+	if _, err := store.file.ReadAt(buffer, store.offset(id)); err != nil && err != io.EOF {
+		return NodePage[V, T]{}, err
+	}
+
+	return store.decode(buffer)
+}
+
+func (store *FileNodeStore[V, T]) Write(id PageID, page NodePage[V, T]) error {
+	if id == NoPage || uint32(id) >= store.pageCount {
+		return ErrPageNotFound
+	}
+
+	buffer, err := store.encode(page)
+	if err != nil {
+		return err
+	}
+
+	_, err = store.file.WriteAt(buffer, store.offset(id))
+	return err
+}
+
+func (store *FileNodeStore[V, T]) Free(id PageID) error {
+	if id == NoPage || uint32(id) >= store.pageCount {
+		return ErrPageNotFound
+	}
+
+	store.pending = append(store.pending, id)
+
+	return nil
+}
+
+func (store *FileNodeStore[V, T]) Root() PageID {
+	return store.root
+}
+
+func (store *FileNodeStore[V, T]) SetRoot(id PageID) error {
+	store.root = id
+	return nil
+}
+
+// Flush syncs the written pages and the header. Then, the pages freed since the last Flush are
+// added to the free list and the header is written and synced again.
+func (store *FileNodeStore[V, T]) Flush() error {
+	if err := store.syncHeader(); err != nil {
+		return err
+	}
+	if len(store.pending) == 0 {
+		return nil
+	}
+
+	buffer := make([]byte, store.pageSize)
+	for i, id := range store.pending {
+		binary.LittleEndian.PutUint32(buffer, uint32(store.freeHead))
+
+		if _, err := store.file.WriteAt(buffer, store.offset(id)); err != nil {
+			store.pending = store.pending[i:]
+			return err
+		}
+		store.freeHead = id
+	}
+	store.pending = store.pending[:0]
+
+	return store.syncHeader()
+}
+
+func (store *FileNodeStore[V, T]) Close() error {
+	if err := store.Flush(); err != nil {
+		store.file.Close()
+		return err
+	}
+
+	return store.file.Close()
+}
+
+func (store *FileNodeStore[V, T]) offset(id PageID) int64 {
+	return int64(id) * int64(store.pageSize)
+}
+
+func (store *FileNodeStore[V, T]) syncHeader() error {
+	if err := store.writeHeader(); err != nil {
+		return err
+	}
+
+	return store.file.Sync()
+}
+
+func (store *FileNodeStore[V, T]) writeHeader() error {
+	buffer := make([]byte, store.pageSize)
+
+	copy(buffer, fileNodeStoreMagic)
+	binary.LittleEndian.PutUint32(buffer[8:], uint32(store.pageSize))
+	binary.LittleEndian.PutUint32(buffer[12:], uint32(store.root))
+	binary.LittleEndian.PutUint32(buffer[16:], store.pageCount)
+	binary.LittleEndian.PutUint32(buffer[20:], uint32(store.freeHead))
+
+	_, err := store.file.WriteAt(buffer, 0)
+	return err
+}
+
+func (store *FileNodeStore[V, T]) readHeader() error {
+	buffer := make([]byte, 24)
+
+	if _, err := store.file.ReadAt(buffer, 0); err != nil {
+		return ErrInvalidStoreFile
+	}
+
+	if string(buffer[:8]) != string(fileNodeStoreMagic) || int(binary.LittleEndian.Uint32(buffer[8:])) != store.pageSize {
+		return ErrInvalidStoreFile
+	}
+
+	store.root = PageID(binary.LittleEndian.Uint32(buffer[12:]))
+	store.pageCount = binary.LittleEndian.Uint32(buffer[16:])
+	store.freeHead = PageID(binary.LittleEndian.Uint32(buffer[20:]))
+
+	return nil
+}
+
+func (store *FileNodeStore[V, T]) encode(page NodePage[V, T]) ([]byte, error) {
+	keySize := timestampSize[T]()
+	valueSize := store.codec.Size()
+
+	size := 3 + len(page.Keys)*keySize + len(page.Values)*valueSize
+	if !page.IsLeaf {
+		size += len(page.Children) * 4
+	}
+	if size > store.pageSize {
+		return nil, ErrPageOverflow
+	}
+
+	buffer := make([]byte, store.pageSize)
+	if page.IsLeaf {
+		buffer[0] = 1
+	}
+	binary.LittleEndian.PutUint16(buffer[1:], uint16(len(page.Keys)))
+
+	position := 3
+	for _, key := range page.Keys {
+		encodeTimestamp(buffer[position:], key)
+		position += keySize
+	}
+	for _, value := range page.Values {
+		store.codec.Encode(buffer[position:], value)
+		position += valueSize
+	}
+	if !page.IsLeaf {
+		for _, child := range page.Children {
+			binary.LittleEndian.PutUint32(buffer[position:], uint32(child))
+			position += 4
+		}
+	}
+
+	return buffer, nil
+}
+
+// decode returns ErrCorruptPage if the number of keys stored in the page does not fit into it.
+func (store *FileNodeStore[V, T]) decode(buffer []byte) (NodePage[V, T], error) {
+	keySize := timestampSize[T]()
+	valueSize := store.codec.Size()
+	size := int(binary.LittleEndian.Uint16(buffer[1:]))
+
+	length := 3 + size*keySize + (size+1)*valueSize
+	if buffer[0] != 1 {
+		length += (size + 1) * 4
+	}
+	if length > len(buffer) {
+		return NodePage[V, T]{}, fmt.Errorf("%w: %d keys do not fit into the page", ErrCorruptPage, size)
+	}
+
+	page := NodePage[V, T]{
+		Keys:   make([]T, size),
+		Values: make([]V, size+1),
+		IsLeaf: buffer[0] == 1,
+	}
+
+	position := 3
+	for i := range page.Keys {
+		page.Keys[i] = decodeTimestamp[T](buffer[position:])
+		position += keySize
+	}
+	for i := range page.Values {
+		page.Values[i] = store.codec.Decode(buffer[position:])
+		position += valueSize
+	}
+	if !page.IsLeaf {
+		page.Children = make([]PageID, size+1)
+		for i := range page.Children {
+			page.Children[i] = PageID(binary.LittleEndian.Uint32(buffer[position:]))
+			position += 4
+		}
+	}
+
+	return page, nil
+}
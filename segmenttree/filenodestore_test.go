@@ -0,0 +1,198 @@
+package segmenttree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileNodeStoreWriteAndRead(t *testing.T) {
+	// Arrange
+	store := openTestFileNodeStore(t, filepath.Join(t.TempDir(), "tree.db"))
+	defer store.Close()
+
+	leaf := NodePage[Float, uint32]{Keys: []uint32{10, 20}, Values: []Float{1, 2, 3}, IsLeaf: true}
+	interior := NodePage[Float, uint32]{Keys: []uint32{15}, Values: []Float{4, 5}, Children: []PageID{1, 2}}
+
+	leafID, _ := store.Allocate()
+	interiorID, _ := store.Allocate()
+
+	// Act
+	assert.NoError(t, store.Write(leafID, leaf))
+	assert.NoError(t, store.Write(interiorID, interior))
+	readLeaf, leafErr := store.Read(leafID)
+	readInterior, interiorErr := store.Read(interiorID)
+
+	// Assert
+	assert.NoError(t, leafErr)
+	assert.NoError(t, interiorErr)
+	assert.Equal(t, leaf, readLeaf)
+	assert.Equal(t, interior, readInterior)
+}
+
+func TestFileNodeStoreReusesFreedPages(t *testing.T) {
+	// Arrange
+	store := openTestFileNodeStore(t, filepath.Join(t.TempDir(), "tree.db"))
+	defer store.Close()
+
+	first, _ := store.Allocate()
+	second, _ := store.Allocate()
+
+	// Act
+	assert.NoError(t, store.Free(first))
+	assert.NoError(t, store.Free(second))
+	assert.NoError(t, store.Flush())
+	reused1, _ := store.Allocate()
+	reused2, _ := store.Allocate()
+	fresh, _ := store.Allocate()
+
+	// Assert
+	assert.Equal(t, second, reused1)
+	assert.Equal(t, first, reused2)
+	assert.Equal(t, PageID(3), fresh)
+}
+
+func TestFileNodeStoreKeepsFreedPagesUntilFlush(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "tree.db")
+	store := openTestFileNodeStore(t, path)
+	page := NodePage[Float, uint32]{Keys: []uint32{7}, Values: []Float{1, 2}, IsLeaf: true}
+
+	id, _ := store.Allocate()
+	store.Write(id, page)
+	store.SetRoot(id)
+	assert.NoError(t, store.Flush())
+
+	// Act
+	assert.NoError(t, store.Free(id))
+	allocated, _ := store.Allocate()
+	readPage, err := store.Read(id)
+	file, _ := os.ReadFile(path)
+
+	// Assert
+	assert.NotEqual(t, id, allocated)
+	assert.NoError(t, err)
+	assert.Equal(t, page, readPage)
+	// The page on disk is unchanged as well, as the header on disk still references it
+	assert.Equal(t, page, must(store.decode(file[int(id)*DefaultPageSize:])))
+}
+
+func TestFileNodeStoreReopen(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "tree.db")
+	store := openTestFileNodeStore(t, path)
+	page := NodePage[Float, uint32]{Keys: []uint32{7}, Values: []Float{1, 2}, IsLeaf: true}
+
+	id, _ := store.Allocate()
+	store.Write(id, page)
+	store.SetRoot(id)
+	assert.NoError(t, store.Close())
+
+	// Act
+	reopened := openTestFileNodeStore(t, path)
+	defer reopened.Close()
+	readPage, err := reopened.Read(reopened.Root())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, id, reopened.Root())
+	assert.Equal(t, page, readPage)
+}
+
+func TestOpenFileNodeStoreWithInvalidFile(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "tree.db")
+	os.WriteFile(path, []byte("no segment tree"), 0644)
+
+	// Act
+	_, err := OpenFileNodeStore[Float, uint32](path, DefaultPageSize, FloatCodec{})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidStoreFile)
+}
+
+func TestOpenFileNodeStoreWithOtherPageSize(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "tree.db")
+	openTestFileNodeStore(t, path).Close()
+
+	// Act
+	_, err := OpenFileNodeStore[Float, uint32](path, 2*DefaultPageSize, FloatCodec{})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidStoreFile)
+}
+
+func TestFileNodeStoreWriteTooLargePage(t *testing.T) {
+	// Arrange
+	store, _ := OpenFileNodeStore[Float, uint32](filepath.Join(t.TempDir(), "tree.db"), 32, FloatCodec{})
+	defer store.Close()
+	id, _ := store.Allocate()
+
+	// Act
+	err := store.Write(id, NodePage[Float, uint32]{Keys: []uint32{1, 2, 3, 4}, Values: []Float{1, 2, 3, 4, 5}, IsLeaf: true})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrPageOverflow)
+}
+
+func TestFileNodeStoreReadUnknownPage(t *testing.T) {
+	// Arrange
+	store := openTestFileNodeStore(t, filepath.Join(t.TempDir(), "tree.db"))
+	defer store.Close()
+
+	// Act
+	_, err := store.Read(5)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrPageNotFound)
+}
+
+func TestFileNodeStoreMaxBranchingFactor(t *testing.T) {
+	// Arrange
+	store := openTestFileNodeStore(t, filepath.Join(t.TempDir(), "tree.db"))
+	defer store.Close()
+	b := int(store.MaxBranchingFactor())
+	id, _ := store.Allocate()
+
+	// Act
+	// An insert may split a node into b+2 intervals before it is rebalanced
+	fittingErr := store.Write(id, NodePage[Float, uint32]{Keys: make([]uint32, b+1), Values: make([]Float, b+2), Children: make([]PageID, b+2)})
+	tooLargeErr := store.Write(id, NodePage[Float, uint32]{Keys: make([]uint32, b+2), Values: make([]Float, b+3), Children: make([]PageID, b+3)})
+
+	// Assert
+	assert.Equal(t, 339, b)
+	assert.NoError(t, fittingErr)
+	assert.ErrorIs(t, tooLargeErr, ErrPageOverflow)
+}
+
+func TestFileNodeStoreReadCorruptPage(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "tree.db")
+	store := openTestFileNodeStore(t, path)
+	id, _ := store.Allocate()
+	store.Write(id, NodePage[Float, uint32]{Keys: []uint32{7}, Values: []Float{1, 2}, IsLeaf: true})
+	store.Close()
+	file, _ := os.OpenFile(path, os.O_RDWR, 0644)
+	file.WriteAt([]byte{0xff, 0xff}, int64(id)*DefaultPageSize+1)
+	file.Close()
+	store = openTestFileNodeStore(t, path)
+	defer store.Close()
+
+	// Act
+	_, err := store.Read(id)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrCorruptPage)
+}
+
+func openTestFileNodeStore(t *testing.T, path string) *FileNodeStore[Float, uint32] {
+	store, err := OpenFileNodeStore[Float, uint32](path, DefaultPageSize, FloatCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
@@ -0,0 +1,155 @@
+package segmenttree
+
+// FindOption declares how the predicate of FindIntervals depends on the value, see Increasing.
+type FindOption func(*findOptions)
+
+type findOptions struct {
+	increasing bool
+	decreasing bool
+}
+
+// Increasing declares that a predicate matching a value matches all larger values as well, e.g.
+// value > limit. Values are compared as floats, see Addable.AsFloat64.
+func Increasing() FindOption {
+	return func(options *findOptions) {
+		options.increasing = true
+	}
+}
+
+// Decreasing declares that a predicate matching a value matches all smaller values as well, e.g.
+// value < limit, see Increasing.
+func Decreasing() FindOption {
+	return func(options *findOptions) {
+		options.decreasing = true
+	}
+}
+
+// predicate selects the values searched by findIntervals. Given the smallest and the largest value
+// within a subtree, matchesNone reports whether no value in between matches and matchesAll whether
+// all of them match. Both are nil if nothing is known about the predicate.
+type predicate[V Addable[V]] struct {
+	matches     func(value V) bool
+	matchesNone func(minimum V, maximum V) bool
+	matchesAll  func(minimum V, maximum V) bool
+}
+
+// FindIntervals returns the maximal intervals within the interval during which the aggregated
+// value matches the predicate, e.g. the periods during which the total dose exceeded a limit.
+// Adjacent pieces with matching values are merged into one interval.
+//
+// Unless the predicate is declared Increasing or Decreasing, nothing is known about it and every
+// piece within the interval is visited. If it is and the aggregate is monotone (see
+// Aggregate.isMonotone), e.g. sum, min or max, every node keeps the extreme values within its
+// subtree. A subtree whose values all match or all do not match is then not visited, so that the
+// search takes O(log n) per interval found.
+func (tree *SegmentTreeImpl[V, T]) FindIntervals(interval Interval[T], matches func(value V) bool, options ...FindOption) ([]Interval[T], error) {
+	var configured findOptions
+	for _, option := range options {
+		option(&configured)
+	}
+
+	predicate := predicate[V]{matches: matches}
+	switch {
+	case configured.increasing:
+		predicate.matchesNone = func(minimum V, maximum V) bool { return !matches(maximum) }
+		predicate.matchesAll = func(minimum V, maximum V) bool { return matches(minimum) }
+	case configured.decreasing:
+		predicate.matchesNone = func(minimum V, maximum V) bool { return !matches(minimum) }
+		predicate.matchesAll = func(minimum V, maximum V) bool { return matches(maximum) }
+	}
+
+	return tree.findIntervals(interval, predicate)
+}
+
+// FindIntervalsAbove returns the maximal intervals within the interval during which the aggregated
+// value as float is greater than the threshold, see FindIntervals.
+func (tree *SegmentTreeImpl[V, T]) FindIntervalsAbove(interval Interval[T], threshold float64) ([]Interval[T], error) {
+	return tree.FindIntervals(interval, func(value V) bool {
+		return value.AsFloat64() > threshold
+	}, Increasing())
+}
+
+// FindIntervalsBelow returns the maximal intervals within the interval during which the aggregated
+// value as float is less than the threshold, see FindIntervals.
+func (tree *SegmentTreeImpl[V, T]) FindIntervalsBelow(interval Interval[T], threshold float64) ([]Interval[T], error) {
+	return tree.FindIntervals(interval, func(value V) bool {
+		return value.AsFloat64() < threshold
+	}, Decreasing())
+}
+
+func (tree *SegmentTreeImpl[V, T]) findIntervals(interval Interval[T], predicate predicate[V]) ([]Interval[T], error) {
+	if err := checkIntervals(interval); err != nil {
+		return nil, err
+	}
+
+	var result []Interval[T]
+
+	err := tree.run(false, func() error {
+		result = []Interval[T]{}
+
+		if interval.GetLength() > 0 {
+			return tree.find(tree.root, NewInterval(0, MaxInstant[T]()), interval, tree.aggregate.neutralElement, predicate, &result)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// find appends the intervals within the node matching the predicate to the result. value is the
+// aggregated value of the node's ancestors, as in rangeQuery.
+func (tree *SegmentTreeImpl[V, T]) find(node *Node[V, T], bounds Interval[T], interval Interval[T], value V, predicate predicate[V], result *[]Interval[T]) error {
+	for index, nodeInterval := range node.getIntervalsWithin(bounds) {
+		intersection := interval.IntersectionWith(nodeInterval)
+
+		if intersection.GetLength() == 0 {
+			continue
+		}
+
+		aggregated := tree.aggregate.operation(node.values[index], value)
+
+		if node.isLeaf {
+			if predicate.matches(aggregated) {
+				*result = appendInterval(*result, intersection)
+			}
+			continue
+		}
+
+		if tree.extrema && predicate.matchesNone != nil {
+			summary := node.children[index].node
+			minimum := tree.aggregate.operation(summary.minimum, aggregated)
+			maximum := tree.aggregate.operation(summary.maximum, aggregated)
+
+			if predicate.matchesNone(minimum, maximum) {
+				continue
+			}
+			if predicate.matchesAll(minimum, maximum) {
+				*result = appendInterval(*result, intersection)
+				continue
+			}
+		}
+
+		child, err := node.child(index)
+		if err != nil {
+			return err
+		}
+		err = tree.find(child, nodeInterval, interval, aggregated, predicate, result)
+		child.unpin()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendInterval appends the interval to the sorted intervals, merging it with the last one if
+// they are adjacent.
+func appendInterval[T Timestamp](intervals []Interval[T], interval Interval[T]) []Interval[T] {
+	if last := len(intervals) - 1; last >= 0 && intervals[last].end == interval.start {
+		intervals[last].end = interval.end
+		return intervals
+	}
+
+	return append(intervals, interval)
+}
@@ -0,0 +1,177 @@
+package segmenttree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindIntervalsDosageScenario(t *testing.T) {
+	// Arrange
+	tree := setupTree()
+
+	testData := []struct {
+		threshold     float64
+		expectedAbove []Interval[uint32]
+		expectedBelow []Interval[uint32]
+	}{
+		{5, []Interval[uint32]{NewInterval[uint32](10, 30), NewInterval[uint32](35, 40)}, []Interval[uint32]{NewInterval[uint32](0, 10), NewInterval[uint32](30, 35), NewInterval[uint32](45, 60)}},
+		{6, []Interval[uint32]{NewInterval[uint32](10, 15), NewInterval[uint32](20, 30), NewInterval[uint32](35, 40)}, []Interval[uint32]{NewInterval[uint32](0, 10), NewInterval[uint32](30, 35), NewInterval[uint32](40, 60)}},
+		{8, []Interval[uint32]{}, []Interval[uint32]{NewInterval[uint32](0, 10), NewInterval[uint32](15, 35), NewInterval[uint32](40, 60)}},
+	}
+
+	for _, td := range testData {
+		// Act
+		above := must(tree.FindIntervalsAbove(NewInterval[uint32](0, 60), td.threshold))
+		below := must(tree.FindIntervalsBelow(NewInterval[uint32](0, 60), td.threshold))
+		matching := must(tree.FindIntervals(NewInterval[uint32](0, 60), func(value Float) bool {
+			return float64(value) > td.threshold
+		}))
+
+		// Assert
+		assert.Equal(t, td.expectedAbove, above, "threshold %v", td.threshold)
+		assert.Equal(t, td.expectedBelow, below, "threshold %v", td.threshold)
+		assert.Equal(t, td.expectedAbove, matching, "threshold %v", td.threshold)
+	}
+}
+
+func TestFindIntervalsClipsToInterval(t *testing.T) {
+	// Arrange
+	tree := setupTree()
+
+	// Act
+	result := must(tree.FindIntervalsAbove(NewInterval[uint32](12, 37), 5))
+
+	// Assert
+	assert.Equal(t, []Interval[uint32]{NewInterval[uint32](12, 30), NewInterval[uint32](35, 37)}, result)
+	assert.Equal(t, []Interval[uint32]{}, must(tree.FindIntervalsAbove(NewInterval[uint32](12, 12), 5)))
+	_, err := tree.FindIntervalsAbove(NewInterval[uint32](37, 12), 5)
+	assert.ErrorIs(t, err, ErrInvalidInterval)
+}
+
+// Compares the pruned search with filtering the pieces of GetWithinInterval, while the tree is
+// split and merged by inserts and deletes.
+func TestFindIntervalsMatchesPieces(t *testing.T) {
+	aggregates := map[string]Aggregate[Float]{
+		"sum":          SumAggregate[Float](),
+		"max":          MaxAggregate(Float(math.Inf(-1))),
+		"min":          MinAggregate(Float(math.Inf(1))),
+		"declared max": NewAggregate(Max[Float], nil, Identity[Float], Float(math.Inf(-1)), Monotone()),
+		"average":      NewAggregate(func(x, y Float) Float { return (x + y) / 2 }, nil, Identity[Float], Float(0)),
+	}
+
+	for name, aggregate := range aggregates {
+		// Arrange
+		random := rand.New(rand.NewSource(1))
+		tree := NewSegmentTree[Float, uint32](3, aggregate)
+		inserted := []ValueIntervalTuple[Float, uint32]{}
+		assert.Equal(t, name != "average", tree.extrema, name)
+
+		for step := 0; step < 400; step++ {
+			if len(inserted) > 0 && random.Intn(3) == 0 {
+				i := random.Intn(len(inserted))
+				tree.Delete(inserted[i])
+				inserted = append(inserted[:i], inserted[i+1:]...)
+			} else {
+				start := uint32(random.Intn(200))
+				tuple := NewValueIntervalTuple(Float(random.Intn(20)), NewInterval(start, start+1+uint32(random.Intn(40))))
+				tree.Insert(tuple)
+				inserted = append(inserted, tuple)
+			}
+
+			if step%20 != 0 {
+				continue
+			}
+			if tree.extrema {
+				assertExtrema(t, tree, tree.root, NewInterval(0, MaxInstant[uint32]()))
+			}
+
+			start := uint32(random.Intn(250))
+			interval := NewInterval(start, start+uint32(random.Intn(100)))
+			threshold := float64(random.Intn(40))
+
+			// Act
+			above := must(tree.FindIntervalsAbove(interval, threshold))
+			below := must(tree.FindIntervalsBelow(interval, threshold))
+			atLeast := must(tree.FindIntervals(interval, func(value Float) bool { return float64(value) >= threshold }, Increasing()))
+
+			// Assert
+			pieces := must(tree.GetWithinInterval(interval))
+			assert.Equal(t, filterPieces(pieces, func(value Float) bool { return float64(value) > threshold }), above, "%s, step %d", name, step)
+			assert.Equal(t, filterPieces(pieces, func(value Float) bool { return float64(value) < threshold }), below, "%s, step %d", name, step)
+			assert.Equal(t, filterPieces(pieces, func(value Float) bool { return float64(value) >= threshold }), atLeast, "%s, step %d", name, step)
+		}
+	}
+}
+
+func TestFindIntervalsPrunesDeclaredIncreasingPredicate(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	for i := uint32(0); i < 1000; i++ {
+		tree.Insert(NewValueIntervalTuple(Float(i%10), NewInterval(2*i, 2*i+1)))
+	}
+	tree.Insert(NewValueIntervalTuple(Float(100), NewInterval[uint32](500, 503)))
+
+	calls := 0
+	matches := func(value Float) bool {
+		calls++
+		return value > 50
+	}
+
+	// Act
+	all := must(tree.FindIntervals(NewInterval[uint32](0, 2000), matches))
+	visited := calls
+	calls = 0
+	pruned := must(tree.FindIntervals(NewInterval[uint32](0, 2000), matches, Increasing()))
+
+	// Assert
+	assert.Equal(t, []Interval[uint32]{NewInterval[uint32](500, 503)}, all)
+	assert.Equal(t, all, pruned)
+	assert.GreaterOrEqual(t, visited, 1000)
+	assert.Less(t, calls, 100)
+}
+
+func TestFindIntervalsPagedTree(t *testing.T) {
+	// Arrange
+	tree := must(NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float](), NewMemoryNodeStore[Float, uint32](), 2))
+	tree.InsertRange(dosageTestData[Float]())
+
+	// Act
+	result := must(tree.FindIntervalsAbove(NewInterval[uint32](0, 60), 5))
+
+	// Assert
+	assert.False(t, tree.extrema)
+	assert.Equal(t, []Interval[uint32]{NewInterval[uint32](10, 30), NewInterval[uint32](35, 40)}, result)
+}
+
+// filterPieces returns the merged intervals of the pieces whose values match.
+func filterPieces[V Addable[V], T Timestamp](pieces []ValueIntervalTuple[V, T], matches func(V) bool) []Interval[T] {
+	result := []Interval[T]{}
+	for _, piece := range pieces {
+		if matches(piece.value) {
+			result = appendInterval(result, piece.interval)
+		}
+	}
+
+	return result
+}
+
+// assertExtrema checks the extrema of every node against the extrema of the pieces of its subtree.
+func assertExtrema[V Addable[V], T Timestamp](t *testing.T, tree *SegmentTreeImpl[V, T], node *Node[V, T], bounds Interval[T]) {
+	minimum, maximum := math.Inf(1), math.Inf(-1)
+	for _, piece := range must(tree.rangeQuery(node, bounds, bounds, tree.aggregate.neutralElement)) {
+		minimum, maximum = math.Min(minimum, piece.value.AsFloat64()), math.Max(maximum, piece.value.AsFloat64())
+	}
+
+	assert.False(t, node.summaryStale)
+	assert.Equal(t, minimum, node.minimum.AsFloat64())
+	assert.Equal(t, maximum, node.maximum.AsFloat64())
+
+	if !node.isLeaf {
+		for index, interval := range node.getIntervalsWithin(bounds) {
+			assertExtrema(t, tree, node.children[index].node, interval)
+		}
+	}
+}
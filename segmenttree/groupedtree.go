@@ -0,0 +1,297 @@
+package segmenttree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// GroupedSegmentTree keeps a timeline per group key, e.g. per patient or per drug, together with
+// the timeline of all groups, which answers rollups such as the sum over all patients at an
+// instant in O(log n) instead of querying every group.
+//
+// Every group is a SegmentTreeImpl which is only created once a tuple is inserted for its key and
+// is dropped again once its tuples are deleted, so that thousands of small groups stay cheap. A
+// small branching factor keeps the nodes of small groups small as well. The groups of a paged
+// grouped tree share the buffer pool and the store of the total, see NewPagedGroupedSegmentTree.
+type GroupedSegmentTree[K comparable, V Addable[V], T Timestamp] struct {
+	branchingFactor uint32
+	aggregate       Aggregate[V]
+	groups          map[K]*SegmentTreeImpl[V, T]
+	// total holds the tuples of all groups. For an aggregate without an inverse, it does not keep
+	// the inserted tuples, as it is recomputed from the groups on delete, see recompute.
+	total *SegmentTreeImpl[V, T]
+	// spans holds the interval spanned by the tuples inserted into each group, which spanIndex
+	// indexes, so that recompute only queries the groups overlapping the interval. They are only
+	// kept for an aggregate without an inverse.
+	spans     map[K]Interval[T]
+	spanIndex *intervalIndex[K, T]
+}
+
+func NewGroupedSegmentTree[K comparable, V Addable[V], T Timestamp](branchingFactor uint32, aggregate Aggregate[V]) *GroupedSegmentTree[K, V, T] {
+	return &GroupedSegmentTree[K, V, T]{
+		branchingFactor: branchingFactor,
+		aggregate:       aggregate,
+		groups:          make(map[K]*SegmentTreeImpl[V, T]),
+		total:           NewSegmentTree[V, T](branchingFactor, aggregate),
+		spans:           make(map[K]Interval[T]),
+	}
+}
+
+// NewPagedGroupedSegmentTree creates a grouped tree whose total and groups keep their nodes in
+// the store, sharing one buffer pool of bufferCapacity nodes. The root of every group stays in
+// memory, so that a group with few tuples takes a single node, while the other nodes of larger
+// groups are evicted as those of the total.
+//
+// The store has to be empty, as the keys of the groups are not stored and the tree can not be
+// reopened. Otherwise, it returns ErrTreeNotEmpty. It returns the same errors as
+// NewPagedSegmentTree as well.
+func NewPagedGroupedSegmentTree[K comparable, V Addable[V], T Timestamp](branchingFactor uint32, aggregate Aggregate[V], store NodeStore[V, T], bufferCapacity int) (*GroupedSegmentTree[K, V, T], error) {
+	if store.Root() != NoPage {
+		return nil, fmt.Errorf("%w: the store already contains a tree", ErrTreeNotEmpty)
+	}
+
+	total, err := NewPagedSegmentTree(branchingFactor, aggregate, store, bufferCapacity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupedSegmentTree[K, V, T]{
+		branchingFactor: branchingFactor,
+		aggregate:       aggregate,
+		groups:          make(map[K]*SegmentTreeImpl[V, T]),
+		total:           total,
+		spans:           make(map[K]Interval[T]),
+	}, nil
+}
+
+// Flush writes the modified nodes of all groups and the total of a paged grouped tree to the store.
+func (grouped *GroupedSegmentTree[K, V, T]) Flush() error {
+	for _, group := range grouped.groups {
+		if group.failure != nil {
+			return group.failure
+		}
+	}
+
+	return grouped.total.Flush()
+}
+
+// Close flushes and closes the store of a paged grouped tree.
+func (grouped *GroupedSegmentTree[K, V, T]) Close() error {
+	if grouped.total.pool == nil {
+		return nil
+	}
+
+	if err := grouped.Flush(); err != nil {
+		return errors.Join(err, grouped.total.pool.store.Close())
+	}
+
+	return grouped.total.pool.store.Close()
+}
+
+// GetAtInstant returns the aggregated value of the group at the instant. For a group without
+// tuples, this is the neutral element.
+func (grouped *GroupedSegmentTree[K, V, T]) GetAtInstant(key K, instant T) (V, error) {
+	group, ok := grouped.groups[key]
+	if !ok {
+		return grouped.aggregate.neutralElement, nil
+	}
+
+	return group.GetAtInstant(instant)
+}
+
+func (grouped *GroupedSegmentTree[K, V, T]) GetWithinInterval(key K, interval Interval[T]) ([]ValueIntervalTuple[V, T], error) {
+	group, ok := grouped.groups[key]
+	if !ok {
+		group = NewSegmentTree[V, T](grouped.branchingFactor, grouped.aggregate)
+	}
+
+	return group.GetWithinInterval(interval)
+}
+
+// GetTotalAtInstant returns the value aggregated over all groups at the instant.
+func (grouped *GroupedSegmentTree[K, V, T]) GetTotalAtInstant(instant T) (V, error) {
+	return grouped.total.GetAtInstant(instant)
+}
+
+// GetTotalWithinInterval returns the values aggregated over all groups within the interval.
+func (grouped *GroupedSegmentTree[K, V, T]) GetTotalWithinInterval(interval Interval[T]) ([]ValueIntervalTuple[V, T], error) {
+	return grouped.total.GetWithinInterval(interval)
+}
+
+// GetKeys returns the keys of all groups with tuples, in no particular order.
+func (grouped *GroupedSegmentTree[K, V, T]) GetKeys() []K {
+	keys := make([]K, 0, len(grouped.groups))
+	for key := range grouped.groups {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// Len returns the number of groups with tuples.
+func (grouped *GroupedSegmentTree[K, V, T]) Len() int {
+	return len(grouped.groups)
+}
+
+func (grouped *GroupedSegmentTree[K, V, T]) Insert(key K, value ValueIntervalTuple[V, T]) error {
+	group, ok := grouped.groups[key]
+	if !ok {
+		group = grouped.newGroup()
+	}
+
+	if err := group.Insert(value); err != nil {
+		return errors.Join(err, grouped.keep(key, group))
+	}
+	if err := grouped.total.Insert(value); err != nil {
+		return errors.Join(err, group.Delete(value), grouped.keep(key, group))
+	}
+	grouped.forgetTuples()
+	grouped.extendSpan(key, value.interval)
+
+	return grouped.keep(key, group)
+}
+
+// Delete removes one occurrence of the tuple from the group, see SegmentTreeImpl.Delete. For an
+// aggregate without an inverse, the total is recomputed within the interval of the tuple from
+// the groups overlapping it, see recompute. Deleting from a group without tuples is a no-op.
+func (grouped *GroupedSegmentTree[K, V, T]) Delete(key K, value ValueIntervalTuple[V, T]) error {
+	group, ok := grouped.groups[key]
+	if !ok {
+		return nil
+	}
+
+	if err := group.Delete(value); err != nil {
+		return errors.Join(err, grouped.keep(key, group))
+	}
+	if grouped.aggregate.isInvertible() {
+		if err := grouped.total.Delete(value); err != nil {
+			return errors.Join(err, group.Insert(value), grouped.keep(key, group))
+		}
+	}
+	if err := grouped.keep(key, group); err != nil {
+		return err
+	}
+
+	if !grouped.aggregate.isInvertible() {
+		return grouped.recompute(value.interval)
+	}
+	return nil
+}
+
+// InsertRange loads the tuples into the group, which has to be empty, in O(n log n). The tuples
+// are merged into the total at once, see SegmentTreeImpl.MergeRange.
+func (grouped *GroupedSegmentTree[K, V, T]) InsertRange(key K, values []ValueIntervalTuple[V, T]) error {
+	if _, ok := grouped.groups[key]; ok {
+		return ErrTreeNotEmpty
+	}
+
+	group := grouped.newGroup()
+	if err := group.InsertRange(values); err != nil {
+		return errors.Join(err, grouped.drop(group))
+	}
+
+	if err := grouped.total.MergeRange(values); err != nil {
+		return errors.Join(err, grouped.drop(group))
+	}
+	grouped.forgetTuples()
+	for _, value := range values {
+		grouped.extendSpan(key, value.interval)
+	}
+
+	return grouped.keep(key, group)
+}
+
+// newGroup creates the tree of a new group. In a paged grouped tree, it shares the buffer pool of
+// the total. Its root gets a page by the first operation on it.
+func (grouped *GroupedSegmentTree[K, V, T]) newGroup() *SegmentTreeImpl[V, T] {
+	if grouped.total.pool == nil {
+		return NewSegmentTree[V, T](grouped.branchingFactor, grouped.aggregate)
+	}
+
+	group := &SegmentTreeImpl[V, T]{
+		branchingFactor: grouped.branchingFactor,
+		aggregate:       grouped.aggregate,
+		pool:            grouped.total.pool,
+	}
+	group.root = group.newNode()
+	group.root.values = append(group.root.values, grouped.aggregate.neutralElement)
+
+	return group
+}
+
+// keep adds the group under the key, or removes and drops it if it is empty.
+func (grouped *GroupedSegmentTree[K, V, T]) keep(key K, group *SegmentTreeImpl[V, T]) error {
+	if !grouped.isEmpty(group) {
+		grouped.groups[key] = group
+		return nil
+	}
+
+	delete(grouped.groups, key)
+	if span, ok := grouped.spans[key]; ok {
+		delete(grouped.spans, key)
+		grouped.spanIndex, _ = grouped.spanIndex.delete(key, span)
+	}
+	return grouped.drop(group)
+}
+
+// extendSpan extends the span of the group by the interval of a tuple inserted into it. The span
+// is not reduced by deletes, so that it may be larger than the interval spanned by the tuples
+// left, until the group is dropped.
+func (grouped *GroupedSegmentTree[K, V, T]) extendSpan(key K, interval Interval[T]) {
+	if grouped.aggregate.isInvertible() {
+		return
+	}
+
+	if span, ok := grouped.spans[key]; ok {
+		if interval.IsSubsetOf(span) {
+			return
+		}
+		grouped.spanIndex, _ = grouped.spanIndex.delete(key, span)
+		interval = NewInterval(min(span.start, interval.start), max(span.end, interval.end))
+	}
+
+	grouped.spans[key] = interval
+	grouped.spanIndex = grouped.spanIndex.insert(key, interval)
+}
+
+// drop frees the pages of a group which is not kept.
+func (grouped *GroupedSegmentTree[K, V, T]) drop(group *SegmentTreeImpl[V, T]) error {
+	if group.pool == nil || group.failure != nil {
+		return nil
+	}
+
+	return group.run(true, func() error {
+		return group.discardSubtree(group.root)
+	})
+}
+
+// isEmpty reports whether the group consists of a single interval with the neutral element.
+func (grouped *GroupedSegmentTree[K, V, T]) isEmpty(group *SegmentTreeImpl[V, T]) bool {
+	return group.root.size() == 0 && group.tuples.len() == 0 && equalValues(group.root.values[0], grouped.aggregate.neutralElement)
+}
+
+// forgetTuples drops the tuples kept by the total for an aggregate without an inverse, as it is
+// recomputed from the groups instead, see recompute.
+func (grouped *GroupedSegmentTree[K, V, T]) forgetTuples() {
+	grouped.total.tuples = nil
+}
+
+// recompute replaces the pieces of the total within the interval by the values aggregated over
+// the groups overlapping it. This is how a tuple is deleted from the total for an aggregate
+// without an inverse. The k groups overlapping the interval are found by their spans in
+// O(log g + k) for g groups. Querying them and replacing the p pieces of the total within the
+// interval then takes O(p log n), see SegmentTreeImpl.replacePieces, plus the queries.
+func (grouped *GroupedSegmentTree[K, V, T]) recompute(interval Interval[T]) error {
+	within := []ValueIntervalTuple[V, T]{NewValueIntervalTuple(grouped.aggregate.neutralElement, interval)}
+	for _, key := range grouped.spanIndex.overlapping(interval, nil) {
+		pieces, err := grouped.groups[key].GetWithinInterval(interval)
+		if err != nil {
+			return err
+		}
+		within = combinePieces(grouped.aggregate.combine, within, pieces)
+	}
+
+	return grouped.total.run(true, func() error {
+		return grouped.total.replacePieces(within)
+	})
+}
@@ -0,0 +1,235 @@
+package segmenttree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupedTreeKeepsGroupsApart(t *testing.T) {
+	// Arrange
+	tree := NewGroupedSegmentTree[string, Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+
+	// Act
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert("alice", tuple)
+	}
+	tree.Insert("bob", NewValueIntervalTuple(Float(10), NewInterval[uint32](20, 40)))
+
+	// Assert
+	for _, td := range testDataGetAtInstant {
+		assert.Equal(t, td.expectedValue, must(tree.GetAtInstant("alice", td.instant)), "instant %d", td.instant)
+	}
+	assert.Equal(t, Float(10), must(tree.GetAtInstant("bob", 30)))
+	assert.Equal(t, Float(0), must(tree.GetAtInstant("bob", 45)))
+	assert.Equal(t, Float(0), must(tree.GetAtInstant("carol", 30)))
+	assert.Equal(t, []ValueIntervalTuple[Float, uint32]{{value: 0, interval: NewInterval[uint32](0, 60)}}, must(tree.GetWithinInterval("carol", NewInterval[uint32](0, 60))))
+	assert.ElementsMatch(t, []string{"alice", "bob"}, tree.GetKeys())
+}
+
+func TestGroupedTreeTotal(t *testing.T) {
+	// Arrange
+	tree := NewGroupedSegmentTree[int, Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	for patient, tuple := range dosageTestData[Float]() {
+		tree.Insert(patient, tuple)
+	}
+
+	// Act
+	result := must(tree.GetTotalWithinInterval(NewInterval[uint32](0, 60)))
+
+	// Assert
+	assert.Equal(t, must(setupTree().GetWithinInterval(NewInterval[uint32](0, 60))), result)
+	for _, td := range testDataGetAtInstant {
+		assert.Equal(t, td.expectedValue, must(tree.GetTotalAtInstant(td.instant)), "instant %d", td.instant)
+	}
+}
+
+func TestGroupedTreeDropsEmptyGroups(t *testing.T) {
+	// Arrange
+	tree := NewGroupedSegmentTree[string, Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	tree.InsertRange("alice", dosageTestData[Float]())
+	tree.Insert("bob", NewValueIntervalTuple(Float(10), NewInterval[uint32](20, 40)))
+
+	// Act
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Delete("alice", tuple)
+	}
+
+	// Assert
+	assert.Equal(t, []string{"bob"}, tree.GetKeys())
+	assert.Equal(t, 1, tree.Len())
+	assert.Equal(t, Float(10), must(tree.GetTotalAtInstant(30)))
+	assert.Equal(t, Float(0), must(tree.GetTotalAtInstant(10)))
+}
+
+func TestGroupedTreeInsertRangeIntoNonEmptyGroup(t *testing.T) {
+	// Arrange
+	tree := NewGroupedSegmentTree[string, Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	tree.Insert("alice", NewValueIntervalTuple(Float(10), NewInterval[uint32](20, 40)))
+
+	// Act
+	err := tree.InsertRange("alice", dosageTestData[Float]())
+
+	// Assert
+	assert.ErrorIs(t, err, ErrTreeNotEmpty)
+	assert.Equal(t, Float(10), must(tree.GetTotalAtInstant(30)))
+}
+
+func TestGroupedTreeWithMaxMatchesGroups(t *testing.T) {
+	// Arrange
+	random := rand.New(rand.NewSource(1))
+	tree := NewGroupedSegmentTree[int, Float, uint32](BRANCHING_FACTOR, MaxAggregate(Float(math.Inf(-1))))
+	reference := NewNaiveSegmentTree[Float, uint32](MaxAggregate(Float(math.Inf(-1))))
+	inserted := map[int][]ValueIntervalTuple[Float, uint32]{}
+
+	// Act
+	for step := 0; step < 500; step++ {
+		key := random.Intn(20)
+		if len(inserted[key]) > 0 && random.Intn(3) == 0 {
+			i := random.Intn(len(inserted[key]))
+			tree.Delete(key, inserted[key][i])
+			reference.Delete(inserted[key][i])
+			inserted[key] = append(inserted[key][:i], inserted[key][i+1:]...)
+		} else {
+			start := uint32(random.Intn(100))
+			tuple := NewValueIntervalTuple(Float(random.Intn(50)), NewInterval(start, start+1+uint32(random.Intn(30))))
+			tree.Insert(key, tuple)
+			reference.Insert(tuple)
+			inserted[key] = append(inserted[key], tuple)
+		}
+	}
+
+	// Assert
+	interval := NewInterval(uint32(0), MaxInstant[uint32]())
+	assert.Equal(t, must(reference.GetWithinInterval(interval)), must(tree.GetTotalWithinInterval(interval)))
+	// The total is recomputed from the groups instead of its own tuples
+	assert.Empty(t, tree.total.tuples)
+	for key, tuples := range inserted {
+		group := NewNaiveSegmentTree[Float, uint32](MaxAggregate(Float(math.Inf(-1))))
+		group.InsertRange(tuples)
+		assert.Equal(t, must(group.GetWithinInterval(interval)), must(tree.GetWithinInterval(key, interval)), "group %d", key)
+	}
+}
+
+// Deletes a tuple from a max grouped tree whose other groups fail all operations. Only the groups
+// overlapping the interval of the tuple are queried to recompute the total.
+func TestGroupedTreeWithMaxRecomputesOnlyOverlappingGroups(t *testing.T) {
+	// Arrange
+	tree := NewGroupedSegmentTree[int, Float, uint32](BRANCHING_FACTOR, MaxAggregate(Float(math.Inf(-1))))
+	for key := 0; key < 10; key++ {
+		start := uint32(100 * key)
+		tree.Insert(key, NewValueIntervalTuple(Float(key+1), NewInterval(start, start+50)))
+		tree.Insert(key, NewValueIntervalTuple(Float(1), NewInterval(start+20, start+80)))
+	}
+	for key, group := range tree.groups {
+		if key != 3 {
+			group.failure = ErrCorruptTree
+		}
+	}
+
+	// Act
+	err := tree.Delete(3, NewValueIntervalTuple(Float(4), NewInterval[uint32](300, 350)))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []ValueIntervalTuple[Float, uint32]{
+		{value: Float(3), interval: NewInterval[uint32](200, 250)},
+		{value: Float(1), interval: NewInterval[uint32](250, 280)},
+		{value: Float(math.Inf(-1)), interval: NewInterval[uint32](280, 320)},
+		{value: Float(1), interval: NewInterval[uint32](320, 380)},
+		{value: Float(math.Inf(-1)), interval: NewInterval[uint32](380, 400)},
+		{value: Float(5), interval: NewInterval[uint32](400, 450)},
+	}, must(tree.GetTotalWithinInterval(NewInterval[uint32](200, 450))))
+}
+
+func TestGroupedTreeDeleteFromUnknownGroup(t *testing.T) {
+	// Arrange
+	tree := NewGroupedSegmentTree[string, Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert("alice", tuple)
+	}
+	expected := must(tree.GetTotalWithinInterval(NewInterval[uint32](0, 60)))
+
+	// Act
+	err := tree.Delete("bob", dosageTestData[Float]()[0])
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, tree.GetKeys())
+	assert.Equal(t, expected, must(tree.GetTotalWithinInterval(NewInterval[uint32](0, 60))))
+	assert.Equal(t, Float(0), must(tree.GetAtInstant("bob", 12)))
+}
+
+// Compares a paged grouped tree, whose groups share the buffer pool and the store of the total,
+// with an in-memory one while groups are loaded, modified and dropped.
+func TestPagedGroupedTreeMatchesInMemoryGroupedTree(t *testing.T) {
+	// Arrange
+	random := rand.New(rand.NewSource(1))
+	const capacity = 4
+	store := NewMemoryNodeStore[Float, uint32]()
+	paged := must(NewPagedGroupedSegmentTree[int, Float, uint32](4, SumAggregate[Float](), store, capacity))
+	reference := NewGroupedSegmentTree[int, Float, uint32](4, SumAggregate[Float]())
+	inserted := map[int][]ValueIntervalTuple[Float, uint32]{}
+	interval := NewInterval[uint32](0, 200)
+
+	for step := 0; step < 600; step++ {
+		// Act
+		key := random.Intn(10)
+		switch {
+		case len(inserted[key]) == 0 && random.Intn(2) == 0:
+			values := []ValueIntervalTuple[Float, uint32]{}
+			for i := 0; i < 20; i++ {
+				start := uint32(random.Intn(150))
+				values = append(values, NewValueIntervalTuple(Float(random.Intn(10)+1), NewInterval(start, start+1+uint32(random.Intn(30)))))
+			}
+			assert.NoError(t, paged.InsertRange(key, values))
+			reference.InsertRange(key, values)
+			inserted[key] = values
+		case len(inserted[key]) > 0 && random.Intn(2) == 0:
+			i := random.Intn(len(inserted[key]))
+			assert.NoError(t, paged.Delete(key, inserted[key][i]))
+			reference.Delete(key, inserted[key][i])
+			inserted[key] = append(inserted[key][:i], inserted[key][i+1:]...)
+		default:
+			start := uint32(random.Intn(150))
+			tuple := NewValueIntervalTuple(Float(random.Intn(10)+1), NewInterval(start, start+1+uint32(random.Intn(30))))
+			assert.NoError(t, paged.Insert(key, tuple))
+			reference.Insert(key, tuple)
+			inserted[key] = append(inserted[key], tuple)
+		}
+
+		// Assert
+		assert.ElementsMatch(t, reference.GetKeys(), paged.GetKeys(), "step %d", step)
+		assert.Equal(t, must(reference.GetTotalWithinInterval(interval)), must(paged.GetTotalWithinInterval(interval)), "step %d", step)
+		assert.Equal(t, must(reference.GetWithinInterval(key, interval)), must(paged.GetWithinInterval(key, interval)), "step %d", step)
+		// Only the roots of the groups stay in memory besides the nodes kept by the pool
+		assert.LessOrEqual(t, paged.total.pool.Len(), capacity+paged.Len(), "step %d", step)
+	}
+
+	// The store only holds the nodes of the total and the groups kept, with the total as its root
+	for key := range inserted {
+		for _, tuple := range inserted[key] {
+			paged.Delete(key, tuple)
+			reference.Delete(key, tuple)
+		}
+	}
+	assert.NoError(t, paged.Flush())
+	assert.Zero(t, paged.Len())
+	assert.Equal(t, paged.total.root.id, store.Root())
+	assert.Equal(t, countNodes(reference.total.root), store.PageCount())
+}
+
+func TestNewPagedGroupedTreeWithNonEmptyStore(t *testing.T) {
+	// Arrange
+	store := NewMemoryNodeStore[Float, uint32]()
+	must(NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float](), store, 4))
+
+	// Act
+	tree, err := NewPagedGroupedSegmentTree[string, Float, uint32](BRANCHING_FACTOR, SumAggregate[Float](), store, 4)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrTreeNotEmpty)
+	assert.Nil(t, tree)
+}
@@ -0,0 +1,157 @@
+package segmenttree
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+var ErrInvalidHistogramBuckets = errors.New("invalid histogram buckets")
+
+// HistogramSize is the maximum number of buckets of a Histogram.
+const HistogramSize = 16
+
+// Histogram counts values in the buckets of a HistogramBuckets, e.g. the number of prescriptions
+// with a dose of 0-1mg, 1-5mg and so on. Histograms are added and subtracted bucket by bucket, so
+// that a Histogram aggregate is invertible. AsFloat64 returns the total count.
+type Histogram struct {
+	counts [HistogramSize]int64
+}
+
+func (x Histogram) Add(y Histogram) Histogram {
+	for i := range x.counts {
+		x.counts[i] += y.counts[i]
+	}
+
+	return x
+}
+
+func (x Histogram) Subtract(y Histogram) Histogram {
+	for i := range x.counts {
+		x.counts[i] -= y.counts[i]
+	}
+
+	return x
+}
+
+func (x Histogram) Inverse() Histogram {
+	for i := range x.counts {
+		x.counts[i] = -x.counts[i]
+	}
+
+	return x
+}
+
+func (x Histogram) AsFloat64() float64 {
+	return float64(x.GetTotal())
+}
+
+func (x Histogram) IsLinear() bool {
+	return true
+}
+
+// GetCount returns the count of the bucket with the index.
+func (x Histogram) GetCount(bucket int) int64 {
+	return x.counts[bucket]
+}
+
+func (x Histogram) GetTotal() int64 {
+	total := int64(0)
+	for _, count := range x.counts {
+		total += count
+	}
+
+	return total
+}
+
+// HistogramBuckets splits the values into buckets at increasing bounds. With the bounds 1 and 5,
+// bucket 0 holds the values below 1, bucket 1 the values from 1 up to 5 and bucket 2 the others.
+type HistogramBuckets struct {
+	bounds []float64
+}
+
+// NewHistogramBuckets returns the buckets between the bounds, which have to be finite and
+// increasing. At most HistogramSize - 1 bounds are supported.
+func NewHistogramBuckets(bounds ...float64) (HistogramBuckets, error) {
+	if len(bounds) >= HistogramSize {
+		return HistogramBuckets{}, fmt.Errorf("%w: %d bounds, at most %d are supported", ErrInvalidHistogramBuckets, len(bounds), HistogramSize-1)
+	}
+	for i, bound := range bounds {
+		if math.IsInf(bound, 0) || math.IsNaN(bound) || (i > 0 && bound <= bounds[i-1]) {
+			return HistogramBuckets{}, fmt.Errorf("%w: %v", ErrInvalidHistogramBuckets, bounds)
+		}
+	}
+
+	return HistogramBuckets{bounds: slices.Clone(bounds)}, nil
+}
+
+func (buckets HistogramBuckets) GetBounds() []float64 {
+	return slices.Clone(buckets.bounds)
+}
+
+// Bucket returns the index of the bucket containing the value.
+func (buckets HistogramBuckets) Bucket(value float64) int {
+	return sort.Search(len(buckets.bounds), func(i int) bool {
+		return buckets.bounds[i] > value
+	})
+}
+
+// Histogram returns the histogram of the single value, to be inserted into a tree with a
+// HistogramAggregate.
+func (buckets HistogramBuckets) Histogram(value float64) Histogram {
+	var histogram Histogram
+	histogram.counts[buckets.Bucket(value)] = 1
+
+	return histogram
+}
+
+// Quantile estimates the q-quantile of the values counted by the histogram, e.g. their median for
+// q = 0.5, by interpolating linearly within the bucket containing it. The first and the last
+// bucket are unbounded, so a quantile within them is estimated as their finite bound. Quantile
+// returns NaN for an empty histogram or a q outside of [0, 1].
+func (buckets HistogramBuckets) Quantile(histogram Histogram, q float64) float64 {
+	total := histogram.GetTotal()
+	if total <= 0 || !(q >= 0 && q <= 1) || len(buckets.bounds) == 0 {
+		return math.NaN()
+	}
+
+	rank := q * float64(total)
+	below := 0.0
+
+	for i := 0; i <= len(buckets.bounds); i++ {
+		count := float64(histogram.counts[i])
+		if count <= 0 || below+count < rank {
+			below += max(count, 0)
+			continue
+		}
+
+		switch i {
+		case 0:
+			return buckets.bounds[0]
+		case len(buckets.bounds):
+			return buckets.bounds[i-1]
+		}
+
+		lower, upper := buckets.bounds[i-1], buckets.bounds[i]
+		return lower + (upper-lower)*(rank-below)/count
+	}
+
+	return buckets.bounds[len(buckets.bounds)-1]
+}
+
+// Quantiles estimates the q-quantile of the histogram of every piece, e.g. of the result of
+// GetWithinInterval. Pieces with an empty histogram have a NaN quantile.
+func Quantiles[T Timestamp](buckets HistogramBuckets, pieces []ValueIntervalTuple[Histogram, T], q float64) []ValueIntervalTuple[Float64, T] {
+	result := make([]ValueIntervalTuple[Float64, T], 0, len(pieces))
+
+	for _, piece := range pieces {
+		result = append(result, ValueIntervalTuple[Float64, T]{
+			value:    Float64(buckets.Quantile(piece.value, q)),
+			interval: piece.interval,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,148 @@
+package segmenttree
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func doseBuckets() HistogramBuckets {
+	return must(NewHistogramBuckets(1, 5, 10))
+}
+
+func prescriptionTestData() []ValueIntervalTuple[Histogram, uint32] {
+	buckets := doseBuckets()
+
+	return []ValueIntervalTuple[Histogram, uint32]{
+		{value: buckets.Histogram(0.5), interval: NewInterval[uint32](0, 30)},
+		{value: buckets.Histogram(2), interval: NewInterval[uint32](10, 40)},
+		{value: buckets.Histogram(4), interval: NewInterval[uint32](20, 50)},
+		{value: buckets.Histogram(12), interval: NewInterval[uint32](35, 45)},
+	}
+}
+
+func TestHistogramAtInstant(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Histogram, uint32](BRANCHING_FACTOR, HistogramAggregate())
+	tree.InsertRange(prescriptionTestData())
+
+	testData := []struct {
+		instant  uint32
+		expected [4]int64
+	}{
+		{5, [4]int64{1, 0, 0, 0}},
+		{25, [4]int64{1, 2, 0, 0}},
+		{37, [4]int64{0, 2, 0, 1}},
+		{47, [4]int64{0, 1, 0, 0}},
+		{55, [4]int64{0, 0, 0, 0}},
+	}
+
+	for _, td := range testData {
+		// Act
+		result := must(tree.GetAtInstant(td.instant))
+
+		// Assert
+		for bucket, expected := range td.expected {
+			assert.Equal(t, expected, result.GetCount(bucket), "instant %d, bucket %d", td.instant, bucket)
+		}
+	}
+}
+
+func TestHistogramAfterDelete(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Histogram, uint32](BRANCHING_FACTOR, HistogramAggregate())
+	tree.InsertRange(prescriptionTestData())
+
+	// Act
+	tree.Delete(ValueIntervalTuple[Histogram, uint32]{value: doseBuckets().Histogram(2), interval: NewInterval[uint32](10, 40)})
+
+	// Assert
+	assert.Equal(t, []ValueIntervalTuple[Histogram, uint32]{
+		{value: doseBuckets().Histogram(0.5), interval: NewInterval[uint32](0, 20)},
+		{value: doseBuckets().Histogram(0.5).Add(doseBuckets().Histogram(4)), interval: NewInterval[uint32](20, 30)},
+	}, must(tree.GetWithinInterval(NewInterval[uint32](0, 30))))
+}
+
+func TestHistogramIntegral(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Histogram, uint32](BRANCHING_FACTOR, HistogramAggregate())
+	tree.InsertRange(prescriptionTestData())
+
+	// Act
+	result := must(tree.Integrate(NewInterval[uint32](0, 60)))
+
+	// Assert
+	assert.Equal(t, float64(30+30+30+10), result)
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	// Arrange
+	buckets := doseBuckets()
+	histogram := Histogram{}
+	for _, value := range []float64{0.5, 2, 2, 3, 4, 6, 8, 12} {
+		histogram = histogram.Add(buckets.Histogram(value))
+	}
+
+	testData := []struct {
+		q        float64
+		expected float64
+	}{
+		{0, 1},
+		{0.125, 1},
+		{0.25, 1 + 4*1.0/4},
+		{0.5, 1 + 4*3.0/4},
+		{0.75, 5 + 5*1.0/2},
+		{0.95, 10},
+		{1, 10},
+	}
+
+	for _, td := range testData {
+		// Act
+		result := buckets.Quantile(histogram, td.q)
+
+		// Assert
+		assert.InDelta(t, td.expected, result, 1e-9, td.q)
+	}
+	assert.True(t, math.IsNaN(buckets.Quantile(histogram, 1.5)))
+	assert.True(t, math.IsNaN(buckets.Quantile(Histogram{}, 0.5)))
+}
+
+func TestQuantilesWithinInterval(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Histogram, uint32](BRANCHING_FACTOR, HistogramAggregate())
+	tree.InsertRange(prescriptionTestData())
+
+	// Act
+	result := Quantiles(doseBuckets(), must(tree.GetWithinInterval(NewInterval[uint32](0, 40))), 0.5)
+
+	// Assert
+	assert.Equal(t, []ValueIntervalTuple[Float64, uint32]{
+		{value: 1, interval: NewInterval[uint32](0, 10)},
+		{value: 1, interval: NewInterval[uint32](10, 20)},
+		{value: 1 + 4*0.5/2, interval: NewInterval[uint32](20, 30)},
+		{value: 1 + 4*1.0/2, interval: NewInterval[uint32](30, 35)},
+		{value: 1 + 4*1.5/2, interval: NewInterval[uint32](35, 40)},
+	}, result)
+}
+
+func TestInvalidHistogramBuckets(t *testing.T) {
+	for _, bounds := range [][]float64{{1, 1}, {5, 1}, {math.Inf(1)}, {math.NaN()}, make([]float64, HistogramSize)} {
+		// Act
+		_, err := NewHistogramBuckets(bounds...)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidHistogramBuckets, bounds)
+	}
+}
+
+func TestHistogramBucket(t *testing.T) {
+	// Arrange
+	buckets := doseBuckets()
+
+	// Assert
+	assert.Equal(t, 0, buckets.Bucket(0.99))
+	assert.Equal(t, 1, buckets.Bucket(1))
+	assert.Equal(t, 2, buckets.Bucket(9.5))
+	assert.Equal(t, 3, buckets.Bucket(10))
+}
@@ -0,0 +1,85 @@
+package segmenttree
+
+// Integrate returns the area under the aggregate within the interval, i.e. the sum of
+// value.AsFloat64() * length over the pieces returned by GetWithinInterval. For a dosage
+// tree, this is the total drug exposure (dose × duration).
+//
+// If the aggregate is additive (see Aggregate.isAdditive), every node keeps the integral of its
+// subtree and Integrate only descends into the nodes partially overlapping the interval, which
+// takes O(log n). Otherwise, the integral is computed from the pieces.
+func (tree *SegmentTreeImpl[V, T]) Integrate(interval Interval[T]) (float64, error) {
+	if err := checkIntervals(interval); err != nil {
+		return 0, err
+	}
+
+	var integral float64
+
+	err := tree.run(false, func() (err error) {
+		integral, err = tree.integrateRoot(tree.root, interval)
+		return err
+	})
+
+	return integral, err
+}
+
+// integrateFrom integrates a root which is not modified anymore, see lookupFrom.
+func (tree *SegmentTreeImpl[V, T]) integrateFrom(root *Node[V, T], interval Interval[T]) (float64, error) {
+	if err := checkIntervals(interval); err != nil {
+		return 0, err
+	}
+
+	var integral float64
+
+	err := catch(func() (err error) {
+		integral, err = tree.integrateRoot(root, interval)
+		return err
+	})
+
+	return integral, err
+}
+
+func (tree *SegmentTreeImpl[V, T]) integrateRoot(root *Node[V, T], interval Interval[T]) (float64, error) {
+	if tree.integrals {
+		return tree.integrate(root, NewInterval(0, MaxInstant[T]()), interval), nil
+	}
+
+	pieces, err := tree.rangeQuery(root, NewInterval(0, MaxInstant[T]()), interval, tree.aggregate.neutralElement)
+	if err != nil {
+		return 0, err
+	}
+
+	integral := 0.0
+	for _, piece := range pieces {
+		integral += piece.value.AsFloat64() * float64(piece.interval.GetLength())
+	}
+
+	return integral, nil
+}
+
+func (tree *SegmentTreeImpl[V, T]) integrate(node *Node[V, T], bounds Interval[T], interval Interval[T]) float64 {
+	// As the aggregate is additive, the value of an interior node contributes to the integral
+	// over the whole part of its interval within the queried interval.
+	integral := 0.0
+
+	for index, nodeInterval := range node.getIntervalsWithin(bounds) {
+		intersection := interval.IntersectionWith(nodeInterval)
+
+		if intersection.GetLength() == 0 {
+			continue
+		}
+
+		integral += node.values[index].AsFloat64() * float64(intersection.GetLength())
+
+		if node.isLeaf {
+			continue
+		}
+
+		if nodeInterval.IsSubsetOf(interval) {
+			integral += node.children[index].node.integral
+		} else {
+			integral += tree.integrate(node.children[index].node, nodeInterval, interval)
+		}
+	}
+
+	return integral
+}
@@ -0,0 +1,189 @@
+package segmenttree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntegrateDosageScenario(t *testing.T) {
+	forEachValueType(t, testIntegrateDosageScenario[Float], testIntegrateDosageScenario[Float64], testIntegrateDosageScenario[Int64], testIntegrateDosageScenario[Decimal])
+}
+
+func testIntegrateDosageScenario[V scalarValue[V]](t *testing.T) {
+	// Arrange
+	testData := []struct {
+		interval Interval[uint32]
+		expected float64
+	}{
+		{NewInterval[uint32](0, 60), 240},
+		{NewInterval[uint32](0, math.MaxUint32), 240},
+		{NewInterval[uint32](12, 18), 42},
+		{NewInterval[uint32](0, 5), 0},
+		{NewInterval[uint32](44, 46), 6},
+		{NewInterval[uint32](20, 20), 0},
+	}
+
+	tree := NewSegmentTree[V, uint32](BRANCHING_FACTOR, SumAggregate[V]())
+	tree.InsertRange(dosageTestData[V]())
+
+	for _, td := range testData {
+		// Act
+		result := must(tree.Integrate(td.interval))
+
+		// Assert
+		assert.Equal(t, td.expected, result, "interval %v", td.interval)
+	}
+}
+
+func TestIntegrateKeepsSubtreeIntegrals(t *testing.T) {
+	forEachValueType(t, testIntegrateKeepsSubtreeIntegrals[Float], testIntegrateKeepsSubtreeIntegrals[Float64], testIntegrateKeepsSubtreeIntegrals[Int64], testIntegrateKeepsSubtreeIntegrals[Decimal])
+}
+
+func testIntegrateKeepsSubtreeIntegrals[V scalarValue[V]](t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[V, uint32](BRANCHING_FACTOR, SumAggregate[V]())
+
+	// Act
+	for _, tuple := range dosageTestData[V]() {
+		tree.Insert(tuple)
+	}
+	tree.Delete(dosageTestData[V]()[4])
+
+	// Assert
+	assert.True(t, tree.integrals)
+	assert.Equal(t, 200.0, tree.root.integral)
+	assertIntegrals(t, tree.root, NewInterval(0, MaxInstant[uint32]()))
+}
+
+// Compares Integrate against the integral computed from the pieces returned by GetWithinInterval.
+func TestIntegrateMatchesPieces(t *testing.T) {
+	forEachValueType(t, testIntegrateMatchesPieces[Float], testIntegrateMatchesPieces[Float64], testIntegrateMatchesPieces[Int64], testIntegrateMatchesPieces[Decimal])
+}
+
+func testIntegrateMatchesPieces[V scalarValue[V]](t *testing.T) {
+	for _, branchingFactor := range []uint32{4, 5, 8} {
+		// Arrange
+		random := rand.New(rand.NewSource(int64(branchingFactor)))
+		tree := NewSegmentTree[V, uint32](branchingFactor, SumAggregate[V]())
+		inserted := []ValueIntervalTuple[V, uint32]{}
+
+		for step := 0; step < 300; step++ {
+			// Act
+			if len(inserted) > 0 && random.Intn(3) == 0 {
+				i := random.Intn(len(inserted))
+				tree.Delete(inserted[i])
+				inserted = append(inserted[:i], inserted[i+1:]...)
+			} else {
+				start := uint32(random.Intn(1000))
+				tuple := ValueIntervalTuple[V, uint32]{
+					value:    scalar[V](random.Intn(5) + 1),
+					interval: NewInterval(start, start+1+uint32(random.Intn(100))),
+				}
+				tree.Insert(tuple)
+				inserted = append(inserted, tuple)
+			}
+
+			// Assert
+			start := uint32(random.Intn(1100))
+			interval := NewInterval(start, start+uint32(random.Intn(500)))
+
+			expected := 0.0
+			for _, piece := range must(tree.GetWithinInterval(interval)) {
+				expected += piece.value.AsFloat64() * float64(piece.interval.GetLength())
+			}
+
+			if !assert.InDelta(t, expected, must(tree.Integrate(interval)), 1e-6, "b = %d, step %d, interval %v", branchingFactor, step, interval) {
+				return
+			}
+		}
+
+		assertIntegrals(t, tree.root, NewInterval(0, MaxInstant[uint32]()))
+	}
+}
+
+func TestIntegrateWithoutSubtreeIntegrals(t *testing.T) {
+	// Arrange
+	maxTree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, MaxAggregate(Float(0)))
+	averageTree := NewSegmentTree[AverageTuple, uint32](BRANCHING_FACTOR, NewAggregate(Average[AverageTuple], InverseAverage[AverageTuple], Identity[AverageTuple], AverageTuple{}, WithName("average")))
+
+	maxTree.InsertRange(dosageTestData[Float]())
+	averageTree.Insert(ValueIntervalTuple[AverageTuple, uint32]{interval: NewInterval[uint32](10, 20), value: AverageTuple{2, 1}})
+	averageTree.Insert(ValueIntervalTuple[AverageTuple, uint32]{interval: NewInterval[uint32](15, 20), value: AverageTuple{4, 1}})
+
+	// Act
+	maxIntegral := must(maxTree.Integrate(NewInterval[uint32](0, 60)))
+	averageIntegral := must(averageTree.Integrate(NewInterval[uint32](10, 20)))
+
+	// Assert
+	assert.False(t, maxTree.integrals)
+	assert.False(t, averageTree.integrals)
+	assert.Equal(t, 2.0*5+3*20+2*5+4*10+1*5, maxIntegral)
+	assert.Equal(t, 2.0*5+3*5, averageIntegral)
+}
+
+func TestIntegrateWithUserDefinedAggregate(t *testing.T) {
+	// Arrange
+	additive := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, NewAggregate(Sum[Float], InverseSum[Float], Identity[Float], Float(0), Additive()))
+	undeclared := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, NewAggregate(Sum[Float], InverseSum[Float], Identity[Float], Float(0)))
+	additive.InsertRange(dosageTestData[Float]())
+	undeclared.InsertRange(dosageTestData[Float]())
+
+	// Act
+	additiveIntegral := must(additive.Integrate(NewInterval[uint32](0, 60)))
+	undeclaredIntegral := must(undeclared.Integrate(NewInterval[uint32](0, 60)))
+
+	// Assert
+	assert.True(t, additive.integrals)
+	assert.False(t, undeclared.integrals)
+	assert.Equal(t, 240.0, additiveIntegral)
+	assert.Equal(t, 240.0, undeclaredIntegral)
+	assertIntegrals(t, additive.root, NewInterval(0, MaxInstant[uint32]()))
+}
+
+func TestIntegrateSnapshot(t *testing.T) {
+	// Arrange
+	tree := NewPersistentSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+	before := tree.Snapshot()
+
+	// Act
+	tree.Delete(dosageTestData[Float]()[5])
+
+	// Assert
+	assert.Equal(t, 240.0, must(before.Integrate(NewInterval[uint32](0, 60))))
+	assert.Equal(t, 200.0, must(tree.Integrate(NewInterval[uint32](0, 60))))
+}
+
+func TestIntegratePagedTree(t *testing.T) {
+	// Arrange
+	tree := must(NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float](), NewMemoryNodeStore[Float, uint32](), 2))
+
+	// Act
+	tree.InsertRange(dosageTestData[Float]())
+
+	// Assert
+	assert.False(t, tree.integrals)
+	assert.Equal(t, 42.0, must(tree.Integrate(NewInterval[uint32](12, 18))))
+}
+
+// assertIntegrals checks the integral of every node against the integral computed from its values.
+func assertIntegrals[V Addable[V], T Timestamp](t *testing.T, node *Node[V, T], bounds Interval[T]) float64 {
+	integral := 0.0
+
+	for index, interval := range node.getIntervalsWithin(bounds) {
+		integral += node.values[index].AsFloat64() * float64(interval.GetLength())
+		if !node.isLeaf {
+			integral += assertIntegrals(t, node.children[index].node, interval)
+		}
+	}
+
+	assert.False(t, node.summaryStale)
+	assert.InDelta(t, integral, node.integral, 1e-6)
+
+	return integral
+}
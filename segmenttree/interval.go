@@ -1,40 +1,58 @@
 package segmenttree
 
-var EmptyInterval Interval = NewInterval(0, 0)
+// Timestamp is the constraint for the time axis of a segment tree. Only
+// unsigned integers are allowed, so the timeline always starts at 0.
+type Timestamp interface {
+	~uint8 | ~uint16 | ~uint32 | ~uint64
+}
 
-type Interval struct {
-	start uint32
-	end   uint32
+type Interval[T Timestamp] struct {
+	start T
+	end   T
 }
 
-func NewInterval(start uint32, end uint32) Interval {
-	if start > end {
-		panic("Interval start must be before end")
-	}
+func EmptyInterval[T Timestamp]() Interval[T] {
+	return NewInterval[T](0, 0)
+}
 
-	return Interval{
+// NewInterval creates the interval [start, end). An interval with start > end is invalid, the
+// tree operations reject it with ErrInvalidInterval.
+func NewInterval[T Timestamp](start T, end T) Interval[T] {
+	return Interval[T]{
 		start: start,
 		end:   end,
 	}
 }
 
-func (interval Interval) IntersectionWith(otherInterval Interval) Interval {
+func (interval Interval[T]) IntersectionWith(otherInterval Interval[T]) Interval[T] {
 	if interval.end < otherInterval.start ||
 		otherInterval.end < interval.start {
-		return EmptyInterval
+		return EmptyInterval[T]()
 	}
 
-	start := MaxUint32(interval.start, otherInterval.start)
-	end := MinUint32(interval.end, otherInterval.end)
+	start := MaxTime(interval.start, otherInterval.start)
+	end := MinTime(interval.end, otherInterval.end)
 
 	return NewInterval(start, end)
 }
 
-func (interval Interval) IsSubsetOf(otherInterval Interval) bool {
+func (interval Interval[T]) IsSubsetOf(otherInterval Interval[T]) bool {
 	return interval.start >= otherInterval.start &&
 		interval.end <= otherInterval.end
 }
 
-func (interval Interval) GetLength() uint32 {
+func (interval Interval[T]) IsValid() bool {
+	return interval.start <= interval.end
+}
+
+func (interval Interval[T]) GetStart() T {
+	return interval.start
+}
+
+func (interval Interval[T]) GetEnd() T {
+	return interval.end
+}
+
+func (interval Interval[T]) GetLength() T {
 	return interval.end - interval.start
 }
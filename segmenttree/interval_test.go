@@ -12,38 +12,36 @@ func TestNewInterval(t *testing.T) {
 	assert := assert.New(t)
 
 	// Act
-	interval := NewInterval(1, 2)
+	interval := NewInterval[uint32](1, 2)
 
 	// Assert
-	assert.Equal(Interval{1, 2}, interval)
+	assert.Equal(Interval[uint32]{1, 2}, interval)
 }
 
 func TestNewIntervalInvalid(t *testing.T) {
-	// Assert
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("expected panic")
-		}
-	}()
-
 	// Act
-	NewInterval(2, 1)
+	interval := NewInterval[uint32](2, 1)
+
+	// Assert
+	assert.False(t, interval.IsValid())
+	assert.True(t, NewInterval[uint32](1, 1).IsValid())
+	assert.ErrorIs(t, checkIntervals(NewInterval[uint32](1, 2), interval), ErrInvalidInterval)
 }
 
 func TestIntersectionWith(t *testing.T) {
 	// Arrange
 	testData := []struct {
-		a        Interval
-		b        Interval
-		expected Interval
+		a        Interval[uint32]
+		b        Interval[uint32]
+		expected Interval[uint32]
 	}{
-		{NewInterval(1, 2), NewInterval(3, 4), EmptyInterval},
-		{NewInterval(3, 4), NewInterval(1, 2), EmptyInterval},
-		{NewInterval(1, 3), NewInterval(2, 4), NewInterval(2, 3)},
-		{NewInterval(2, 4), NewInterval(1, 3), NewInterval(2, 3)},
-		{NewInterval(1, 4), NewInterval(1, 4), NewInterval(1, 4)},
-		{NewInterval(1, 4), NewInterval(2, 3), NewInterval(2, 3)},
-		{NewInterval(1, 2), NewInterval(2, 3), NewInterval(2, 2)},
+		{NewInterval[uint32](1, 2), NewInterval[uint32](3, 4), EmptyInterval[uint32]()},
+		{NewInterval[uint32](3, 4), NewInterval[uint32](1, 2), EmptyInterval[uint32]()},
+		{NewInterval[uint32](1, 3), NewInterval[uint32](2, 4), NewInterval[uint32](2, 3)},
+		{NewInterval[uint32](2, 4), NewInterval[uint32](1, 3), NewInterval[uint32](2, 3)},
+		{NewInterval[uint32](1, 4), NewInterval[uint32](1, 4), NewInterval[uint32](1, 4)},
+		{NewInterval[uint32](1, 4), NewInterval[uint32](2, 3), NewInterval[uint32](2, 3)},
+		{NewInterval[uint32](1, 2), NewInterval[uint32](2, 3), NewInterval[uint32](2, 2)},
 	}
 
 	for _, td := range testData {
@@ -58,15 +56,15 @@ func TestIntersectionWith(t *testing.T) {
 func TestIsSubsetOf(t *testing.T) {
 	// Arrange
 	testData := []struct {
-		a        Interval
-		b        Interval
+		a        Interval[uint32]
+		b        Interval[uint32]
 		isSubset bool
 	}{
-		{NewInterval(2, 3), NewInterval(2, 3), true},
-		{NewInterval(1, 3), NewInterval(2, 4), false},
-		{NewInterval(3, 5), NewInterval(2, 4), false},
-		{NewInterval(3, 5), NewInterval(1, 10), true},
-		{NewInterval(1, 5), NewInterval(2, 4), false},
+		{NewInterval[uint32](2, 3), NewInterval[uint32](2, 3), true},
+		{NewInterval[uint32](1, 3), NewInterval[uint32](2, 4), false},
+		{NewInterval[uint32](3, 5), NewInterval[uint32](2, 4), false},
+		{NewInterval[uint32](3, 5), NewInterval[uint32](1, 10), true},
+		{NewInterval[uint32](1, 5), NewInterval[uint32](2, 4), false},
 	}
 
 	for _, td := range testData {
@@ -81,16 +79,16 @@ func TestIsSubsetOf(t *testing.T) {
 func TestGetLength(t *testing.T) {
 	// Arrange
 	testData := []struct {
-		a      Interval
+		a      Interval[uint32]
 		length uint32
 	}{
-		{NewInterval(0, 0), 0},
-		{NewInterval(2, 2), 0},
-		{NewInterval(2, 3), 1},
-		{NewInterval(1, 3), 2},
-		{NewInterval(3, 5), 2},
-		{NewInterval(3, 5), 2},
-		{NewInterval(1, math.MaxUint32), math.MaxUint32 - 1},
+		{NewInterval[uint32](0, 0), 0},
+		{NewInterval[uint32](2, 2), 0},
+		{NewInterval[uint32](2, 3), 1},
+		{NewInterval[uint32](1, 3), 2},
+		{NewInterval[uint32](3, 5), 2},
+		{NewInterval[uint32](3, 5), 2},
+		{NewInterval[uint32](1, math.MaxUint32), math.MaxUint32 - 1},
 	}
 
 	for _, td := range testData {
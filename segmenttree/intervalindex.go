@@ -0,0 +1,154 @@
+package segmenttree
+
+import "math/rand/v2"
+
+// intervalIndex keeps entries with an interval each, e.g. the tuples inserted into a tree with an
+// aggregate without an inverse, from which the pieces within the interval of a deleted tuple are
+// recomputed (see SegmentTreeImpl.deleteAndRecompute). It is a treap ordered by the intervals of
+// the entries whose nodes keep the largest end within their subtree, so that the k entries
+// overlapping an interval are found in O(log m + k) for m entries, and an entry is inserted or
+// deleted in O(log m).
+//
+// The index is persistent: insert and delete copy the nodes on the modified paths and return a new
+// index, while the old one stays valid. A tree copying on write thus reverts its tuples together
+// with its root, see SegmentTreeImpl.run. The empty index is nil.
+type intervalIndex[E comparable, T Timestamp] struct {
+	entry       E
+	interval    Interval[T]
+	priority    uint64
+	left, right *intervalIndex[E, T]
+	// end is the largest end of the intervals within the subtree and size the number of its entries
+	end  T
+	size int
+}
+
+// newTupleIndex returns the index of the tuples by their intervals.
+func newTupleIndex[V Addable[V], T Timestamp](tuples []ValueIntervalTuple[V, T]) *intervalIndex[ValueIntervalTuple[V, T], T] {
+	var index *intervalIndex[ValueIntervalTuple[V, T], T]
+	for _, tuple := range tuples {
+		index = index.insert(tuple, tuple.interval)
+	}
+
+	return index
+}
+
+func (index *intervalIndex[E, T]) len() int {
+	if index == nil {
+		return 0
+	}
+
+	return index.size
+}
+
+// insert returns the index with the entry added.
+func (index *intervalIndex[E, T]) insert(entry E, interval Interval[T]) *intervalIndex[E, T] {
+	before, after := index.split(interval)
+	node := &intervalIndex[E, T]{entry: entry, interval: interval, priority: rand.Uint64()}
+
+	return joinIntervalIndexes(joinIntervalIndexes(before, node.with(nil, nil)), after)
+}
+
+// delete returns the index with one occurrence of the entry with the interval removed, and whether
+// it was found.
+func (index *intervalIndex[E, T]) delete(entry E, interval Interval[T]) (*intervalIndex[E, T], bool) {
+	if index == nil || index.end < interval.end {
+		return index, false
+	}
+	if index.entry == entry && index.interval == interval {
+		return joinIntervalIndexes(index.left, index.right), true
+	}
+
+	// Entries with the same interval may be on both sides
+	if !intervalBefore(index.interval, interval) {
+		if left, ok := index.left.delete(entry, interval); ok {
+			return index.with(left, index.right), true
+		}
+	}
+	if !intervalBefore(interval, index.interval) {
+		if right, ok := index.right.delete(entry, interval); ok {
+			return index.with(index.left, right), true
+		}
+	}
+
+	return index, false
+}
+
+// overlapping appends the entries overlapping the interval to the result, ordered by their intervals.
+func (index *intervalIndex[E, T]) overlapping(interval Interval[T], result []E) []E {
+	if index == nil || index.end <= interval.start {
+		return result
+	}
+
+	result = index.left.overlapping(interval, result)
+	if index.interval.start < interval.end {
+		if index.interval.IntersectionWith(interval).GetLength() > 0 {
+			result = append(result, index.entry)
+		}
+		result = index.right.overlapping(interval, result)
+	}
+
+	return result
+}
+
+// all appends all entries to the result, ordered by their intervals.
+func (index *intervalIndex[E, T]) all(result []E) []E {
+	if index == nil {
+		return result
+	}
+
+	result = index.left.all(result)
+	result = append(result, index.entry)
+	return index.right.all(result)
+}
+
+// split returns the entries ordered before the interval and the remaining ones.
+func (index *intervalIndex[E, T]) split(interval Interval[T]) (*intervalIndex[E, T], *intervalIndex[E, T]) {
+	if index == nil {
+		return nil, nil
+	}
+
+	if intervalBefore(index.interval, interval) {
+		before, after := index.right.split(interval)
+		return index.with(index.left, before), after
+	}
+
+	before, after := index.left.split(interval)
+	return before, index.with(after, index.right)
+}
+
+// joinIntervalIndexes returns the index of the entries of both indexes, all of whose entries in
+// before are ordered before those in after.
+func joinIntervalIndexes[E comparable, T Timestamp](before *intervalIndex[E, T], after *intervalIndex[E, T]) *intervalIndex[E, T] {
+	switch {
+	case before == nil:
+		return after
+	case after == nil:
+		return before
+	case before.priority > after.priority:
+		return before.with(before.left, joinIntervalIndexes(before.right, after))
+	default:
+		return after.with(joinIntervalIndexes(before, after.left), after.right)
+	}
+}
+
+// with returns a copy of the node with the given children.
+func (index *intervalIndex[E, T]) with(left *intervalIndex[E, T], right *intervalIndex[E, T]) *intervalIndex[E, T] {
+	node := *index
+	node.left, node.right = left, right
+	node.end, node.size = node.interval.end, 1
+
+	for _, child := range []*intervalIndex[E, T]{left, right} {
+		if child != nil {
+			node.end = max(node.end, child.end)
+			node.size += child.size
+		}
+	}
+
+	return &node
+}
+
+// intervalBefore reports whether the interval x is ordered before y, by their starts and then by
+// their ends.
+func intervalBefore[T Timestamp](x Interval[T], y Interval[T]) bool {
+	return x.start < y.start || x.start == y.start && x.end < y.end
+}
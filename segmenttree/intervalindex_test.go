@@ -0,0 +1,79 @@
+package segmenttree
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Compares the index against a plain slice of the inserted tuples.
+func TestIntervalIndexMatchesSlice(t *testing.T) {
+	// Arrange
+	random := rand.New(rand.NewSource(1))
+	var index *intervalIndex[ValueIntervalTuple[Float, uint32], uint32]
+	inserted := []ValueIntervalTuple[Float, uint32]{}
+
+	for step := 0; step < 1000; step++ {
+		// Act
+		if len(inserted) > 0 && random.Intn(3) == 0 {
+			i := random.Intn(len(inserted))
+			var found bool
+			index, found = index.delete(inserted[i], inserted[i].interval)
+			assert.True(t, found, "step %d", step)
+			inserted = append(inserted[:i], inserted[i+1:]...)
+		} else {
+			// Few distinct intervals and values, so that many tuples are equal
+			start := uint32(random.Intn(50))
+			tuple := NewValueIntervalTuple(Float(random.Intn(3)), NewInterval(start, start+1+uint32(random.Intn(10))))
+			index = index.insert(tuple, tuple.interval)
+			inserted = append(inserted, tuple)
+		}
+
+		// Assert
+		query := NewInterval(uint32(random.Intn(60)), 0)
+		query.end = query.start + uint32(random.Intn(20))
+		expected := []ValueIntervalTuple[Float, uint32]{}
+		for _, tuple := range inserted {
+			if tuple.interval.IntersectionWith(query).GetLength() > 0 {
+				expected = append(expected, tuple)
+			}
+		}
+
+		assert.Equal(t, len(inserted), index.len(), "step %d", step)
+		assert.ElementsMatch(t, inserted, index.all(nil), "step %d", step)
+		if !assert.ElementsMatch(t, expected, index.overlapping(query, nil), "step %d", step) {
+			return
+		}
+	}
+}
+
+func TestIntervalIndexDeleteUnknownTuple(t *testing.T) {
+	// Arrange
+	index := newTupleIndex(dosageTestData[Float]())
+	tuple := dosageTestData[Float]()[0]
+	tuple.value++
+
+	// Act
+	deleted, found := index.delete(tuple, tuple.interval)
+
+	// Assert
+	assert.False(t, found)
+	assert.Same(t, index, deleted)
+}
+
+// Deletes from an index and inserts into it. The index before is unchanged, as a tree copying on
+// write relies on it to revert its tuples.
+func TestIntervalIndexIsPersistent(t *testing.T) {
+	// Arrange
+	index := newTupleIndex(dosageTestData[Float]())
+
+	// Act
+	deleted, _ := index.delete(dosageTestData[Float]()[2], dosageTestData[Float]()[2].interval)
+	inserted := index.insert(NewValueIntervalTuple(Float(7), NewInterval[uint32](1, 2)), NewInterval[uint32](1, 2))
+
+	// Assert
+	assert.ElementsMatch(t, dosageTestData[Float](), index.all(nil))
+	assert.ElementsMatch(t, append(dosageTestData[Float]()[:2:2], dosageTestData[Float]()[3:]...), deleted.all(nil))
+	assert.Equal(t, len(dosageTestData[Float]())+1, inserted.len())
+}
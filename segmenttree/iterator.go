@@ -0,0 +1,319 @@
+package segmenttree
+
+import "iter"
+
+// Cursor walks the pieces of a tree within an interval, i.e. the intervals with their aggregated
+// values, without collecting them first. It is positioned with First, Last or Seek and moved with
+// Next and Prev, which take amortized O(1). It walks the pieces as kept by the leaves, so that
+// unlike GetWithinInterval, it does not merge adjacent pieces of neighbouring leaves with equal
+// values.
+//
+// A cursor of a tree copying on write (see SetCopyOnWrite) reads the version of the tree it was
+// created for, as modifications made afterwards copy the nodes they modify. The nodes of other
+// trees are modified in place, so such a tree must not be modified while a cursor is used.
+type Cursor[V Addable[V], T Timestamp] struct {
+	tree     *SegmentTreeImpl[V, T]
+	root     *Node[V, T]
+	bounds   Interval[T]
+	value    V
+	interval Interval[T]
+	// path holds the nodes from the root to the leaf of the current piece. It is empty if the
+	// cursor is not positioned at a piece. In a paged tree, the nodes below the root are only
+	// pinned during a movement, see load.
+	path []cursorFrame[V, T]
+	// current is the current piece. It is kept, as the nodes of a paged tree may be evicted
+	// after a movement.
+	current ValueIntervalTuple[V, T]
+	err     error
+}
+
+type cursorFrame[V Addable[V], T Timestamp] struct {
+	node   *Node[V, T]
+	bounds Interval[T]
+	index  uint32
+	// value is the aggregated value of the node's ancestors.
+	value V
+}
+
+// NewCursor returns a cursor over the pieces within the interval. It is not positioned at a piece yet.
+func (tree *SegmentTreeImpl[V, T]) NewCursor(interval Interval[T]) *Cursor[V, T] {
+	cursor := tree.newCursor(tree.root, NewInterval(0, MaxInstant[T]()), tree.aggregate.neutralElement, interval)
+	cursor.err = checkIntervals(interval)
+
+	return cursor
+}
+
+// newCursor returns a cursor over the pieces of the subtree within the interval, see rangeQuery.
+func (tree *SegmentTreeImpl[V, T]) newCursor(root *Node[V, T], bounds Interval[T], value V, interval Interval[T]) *Cursor[V, T] {
+	return &Cursor[V, T]{
+		tree:     tree,
+		root:     root,
+		bounds:   bounds,
+		value:    value,
+		interval: interval.IntersectionWith(bounds),
+	}
+}
+
+// Iterate returns the pieces within the interval in order of time. Unlike GetWithinInterval, the
+// pieces are found while iterating, so that stopping early does not visit the remaining ones. An
+// invalid interval or a failure yields no further pieces; use a Cursor to tell them apart.
+func (tree *SegmentTreeImpl[V, T]) Iterate(interval Interval[T]) iter.Seq2[Interval[T], V] {
+	return func(yield func(Interval[T], V) bool) {
+		cursor := tree.NewCursor(interval)
+
+		for ok := cursor.First(); ok && yield(cursor.GetInterval(), cursor.GetValue()); ok = cursor.Next() {
+		}
+	}
+}
+
+// IterateBackward returns the pieces within the interval in reverse order of time, see Iterate.
+func (tree *SegmentTreeImpl[V, T]) IterateBackward(interval Interval[T]) iter.Seq2[Interval[T], V] {
+	return func(yield func(Interval[T], V) bool) {
+		cursor := tree.NewCursor(interval)
+
+		for ok := cursor.Last(); ok && yield(cursor.GetInterval(), cursor.GetValue()); ok = cursor.Prev() {
+		}
+	}
+}
+
+// First positions the cursor at the first piece and reports whether there is one.
+func (cursor *Cursor[V, T]) First() bool {
+	return cursor.move(cursor.first)
+}
+
+// Last positions the cursor at the last piece and reports whether there is one.
+func (cursor *Cursor[V, T]) Last() bool {
+	return cursor.move(cursor.last)
+}
+
+// Seek positions the cursor at the piece containing the instant, or at the first piece if the
+// instant is before the cursor's interval. It reports whether there is such a piece.
+func (cursor *Cursor[V, T]) Seek(instant T) bool {
+	return cursor.move(func() error {
+		return cursor.seek(instant)
+	})
+}
+
+// Next moves the cursor to the next piece and reports whether there is one.
+func (cursor *Cursor[V, T]) Next() bool {
+	return cursor.move(cursor.next)
+}
+
+// Prev moves the cursor to the previous piece and reports whether there is one.
+func (cursor *Cursor[V, T]) Prev() bool {
+	return cursor.move(cursor.prev)
+}
+
+// Valid reports whether the cursor is positioned at a piece.
+func (cursor *Cursor[V, T]) Valid() bool {
+	return len(cursor.path) > 0
+}
+
+// Err returns the error which stopped the cursor, if any.
+func (cursor *Cursor[V, T]) Err() error {
+	return cursor.err
+}
+
+// GetInterval returns the interval of the current piece, clipped to the cursor's interval.
+func (cursor *Cursor[V, T]) GetInterval() Interval[T] {
+	return cursor.current.interval
+}
+
+// GetValue returns the aggregated value of the current piece.
+func (cursor *Cursor[V, T]) GetValue() V {
+	return cursor.current.value
+}
+
+// move runs a movement as a tree operation, so that the nodes of a paged tree are loaded.
+func (cursor *Cursor[V, T]) move(movement func() error) bool {
+	if cursor.err != nil {
+		return false
+	}
+
+	cursor.err = cursor.tree.run(false, func() error {
+		if err := cursor.load(); err != nil {
+			return err
+		}
+		if err := movement(); err != nil {
+			return err
+		}
+
+		cursor.current = ValueIntervalTuple[V, T]{}
+		if cursor.Valid() {
+			cursor.current = cursor.piece()
+		}
+		cursor.unpin()
+		return nil
+	})
+	if cursor.err != nil {
+		cursor.path = cursor.path[:0]
+	}
+
+	return cursor.Valid()
+}
+
+func (cursor *Cursor[V, T]) first() error {
+	return cursor.seek(cursor.interval.start)
+}
+
+func (cursor *Cursor[V, T]) last() error {
+	if cursor.interval.GetLength() == 0 {
+		cursor.truncate(0)
+		return nil
+	}
+
+	return cursor.seek(cursor.interval.end - 1)
+}
+
+func (cursor *Cursor[V, T]) seek(instant T) error {
+	cursor.truncate(0)
+
+	if cursor.interval.GetLength() == 0 || instant >= cursor.interval.end {
+		return nil
+	}
+
+	instant = max(instant, cursor.interval.start)
+	frame := cursorFrame[V, T]{node: cursor.root, bounds: cursor.bounds, value: cursor.value}
+
+	for {
+		frame.index = frame.node.findIntervalIndex(instant)
+		cursor.path = append(cursor.path, frame)
+
+		if frame.node.isLeaf {
+			return nil
+		}
+
+		var err error
+		if frame, err = cursor.child(frame); err != nil {
+			return err
+		}
+	}
+}
+
+func (cursor *Cursor[V, T]) next() error {
+	for {
+		if ok, err := cursor.step(1); !ok || err != nil {
+			return err
+		}
+		interval := cursor.leafInterval()
+
+		if interval.start >= cursor.interval.end {
+			cursor.truncate(0)
+		}
+		if interval.GetLength() > 0 {
+			return nil
+		}
+	}
+}
+
+func (cursor *Cursor[V, T]) prev() error {
+	for {
+		if ok, err := cursor.step(-1); !ok || err != nil {
+			return err
+		}
+		interval := cursor.leafInterval()
+
+		if interval.end <= cursor.interval.start {
+			cursor.truncate(0)
+		}
+		if interval.GetLength() > 0 {
+			return nil
+		}
+	}
+}
+
+// step moves the cursor to the adjacent piece in the direction, regardless of the cursor's
+// interval. It reports whether there is such a piece.
+func (cursor *Cursor[V, T]) step(direction int) (bool, error) {
+	for len(cursor.path) > 0 {
+		top := &cursor.path[len(cursor.path)-1]
+
+		if (direction < 0 && top.index == 0) || (direction > 0 && top.index == top.node.size()) {
+			cursor.truncate(len(cursor.path) - 1)
+			continue
+		}
+
+		top.index = uint32(int(top.index) + direction)
+
+		for frame := *top; !frame.node.isLeaf; {
+			var err error
+			if frame, err = cursor.child(frame); err != nil {
+				return false, err
+			}
+			if direction < 0 {
+				frame.index = frame.node.size()
+			}
+			cursor.path = append(cursor.path, frame)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// child returns the frame of the child at the frame's index, positioned at its first interval.
+// In a paged tree, the child is pinned until it is removed from the path, see truncate.
+func (cursor *Cursor[V, T]) child(frame cursorFrame[V, T]) (cursorFrame[V, T], error) {
+	node := frame.node
+	child, err := node.child(int(frame.index))
+	if err != nil {
+		return cursorFrame[V, T]{}, err
+	}
+
+	return cursorFrame[V, T]{
+		node:   child,
+		bounds: node.getIntervalWithin(frame.bounds, frame.index),
+		value:  cursor.tree.aggregate.combine(node.values[frame.index], frame.value),
+	}, nil
+}
+
+// piece returns the current piece, with its interval clipped to the cursor's interval.
+func (cursor *Cursor[V, T]) piece() ValueIntervalTuple[V, T] {
+	leaf := cursor.path[len(cursor.path)-1]
+
+	return ValueIntervalTuple[V, T]{
+		value:    cursor.tree.aggregate.combine(leaf.node.values[leaf.index], leaf.value),
+		interval: cursor.interval.IntersectionWith(cursor.leafInterval()),
+	}
+}
+
+// leafInterval returns the interval of the current piece within its leaf.
+func (cursor *Cursor[V, T]) leafInterval() Interval[T] {
+	leaf := cursor.path[len(cursor.path)-1]
+
+	return leaf.node.getIntervalWithin(leaf.bounds, leaf.index)
+}
+
+// truncate removes the nodes from the path below the first n ones and unpins them.
+func (cursor *Cursor[V, T]) truncate(n int) {
+	for i := max(n, 1); i < len(cursor.path); i++ {
+		cursor.path[i].node.unpin()
+	}
+	cursor.path = cursor.path[:n]
+}
+
+// load pins the nodes on the path of a paged tree again at the beginning of a movement, from the
+// root downwards. Nodes evicted since the last movement are loaded again.
+func (cursor *Cursor[V, T]) load() error {
+	if cursor.tree.pool == nil {
+		return nil
+	}
+
+	for i := 1; i < len(cursor.path); i++ {
+		child, err := cursor.path[i-1].node.child(int(cursor.path[i-1].index))
+		if err != nil {
+			cursor.path = cursor.path[:i]
+			return err
+		}
+		cursor.path[i].node = child
+	}
+
+	return nil
+}
+
+// unpin unpins the nodes on the path at the end of a movement, see load.
+func (cursor *Cursor[V, T]) unpin() {
+	for i := 1; i < len(cursor.path); i++ {
+		cursor.path[i].node.unpin()
+	}
+}
@@ -0,0 +1,152 @@
+package segmenttree
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterateDosageScenario(t *testing.T) {
+	// Arrange
+	tree := setupTree()
+	expected := must(tree.GetWithinInterval(NewInterval[uint32](3, 47)))
+
+	// Act
+	forward := collectPieces(tree.Iterate(NewInterval[uint32](3, 47)))
+	backward := collectPieces(tree.IterateBackward(NewInterval[uint32](3, 47)))
+
+	// Assert
+	assert.Equal(t, expected, forward)
+	slices.Reverse(backward)
+	assert.Equal(t, expected, backward)
+}
+
+func TestIterateStopsEarly(t *testing.T) {
+	// Arrange
+	tree := setupTree()
+	result := []Interval[uint32]{}
+
+	// Act
+	for interval, value := range tree.Iterate(NewInterval[uint32](0, 60)) {
+		if value > 7 {
+			break
+		}
+		result = append(result, interval)
+	}
+
+	// Assert
+	assert.Equal(t, []Interval[uint32]{NewInterval[uint32](0, 5), NewInterval[uint32](5, 10)}, result)
+}
+
+func TestCursorSeek(t *testing.T) {
+	// Arrange
+	tree := setupTree()
+	cursor := tree.NewCursor(NewInterval[uint32](8, 42))
+
+	// Act & Assert
+	assert.True(t, cursor.Seek(17))
+	assert.Equal(t, NewInterval[uint32](15, 20), cursor.GetInterval())
+	assert.Equal(t, Float(6), cursor.GetValue())
+
+	assert.True(t, cursor.Next())
+	assert.Equal(t, NewInterval[uint32](20, 30), cursor.GetInterval())
+
+	assert.True(t, cursor.Prev())
+	assert.True(t, cursor.Prev())
+	assert.Equal(t, NewInterval[uint32](10, 15), cursor.GetInterval())
+	assert.True(t, cursor.Prev())
+	assert.Equal(t, NewInterval[uint32](8, 10), cursor.GetInterval())
+	assert.False(t, cursor.Prev())
+	assert.False(t, cursor.Valid())
+
+	assert.True(t, cursor.Seek(2))
+	assert.Equal(t, NewInterval[uint32](8, 10), cursor.GetInterval())
+
+	assert.True(t, cursor.Last())
+	assert.Equal(t, NewInterval[uint32](40, 42), cursor.GetInterval())
+	assert.False(t, cursor.Next())
+
+	assert.False(t, cursor.Seek(42))
+	assert.NoError(t, cursor.Err())
+}
+
+func TestCursorInvalidInterval(t *testing.T) {
+	// Arrange
+	tree := setupTree()
+	cursor := tree.NewCursor(NewInterval[uint32](42, 8))
+
+	// Act
+	ok := cursor.First()
+
+	// Assert
+	assert.False(t, ok)
+	assert.ErrorIs(t, cursor.Err(), ErrInvalidInterval)
+	assert.Empty(t, collectPieces(tree.Iterate(NewInterval[uint32](42, 8))))
+}
+
+func TestCursorReadsVersionItWasCreatedFor(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	tree.SetCopyOnWrite(true)
+	tree.InsertRange(dosageTestData[Float]())
+	expected := must(tree.GetWithinInterval(NewInterval[uint32](0, 60)))
+	cursor := tree.NewCursor(NewInterval[uint32](0, 60))
+	cursor.First()
+
+	// Act
+	tree.Insert(NewValueIntervalTuple(Float(100), NewInterval[uint32](3, 55)))
+	result := []ValueIntervalTuple[Float, uint32]{}
+	for ok := cursor.Valid(); ok; ok = cursor.Next() {
+		result = append(result, NewValueIntervalTuple(cursor.GetValue(), cursor.GetInterval()))
+	}
+
+	// Assert
+	assert.Equal(t, expected, result)
+}
+
+func TestIterateMatchesGetWithinInterval(t *testing.T) {
+	// Arrange
+	random := rand.New(rand.NewSource(1))
+	trees := map[string]*SegmentTreeImpl[Float, uint32]{
+		"in-memory": NewSegmentTree[Float, uint32](3, SumAggregate[Float]()),
+		"paged":     must(NewPagedSegmentTree[Float, uint32](3, SumAggregate[Float](), NewMemoryNodeStore[Float, uint32](), 2)),
+	}
+
+	for step := 0; step < 200; step++ {
+		start := uint32(random.Intn(200))
+		tuple := NewValueIntervalTuple(Float(random.Intn(20)), NewInterval(start, start+1+uint32(random.Intn(40))))
+		for _, tree := range trees {
+			tree.Insert(tuple)
+		}
+
+		if step%10 != 0 {
+			continue
+		}
+
+		start = uint32(random.Intn(250))
+		interval := NewInterval(start, start+uint32(random.Intn(100)))
+
+		for name, tree := range trees {
+			// Act
+			forward := collectPieces(tree.Iterate(interval))
+			backward := collectPieces(tree.IterateBackward(interval))
+
+			// Assert
+			expected := must(tree.GetWithinInterval(interval))
+			assert.Equal(t, expected, forward, "%s, step %d", name, step)
+			slices.Reverse(backward)
+			assert.Equal(t, expected, backward, "%s, step %d", name, step)
+		}
+	}
+}
+
+func collectPieces[V Addable[V], T Timestamp](pieces func(yield func(Interval[T], V) bool)) []ValueIntervalTuple[V, T] {
+	result := []ValueIntervalTuple[V, T]{}
+	for interval, value := range pieces {
+		result = append(result, NewValueIntervalTuple(value, interval))
+	}
+
+	return result
+}
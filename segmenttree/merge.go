@@ -0,0 +1,133 @@
+package segmenttree
+
+import (
+	"cmp"
+	"errors"
+	"math"
+)
+
+var ErrIncompatibleTrees = errors.New("trees have different aggregates")
+
+// MergeRange inserts all values into the tree, which unlike for InsertRange may already hold
+// values, e.g. to append a nightly batch to an existing index. Instead of inserting the values
+// one by one, the pieces of the tree are merged with the sorted start and end points of the
+// values and the tree is rebuilt from the result. This takes O(n + m log m) for a tree with n
+// pieces and m values.
+//
+// Inserting the values one by one takes O(m log n) instead, which is less for a batch that is
+// small compared to the tree, i.e. if m log n < n. The values are inserted one by one then, as
+// well as for an aggregate without an inverse, which can not close the intervals of the values.
+func (tree *SegmentTreeImpl[V, T]) MergeRange(values []ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(values...); err != nil {
+		return err
+	}
+
+	return tree.run(true, func() error {
+		return tree.mergeRange(values)
+	})
+}
+
+// mergeRange inserts the values within a tree operation, see MergeRange.
+func (tree *SegmentTreeImpl[V, T]) mergeRange(values []ValueIntervalTuple[V, T]) error {
+	// Inserting the values one by one is cheaper than rebuilding the tree if m log n < n.
+	estimated, err := tree.estimatePieces()
+	if err != nil {
+		return err
+	}
+
+	if !tree.aggregate.isInvertible() || (estimated > 1 && float64(len(values))*math.Log2(float64(estimated)) < float64(estimated)) {
+		for _, value := range values {
+			if err := tree.insertValue(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	pieces, err := tree.rangeQuery(tree.root, NewInterval(0, MaxInstant[T]()), NewInterval(0, MaxInstant[T]()), tree.aggregate.neutralElement)
+	if err != nil {
+		return err
+	}
+	return tree.build(combinePieces(tree.aggregate.combine, pieces, deltaPieces(tree.aggregate, values)))
+}
+
+// estimatePieces estimates the number of pieces of the tree from the size of its root and its
+// height, assuming that the other nodes are filled up to the fill factor.
+func (tree *SegmentTreeImpl[V, T]) estimatePieces() (int, error) {
+	pieces := int(tree.root.size()) + 1
+	fill := max(2, int(cmp.Or(tree.fillFactor, DefaultFillFactor)*float64(tree.branchingFactor)))
+
+	node := tree.root
+	for ; !node.isLeaf; pieces *= fill {
+		child, err := node.child(0)
+		if err != nil {
+			return 0, err
+		}
+		node.unpin()
+		node = child
+	}
+	node.unpin()
+
+	return pieces, nil
+}
+
+// Merge inserts all values of the other tree into the tree, so that its aggregated values
+// combine the values inserted into both trees. The other tree has to have an aggregate with the
+// same name (see WithName) and stays unchanged. As MergeRange, it merges the pieces of both trees
+// and rebuilds the tree, which takes O(n + m) for trees with n and m pieces.
+func (tree *SegmentTreeImpl[V, T]) Merge(other *SegmentTreeImpl[V, T]) error {
+	kind, err := tree.aggregate.kind()
+	if err != nil {
+		return err
+	}
+	if otherKind, err := other.aggregate.kind(); err != nil || otherKind != kind || other.aggregate.neutralElement != tree.aggregate.neutralElement {
+		return ErrIncompatibleTrees
+	}
+
+	// The pieces and the tuples of the other tree are read within one operation on it, so that
+	// they are consistent and its pages are loaded by its own buffer pool.
+	var otherPieces, otherTuples []ValueIntervalTuple[V, T]
+	if err := other.run(false, func() (err error) {
+		otherPieces, err = other.rangeQuery(other.root, NewInterval(0, MaxInstant[T]()), NewInterval(0, MaxInstant[T]()), other.aggregate.neutralElement)
+		otherTuples = other.tuples.all(nil)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	return tree.run(true, func() error {
+		pieces, err := tree.rangeQuery(tree.root, NewInterval(0, MaxInstant[T]()), NewInterval(0, MaxInstant[T]()), tree.aggregate.neutralElement)
+		if err != nil {
+			return err
+		}
+		if err := tree.build(combinePieces(tree.aggregate.combine, pieces, otherPieces)); err != nil {
+			return err
+		}
+		// The tuples are kept to recompute the tree on delete, see deleteAndRecompute.
+		for _, tuple := range otherTuples {
+			tree.tuples = tree.tuples.insert(tuple, tuple.interval)
+		}
+		return nil
+	})
+}
+
+// combinePieces returns the pieces whose values combine the values of both pieces with the
+// operation. Both pieces have to cover the same interval.
+func combinePieces[V Addable[V], T Timestamp](operation func(V, V) V, x []ValueIntervalTuple[V, T], y []ValueIntervalTuple[V, T]) []ValueIntervalTuple[V, T] {
+	result := make([]ValueIntervalTuple[V, T], 0, len(x)+len(y))
+
+	for i, j := 0, 0; i < len(x) && j < len(y); {
+		end := min(x[i].interval.end, y[j].interval.end)
+		interval := NewInterval(max(x[i].interval.start, y[j].interval.start), end)
+		result = appendPiece(result, NewValueIntervalTuple(operation(x[i].value, y[j].value), interval))
+
+		if x[i].interval.end == end {
+			i++
+		}
+		if y[j].interval.end == end {
+			j++
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,209 @@
+package segmenttree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeRangeIntoNonEmptyTree(t *testing.T) {
+	forEachValueType(t, testMergeRangeIntoNonEmptyTree[Float], testMergeRangeIntoNonEmptyTree[Float64], testMergeRangeIntoNonEmptyTree[Int64], testMergeRangeIntoNonEmptyTree[Decimal])
+}
+
+func testMergeRangeIntoNonEmptyTree[V scalarValue[V]](t *testing.T) {
+	// Arrange
+	values := dosageTestData[V]()
+	tree := NewSegmentTree[V, uint32](BRANCHING_FACTOR, SumAggregate[V]())
+	tree.InsertRange(values[:3])
+	expected := NewSegmentTree[V, uint32](BRANCHING_FACTOR, SumAggregate[V]())
+	expected.InsertRange(values)
+
+	// Act
+	err := tree.MergeRange(values[3:])
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, must(expected.GetWithinInterval(NewInterval[uint32](0, 60))), must(tree.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
+
+// Compares merging batches into a tree with inserting them into the naive tree, while the
+// batches overlap the intervals already in the tree.
+func TestMergeRangeMatchesNaiveTree(t *testing.T) {
+	// Arrange
+	random := rand.New(rand.NewSource(1))
+	trees := map[string]*SegmentTreeImpl[Int64, uint32]{
+		"in-memory": NewSegmentTree[Int64, uint32](4, SumAggregate[Int64]()),
+		"paged":     must(NewPagedSegmentTree[Int64, uint32](4, SumAggregate[Int64](), NewMemoryNodeStore[Int64, uint32](), 2)),
+	}
+	naive := NewNaiveSegmentTree[Int64, uint32](SumAggregate[Int64]())
+
+	for batch := 0; batch < 10; batch++ {
+		values := []ValueIntervalTuple[Int64, uint32]{}
+		for i := 0; i < 50; i++ {
+			start := uint32(random.Intn(500))
+			value := NewValueIntervalTuple(Int64(random.Intn(41)-20), NewInterval(start, start+1+uint32(random.Intn(60))))
+			values = append(values, value)
+			naive.Insert(value)
+		}
+
+		for name, tree := range trees {
+			// Act
+			err := tree.MergeRange(values)
+
+			// Assert
+			assert.NoError(t, err, name)
+			assert.Equal(t, must(naive.GetWithinInterval(NewInterval[uint32](0, 600))), must(tree.GetWithinInterval(NewInterval[uint32](0, 600))), "%s, batch %d", name, batch)
+			tree.run(false, func() error {
+				assertBounds(t, tree.root, NewInterval(0, MaxInstant[uint32]()), name)
+				return nil
+			})
+		}
+		assertExtrema(t, trees["in-memory"], trees["in-memory"].root, NewInterval(0, MaxInstant[uint32]()))
+	}
+}
+
+// Merges a small batch into a large tree. The values are inserted one by one, so that the leaves
+// away from the values are kept.
+func TestMergeRangeSmallBatch(t *testing.T) {
+	// Arrange
+	values := benchmarkValues(5000, 1)
+	tree := NewSegmentTree[Float64, uint32](8, SumAggregate[Float64]())
+	tree.InsertRange(values)
+	naive := NewNaiveSegmentTree[Float64, uint32](SumAggregate[Float64]())
+	for _, value := range values {
+		naive.Insert(value)
+	}
+	batch := []ValueIntervalTuple[Float64, uint32]{
+		NewValueIntervalTuple(Float64(1), NewInterval[uint32](10, 20)),
+		NewValueIntervalTuple(Float64(2), NewInterval[uint32](15, 30)),
+	}
+	for _, value := range batch {
+		naive.Insert(value)
+	}
+	lastLeaf := func() *Node[Float64, uint32] {
+		node := tree.root
+		for !node.isLeaf {
+			node = node.children[len(node.children)-1].node
+		}
+		return node
+	}
+	leaf := lastLeaf()
+
+	// Act
+	err := tree.MergeRange(batch)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, must(naive.GetWithinInterval(NewInterval(0, MaxInstant[uint32]()))), must(tree.GetWithinInterval(NewInterval(0, MaxInstant[uint32]()))))
+	assert.Same(t, leaf, lastLeaf())
+}
+
+func TestMergeRangeInvalidInterval(t *testing.T) {
+	// Arrange
+	tree := setupTree()
+	expected := must(tree.GetWithinInterval(NewInterval[uint32](0, 60)))
+
+	// Act
+	err := tree.MergeRange([]ValueIntervalTuple[Float, uint32]{
+		NewValueIntervalTuple(Float(1), NewInterval[uint32](5, 10)),
+		NewValueIntervalTuple(Float(1), NewInterval[uint32](10, 5)),
+	})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidInterval)
+	assert.Equal(t, expected, must(tree.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
+
+func TestMergeTrees(t *testing.T) {
+	aggregates := map[string]Aggregate[Float64]{
+		"sum": SumAggregate[Float64](),
+		"max": MaxAggregate(Float64(math.Inf(-1))),
+	}
+
+	for name, aggregate := range aggregates {
+		// Arrange
+		random := rand.New(rand.NewSource(1))
+		tree := NewSegmentTree[Float64, uint32](4, aggregate)
+		other := NewSegmentTree[Float64, uint32](4, aggregate)
+		naive := NewNaiveSegmentTree[Float64, uint32](aggregate)
+		values := []ValueIntervalTuple[Float64, uint32]{}
+
+		for i := 0; i < 100; i++ {
+			start := uint32(random.Intn(500))
+			value := NewValueIntervalTuple(Float64(random.Intn(20)), NewInterval(start, start+1+uint32(random.Intn(60))))
+			if i%2 == 0 {
+				tree.Insert(value)
+			} else {
+				other.Insert(value)
+			}
+			values = append(values, value)
+			naive.Insert(value)
+		}
+		otherPieces := must(other.GetWithinInterval(NewInterval[uint32](0, 600)))
+
+		// Act
+		err := tree.Merge(other)
+
+		// Assert
+		assert.NoError(t, err, name)
+		assert.Equal(t, must(naive.GetWithinInterval(NewInterval[uint32](0, 600))), must(tree.GetWithinInterval(NewInterval[uint32](0, 600))), name)
+		assert.Equal(t, otherPieces, must(other.GetWithinInterval(NewInterval[uint32](0, 600))), name)
+
+		// The values of the other tree can be deleted from the merged tree.
+		tree.Delete(values[1])
+		naive.Delete(values[1])
+		assert.Equal(t, must(naive.GetWithinInterval(NewInterval[uint32](0, 600))), must(tree.GetWithinInterval(NewInterval[uint32](0, 600))), name)
+	}
+}
+
+// Merges trees of different sizes, so that the merged tree has several levels, and checks that the
+// nodes are filled as after InsertRange and that the bounds and parent pointers are set.
+func TestMergeKeepsInvariants(t *testing.T) {
+	for _, sizes := range [][2]int{{0, 500}, {500, 0}, {10, 2000}, {2000, 2000}} {
+		// Arrange
+		random := rand.New(rand.NewSource(1))
+		tree := NewSegmentTree[Int64, uint32](4, SumAggregate[Int64]())
+		other := must(NewPagedSegmentTree[Int64, uint32](4, SumAggregate[Int64](), NewMemoryNodeStore[Int64, uint32](), 2))
+		naive := NewNaiveSegmentTree[Int64, uint32](SumAggregate[Int64]())
+
+		for i, target := range []*SegmentTreeImpl[Int64, uint32]{tree, other} {
+			values := []ValueIntervalTuple[Int64, uint32]{}
+			for j := 0; j < sizes[i]; j++ {
+				start := uint32(random.Intn(10000))
+				values = append(values, NewValueIntervalTuple(Int64(random.Intn(20)+1), NewInterval(start, start+1+uint32(random.Intn(100)))))
+				naive.Insert(values[j])
+			}
+			target.InsertRange(values)
+		}
+
+		// Act
+		err := tree.Merge(other)
+
+		// Assert
+		assert.NoError(t, err, sizes)
+		assert.Equal(t, must(naive.GetWithinInterval(NewInterval[uint32](0, 20000))), must(tree.GetWithinInterval(NewInterval[uint32](0, 20000))), sizes)
+		leafSizes := []int{}
+		assertFill(t, tree.root, true, &leafSizes, sizes)
+		assertBounds(t, tree.root, NewInterval(0, MaxInstant[uint32]()), sizes)
+		assertBackPointers(t, tree, tree.root, nil)
+	}
+}
+
+func TestMergeTreesWithAnotherAggregate(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	other := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, MaxAggregate(Float(math.Inf(-1))))
+	unnamed := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, NewAggregate(Sum[Float], InverseSum[Float], Identity[Float], Float(0)))
+
+	// Act
+	err := tree.Merge(other)
+	unnamedErr := tree.Merge(unnamed)
+	unnamedTreeErr := unnamed.Merge(tree)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrIncompatibleTrees)
+	assert.ErrorIs(t, unnamedErr, ErrIncompatibleTrees)
+	assert.ErrorIs(t, unnamedTreeErr, ErrUnnamedAggregate)
+}
@@ -0,0 +1,137 @@
+package segmenttree
+
+import (
+	"slices"
+)
+
+// NaiveSegmentTree is a reference implementation of SegmentTree without an index. It keeps the
+// inserted tuples and answers every query by aggregating the tuples covering each elementary
+// interval between two sorted endpoints, which takes O(n) per piece. Adjacent pieces with equal
+// values are merged, so its result only depends on the inserted tuples, not on their order.
+type NaiveSegmentTree[V Addable[V], T Timestamp] struct {
+	aggregate Aggregate[V]
+	// entries holds the inserted tuples with their values transformed by the addition element.
+	entries []ValueIntervalTuple[V, T]
+}
+
+func NewNaiveSegmentTree[V Addable[V], T Timestamp](aggregate Aggregate[V]) *NaiveSegmentTree[V, T] {
+	return &NaiveSegmentTree[V, T]{
+		aggregate: aggregate,
+	}
+}
+
+func (naive *NaiveSegmentTree[V, T]) GetAtInstant(instant T) (V, error) {
+	var value V
+
+	err := catch(func() error {
+		value = naive.aggregateAt(instant)
+		return nil
+	})
+
+	return value, err
+}
+
+func (naive *NaiveSegmentTree[V, T]) GetWithinInterval(interval Interval[T]) ([]ValueIntervalTuple[V, T], error) {
+	if err := checkIntervals(interval); err != nil {
+		return nil, err
+	}
+
+	var result []ValueIntervalTuple[V, T]
+
+	err := catch(func() error {
+		result = []ValueIntervalTuple[V, T]{}
+
+		if interval.GetLength() == 0 {
+			return nil
+		}
+
+		endpoints := []T{interval.start, interval.end}
+		for _, entry := range naive.entries {
+			for _, endpoint := range []T{entry.interval.start, entry.interval.end} {
+				if endpoint > interval.start && endpoint < interval.end {
+					endpoints = append(endpoints, endpoint)
+				}
+			}
+		}
+
+		slices.Sort(endpoints)
+		endpoints = slices.Compact(endpoints)
+
+		for i := 0; i+1 < len(endpoints); i++ {
+			result = appendPiece(result, ValueIntervalTuple[V, T]{
+				value:    naive.aggregateAt(endpoints[i]),
+				interval: NewInterval(endpoints[i], endpoints[i+1]),
+			})
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+func (naive *NaiveSegmentTree[V, T]) Insert(value ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(value); err != nil {
+		return err
+	}
+
+	return catch(func() error {
+		naive.entries = append(naive.entries, ValueIntervalTuple[V, T]{
+			value:    naive.aggregate.additionElement(value.value),
+			interval: value.interval,
+		})
+		return nil
+	})
+}
+
+// Delete removes one occurrence of the tuple. As in SegmentTreeImpl, deleting a tuple that was
+// never inserted subtracts it if the aggregate is invertible and is a no-op otherwise.
+func (naive *NaiveSegmentTree[V, T]) Delete(value ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(value); err != nil {
+		return err
+	}
+
+	return catch(func() error {
+		entry := ValueIntervalTuple[V, T]{
+			value:    naive.aggregate.additionElement(value.value),
+			interval: value.interval,
+		}
+
+		if i := slices.Index(naive.entries, entry); i >= 0 {
+			naive.entries = slices.Delete(naive.entries, i, i+1)
+		} else if naive.aggregate.isInvertible() {
+			entry.value = entry.value.Inverse()
+			naive.entries = append(naive.entries, entry)
+		}
+		return nil
+	})
+}
+
+func (naive *NaiveSegmentTree[V, T]) InsertRange(values []ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(values...); err != nil {
+		return err
+	}
+	if len(naive.entries) > 0 {
+		return ErrTreeNotEmpty
+	}
+
+	for _, value := range values {
+		if err := naive.Insert(value); err != nil {
+			naive.entries = nil
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (naive *NaiveSegmentTree[V, T]) aggregateAt(instant T) V {
+	value := naive.aggregate.neutralElement
+
+	for _, entry := range naive.entries {
+		if entry.interval.start <= instant && instant < entry.interval.end {
+			value = naive.aggregate.operation(value, entry.value)
+		}
+	}
+
+	return value
+}
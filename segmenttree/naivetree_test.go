@@ -0,0 +1,121 @@
+package segmenttree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNaiveTreeDosageScenario(t *testing.T) {
+	forEachValueType(t, testNaiveTreeDosageScenario[Float], testNaiveTreeDosageScenario[Float64], testNaiveTreeDosageScenario[Int64], testNaiveTreeDosageScenario[Decimal])
+}
+
+func testNaiveTreeDosageScenario[V scalarValue[V]](t *testing.T) {
+	// Arrange
+	tree := NewNaiveSegmentTree[V, uint32](SumAggregate[V]())
+
+	// Act
+	for _, tuple := range dosageTestData[V]() {
+		tree.Insert(tuple)
+	}
+
+	// Assert
+	for _, td := range testDataGetAtInstant {
+		assert.Equal(t, scalar[V](td.expectedValue), must(tree.GetAtInstant(td.instant)), "instant %d", td.instant)
+	}
+	assert.Equal(t, []ValueIntervalTuple[V, uint32]{
+		{interval: NewInterval[uint32](0, 5), value: scalar[V](0)},
+		{interval: NewInterval[uint32](5, 10), value: scalar[V](2)},
+		{interval: NewInterval[uint32](10, 15), value: scalar[V](8)},
+		{interval: NewInterval[uint32](15, 20), value: scalar[V](6)},
+		{interval: NewInterval[uint32](20, 30), value: scalar[V](7)},
+		{interval: NewInterval[uint32](30, 35), value: scalar[V](4)},
+		{interval: NewInterval[uint32](35, 40), value: scalar[V](8)},
+		{interval: NewInterval[uint32](40, 45), value: scalar[V](5)},
+		{interval: NewInterval[uint32](45, 50), value: scalar[V](1)},
+		{interval: NewInterval[uint32](50, 60), value: scalar[V](0)},
+	}, must(tree.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
+
+func TestNaiveTreeMergesEqualPieces(t *testing.T) {
+	// Arrange
+	tree := NewNaiveSegmentTree[Float, uint32](SumAggregate[Float]())
+	tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](10, 20), value: Float(1)})
+	tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](20, 30), value: Float(1)})
+
+	// Act
+	tree.Delete(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](0, 50), value: Float(0)})
+	result := must(tree.GetWithinInterval(NewInterval[uint32](5, 40)))
+
+	// Assert
+	assert.Equal(t, []ValueIntervalTuple[Float, uint32]{
+		{interval: NewInterval[uint32](5, 10), value: Float(0)},
+		{interval: NewInterval[uint32](10, 30), value: Float(1)},
+		{interval: NewInterval[uint32](30, 40), value: Float(0)},
+	}, result)
+}
+
+// Runs the same random operations on all implementations of SegmentTree and compares them
+// against the naive tree. Adjacent pieces with equal values are merged before comparing, as the
+// implementations split the timeline differently.
+func TestImplementationsMatchNaiveTree(t *testing.T) {
+	forEachValueType(t, testImplementationsMatchNaiveTree[Float], testImplementationsMatchNaiveTree[Float64], testImplementationsMatchNaiveTree[Int64], testImplementationsMatchNaiveTree[Decimal])
+}
+
+func testImplementationsMatchNaiveTree[V scalarValue[V]](t *testing.T) {
+	aggregates := map[string]Aggregate[V]{
+		"sum":   SumAggregate[V](),
+		"count": NewAggregate(Count[V], InverseCount[V], func(V) V { return scalar[V](1) }, scalar[V](0), WithName("count"), Additive()),
+		"max":   MaxAggregate(scalar[V](math.Inf(-1))),
+		"min":   MinAggregate(scalar[V](math.Inf(1))),
+	}
+
+	for name, aggregate := range aggregates {
+		// Arrange
+		random := rand.New(rand.NewSource(1))
+		reference := NewNaiveSegmentTree[V, uint32](aggregate)
+		trees := map[string]SegmentTree[V, uint32]{
+			"sb-tree":    NewSegmentTree[V, uint32](BRANCHING_FACTOR, aggregate),
+			"static":     NewStaticSegmentTree[V, uint32](aggregate),
+			"concurrent": NewConcurrentSegmentTree[V, uint32](BRANCHING_FACTOR, aggregate),
+		}
+		inserted := []ValueIntervalTuple[V, uint32]{}
+
+		for step := 0; step < 200; step++ {
+			// Act
+			operation := SegmentTree[V, uint32].Insert
+			tuple := ValueIntervalTuple[V, uint32]{}
+
+			if len(inserted) > 0 && random.Intn(3) == 0 {
+				i := random.Intn(len(inserted))
+				operation, tuple = SegmentTree[V, uint32].Delete, inserted[i]
+				inserted = append(inserted[:i], inserted[i+1:]...)
+			} else {
+				start := uint32(random.Intn(100))
+				tuple = ValueIntervalTuple[V, uint32]{
+					value:    scalar[V](random.Intn(5) + 1),
+					interval: NewInterval(start, start+1+uint32(random.Intn(10))),
+				}
+				inserted = append(inserted, tuple)
+			}
+
+			assert.NoError(t, operation(reference, tuple))
+			for _, tree := range trees {
+				assert.NoError(t, operation(tree, tuple))
+			}
+
+			// Assert
+			instant := uint32(random.Intn(115))
+			interval := NewInterval(instant, instant+uint32(random.Intn(30)))
+
+			for treeName, tree := range trees {
+				if !assert.Equal(t, must(reference.GetAtInstant(instant)), must(tree.GetAtInstant(instant)), "%s, %s, step %d, instant %d", name, treeName, step, instant) ||
+					!assert.Equal(t, must(reference.GetWithinInterval(interval)), must(tree.GetWithinInterval(interval)), "%s, %s, step %d, interval %v", name, treeName, step, interval) {
+					return
+				}
+			}
+		}
+	}
+}
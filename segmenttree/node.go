@@ -2,7 +2,7 @@ package segmenttree
 
 import "math"
 
-type Node struct {
+type Node[V Addable[V], T Timestamp] struct {
 	/*
 			Size of a node is
 			keys: 4+4+4+ byte (reference, length, cap)  + b-1 * 4 byte
@@ -21,15 +21,39 @@ type Node struct {
 		For simplicity set b = l, then we get a maximal branching factor b and a maximal leaf capacity l
 		of b=l=337 to fit into one disk page of 4 kb.
 	*/
-	keys     []uint32
-	values   []Addable
-	children []*Node
-	parent   *Node
-	tree     *SegmentTreeImpl
+	keys     []T
+	values   []V
+	children []link[V, T]
+	parent   *Node[V, T]
+	tree     *SegmentTreeImpl[V, T]
 	isLeaf   bool
+	// Only used if the tree is backed by a NodeStore, see BufferPool
+	id PageID
+	// The version of the tree the node was created in. Only used if the tree copies on write.
+	version uint64
+	// The integral over the node's interval of the values of the node and its descendants.
+	// Only kept up to date if the tree's aggregate is additive, see SegmentTreeImpl.Integrate.
+	integral float64
+	// The smallest and the largest value (as float) of the node and its descendants within the
+	// node's interval, excluding the values of its ancestors. Only kept up to date if the tree's
+	// aggregate is monotone, see SegmentTreeImpl.FindIntervals.
+	minimum V
+	maximum V
+	// Set if the summaries above have to be recomputed, see markSummaryStale.
+	summaryStale bool
+	// The interval covered by the node. It only changes if the keys of the parent
+	// around the node change, so that it stays valid for nodes shared by several versions.
+	bounds Interval[T]
 }
 
-func (node *Node) findIntervalIndex(instant uint32) uint32 {
+// link references a child of an interior node. In a paged tree, node is nil while the child is not
+// in memory, which is then loaded from the page id, see BufferPool.
+type link[V Addable[V], T Timestamp] struct {
+	node *Node[V, T]
+	id   PageID
+}
+
+func (node *Node[V, T]) findIntervalIndex(instant T) uint32 {
 	var intervalIndex uint32 = 0
 
 	for intervalIndex < node.size() {
@@ -43,9 +67,9 @@ func (node *Node) findIntervalIndex(instant uint32) uint32 {
 	return intervalIndex
 }
 
-func (node *Node) findChildIndex(childToFind *Node) uint32 {
+func (node *Node[V, T]) findChildIndex(childToFind *Node[V, T]) uint32 {
 	for index, child := range node.children {
-		if child == childToFind {
+		if child.node == childToFind {
 			return uint32(index)
 		}
 	}
@@ -53,49 +77,60 @@ func (node *Node) findChildIndex(childToFind *Node) uint32 {
 	panic("Child is not a child of parent")
 }
 
-func (node *Node) getIntervalStart(index uint32) uint32 {
+func (node *Node[V, T]) getIntervalStart(index uint32) T {
 	if index == 0 {
-		if node.parent == nil {
-			return 0 // min of Uint32
-		} else {
-			childIndex := node.parent.findChildIndex(node)
-			return node.parent.getIntervalStart(childIndex)
-		}
+		return node.bounds.start
 	} else {
 		return node.keys[index-1]
 	}
 }
 
-func (node *Node) getIntervalEnd(index uint32) uint32 {
+func (node *Node[V, T]) getIntervalEnd(index uint32) T {
 	if index >= node.size() {
-		if node.parent == nil {
-			return math.MaxUint32
-		} else {
-			childIndex := node.parent.findChildIndex(node)
-			return node.parent.getIntervalEnd(childIndex)
-		}
+		return node.bounds.end
 	} else {
 		return node.keys[index]
 	}
 }
 
-func (node *Node) getIntervals() []Interval {
-	var intervals []Interval = make([]Interval, node.size()+1)
+// getIntervalsWithin returns the same intervals as getIntervals, given the interval
+// covered by the whole node. It does not follow the parent pointers.
+func (node *Node[V, T]) getIntervalsWithin(bounds Interval[T]) []Interval[T] {
+	intervals := make([]Interval[T], node.size()+1)
 
-	var i uint32 = 0
-	for ; i <= node.size(); i++ {
-		start := node.getIntervalStart(i)
-		end := node.getIntervalEnd(i)
-
-		intervals[i] = NewInterval(start, end)
+	start := bounds.start
+	for i, key := range node.keys {
+		intervals[i] = NewInterval(start, key)
+		start = key
 	}
+	intervals[node.size()] = NewInterval(start, bounds.end)
 
 	return intervals
 }
 
-func (node *Node) insert(intervalIndex int, tupleToInsert ValueIntervalTuple) int {
+// getIntervalWithin returns the interval with the index, given the interval covered by the whole
+// node, see getIntervalsWithin.
+func (node *Node[V, T]) getIntervalWithin(bounds Interval[T], index uint32) Interval[T] {
+	start, end := bounds.start, bounds.end
+	if index > 0 {
+		start = node.keys[index-1]
+	}
+	if index < node.size() {
+		end = node.keys[index]
+	}
+
+	return NewInterval(start, end)
+}
+
+func (node *Node[V, T]) getIntervals() []Interval[T] {
+	return node.getIntervalsWithin(node.bounds)
+}
+
+func (node *Node[V, T]) insert(intervalIndex int, tupleToInsert ValueIntervalTuple[V, T]) int {
+	node.modified()
 	nodeIntervalStart := node.getIntervalStart(uint32(intervalIndex))
 	nodeIntervalEnd := node.getIntervalEnd(uint32(intervalIndex))
+	var zero V // placeholder, overwritten when shifting the values
 
 	if nodeIntervalStart < tupleToInsert.interval.start && nodeIntervalEnd > tupleToInsert.interval.end {
 		// Case 1
@@ -106,7 +141,7 @@ func (node *Node) insert(intervalIndex int, tupleToInsert ValueIntervalTuple) in
 
 		// Make space for two additional intervals
 		node.keys = append(node.keys, 0, 0)
-		node.values = append(node.values, Float(0), Float(0))
+		node.values = append(node.values, zero, zero)
 
 		// Shift keys and insert
 		for i := len(node.keys) - 1; i > intervalIndex+1; i-- {
@@ -121,7 +156,7 @@ func (node *Node) insert(intervalIndex int, tupleToInsert ValueIntervalTuple) in
 		}
 
 		node.values[intervalIndex+2] = node.values[intervalIndex] // Original value
-		node.values[intervalIndex+1] = node.tree.aggregate.operation(node.values[intervalIndex], tupleToInsert.value)
+		node.values[intervalIndex+1] = node.tree.aggregate.combine(node.values[intervalIndex], tupleToInsert.value)
 
 		return 2 // one interval got split into three (= 2 new)
 	} else if nodeIntervalStart < tupleToInsert.interval.start && nodeIntervalEnd <= tupleToInsert.interval.end {
@@ -131,7 +166,7 @@ func (node *Node) insert(intervalIndex int, tupleToInsert ValueIntervalTuple) in
 
 		// Add space for a new interval
 		node.keys = append(node.keys, 0)
-		node.values = append(node.values, Float(0))
+		node.values = append(node.values, zero)
 
 		// Shift keys and insert
 		for i := len(node.keys) - 1; i > intervalIndex; i-- {
@@ -145,7 +180,7 @@ func (node *Node) insert(intervalIndex int, tupleToInsert ValueIntervalTuple) in
 			node.values[i] = node.values[i-1]
 		}
 
-		node.values[intervalIndex+1] = node.tree.aggregate.operation(node.values[intervalIndex+1], tupleToInsert.value)
+		node.values[intervalIndex+1] = node.tree.aggregate.combine(node.values[intervalIndex+1], tupleToInsert.value)
 
 		return 1 // one interval got split into two (= 1 new)
 	} else if nodeIntervalStart >= tupleToInsert.interval.start && nodeIntervalEnd > tupleToInsert.interval.end {
@@ -155,7 +190,7 @@ func (node *Node) insert(intervalIndex int, tupleToInsert ValueIntervalTuple) in
 
 		// Add space for a new interval
 		node.keys = append(node.keys, 0)
-		node.values = append(node.values, Float(0))
+		node.values = append(node.values, zero)
 
 		// Shift keys and insert
 		for i := len(node.keys) - 1; i > intervalIndex; i-- {
@@ -169,7 +204,7 @@ func (node *Node) insert(intervalIndex int, tupleToInsert ValueIntervalTuple) in
 			node.values[i] = node.values[i-1]
 		}
 
-		node.values[intervalIndex] = node.tree.aggregate.operation(node.values[intervalIndex], tupleToInsert.value)
+		node.values[intervalIndex] = node.tree.aggregate.combine(node.values[intervalIndex], tupleToInsert.value)
 
 		return 1 // one interval got split into two (= 1 new)
 	} else {
@@ -179,7 +214,7 @@ func (node *Node) insert(intervalIndex int, tupleToInsert ValueIntervalTuple) in
 	}
 }
 
-func (node *Node) split() {
+func (node *Node[V, T]) split() {
 	if node.size() < 1 {
 		// Let's add an invariant to get rid of ugly edge cases, which are irrelevant in practice!
 		panic("A Node of size < 2 can not be split.")
@@ -188,54 +223,68 @@ func (node *Node) split() {
 		// Let's add an invariant to get rid of ugly edge cases, which are irrelevant in practice!
 		panic("A Node to split has to have a size + 1  of l + 1 or l+2 in case it is a leaf and b+1 in case it is not a leaf, where l = b = branchingFactor.")
 	}
-	var parent *Node
+	var parent *Node[V, T]
 	n := node.size() + 1
 	half_n := int32(math.Ceil(float64(n) / float64(2)))
+	bounds := node.bounds
 
 	// N1 contains 1 ... n/2-1 instances and corresponding pointers if not a leaf child
-	n1 := &Node{
-		keys:     make([]uint32, half_n-1),
-		values:   make([]Addable, half_n),
+	n1 := &Node[V, T]{
+		keys:     make([]T, half_n-1),
+		values:   make([]V, half_n),
 		children: nil,
 		parent:   node.parent,
 		tree:     node.tree,
 		isLeaf:   node.isLeaf,
+		version:  node.tree.version,
+		bounds:   NewInterval(bounds.start, node.keys[half_n-1]),
 	}
 	copy(n1.keys, node.keys[:half_n-1])
 	copy(n1.values, node.values[:half_n])
 
 	// N2 contains n/2 ... n-1 instances and corresponding pointers if not a leaf child
-	n2 := &Node{
-		keys:     make([]uint32, len(node.keys[half_n:])),
-		values:   make([]Addable, len(node.values[half_n:])),
+	n2 := &Node[V, T]{
+		keys:     make([]T, len(node.keys[half_n:])),
+		values:   make([]V, len(node.values[half_n:])),
 		children: nil,
 		parent:   node.parent,
 		tree:     node.tree,
 		isLeaf:   node.isLeaf,
+		version:  node.tree.version,
+		bounds:   NewInterval(node.keys[half_n-1], bounds.end),
 	}
 	copy(n2.keys, node.keys[half_n:])
 	copy(n2.values, node.values[half_n:])
 
 	if !node.isLeaf {
-		n1.children = node.children[:half_n]
-		n2.children = node.children[half_n:]
+		// Copy the children, so that appending to n1 can not overwrite the children of n2.
+		n1.children = make([]link[V, T], half_n)
+		n2.children = make([]link[V, T], len(node.children[half_n:]))
+		copy(n1.children, node.children[:half_n])
+		copy(n2.children, node.children[half_n:])
+
+		n1.adoptChildren()
+		n2.adoptChildren()
 	}
 
 	// Case 1: Node is root. Create new root with empty values and hook n1, n2.
 	if node.tree.root == node {
-		parent = &Node{
-			keys:     make([]uint32, 1),
-			values:   make([]Addable, 2),
-			children: []*Node{n1, n2},
+		parent = &Node[V, T]{
+			keys:     make([]T, 1),
+			values:   make([]V, 2),
+			children: []link[V, T]{{node: n1}, {node: n2}},
 			parent:   nil,
 			tree:     node.tree,
 			isLeaf:   false,
+			version:  node.tree.version,
+			bounds:   NewInterval(0, MaxInstant[T]()),
 		}
-		copy(parent.keys, []uint32{node.keys[half_n-1]})
-		copy(parent.values, []Addable{node.tree.aggregate.neutralElement, node.tree.aggregate.neutralElement})
+		copy(parent.keys, []T{node.keys[half_n-1]})
+		copy(parent.values, []V{node.tree.aggregate.neutralElement, node.tree.aggregate.neutralElement})
 		n1.parent = parent
 		n2.parent = parent
 		parent.tree.root = parent
+		node.tree.discard(node)
 	} else if node.parent != nil {
 		// Case 2: Node has parent. Let's insert n1, n2 and shift the keys, values and children to the right.
 		parent = node.parent
@@ -252,46 +301,32 @@ func (node *Node) split() {
 					parent.children[len(parent.children)-i-2] = parent.children[len(parent.children)-i-3]
 				}
 				// swap j to n1
-				parent.children[j] = n1
+				parent.children[j] = link[V, T]{node: n1}
 				parent.keys[j] = node.keys[half_n-1]
 				// the value at pos j stays the same
 
 				// set n2, value of j+1 is already the value of j
-				parent.children[j+1] = n2
+				parent.children[j+1] = link[V, T]{node: n2}
 				break
 			}
 		}
+		node.tree.discard(node)
 	} else {
 		return // this case might happen if the parent was split and replaced but in the execution stack it is split again.
 	}
+	n1.modified()
+	n2.modified()
+	parent.modified()
 	if parent.size()+1 > parent.tree.branchingFactor {
 		parent.split()
 	}
 }
 
-func (node *Node) insertTuple(key uint32, value Addable) {
-	// This function should only be used when bulk inserting
-	// into a tree.
-	// Assumes that sorted continuous key/value pairs are inserted
-	// at the rightmost part of the tree.
-	if node.isLeaf {
-		node.keys = append(node.keys, key)
-		node.values = append(node.values, value)
-
-		if node.size()+1 > node.tree.branchingFactor {
-			node.split()
-		}
-	} else {
-		lastChild := node.children[len(node.children)-1]
-		lastChild.insertTuple(key, value)
-	}
-}
-
-func (node *Node) size() uint32 {
+func (node *Node[V, T]) size() uint32 {
 	return uint32(len(node.keys))
 }
 
-func (node *Node) imerge() {
+func (node *Node[V, T]) imerge() {
 	/*
 		Merge two adjacent leaf intervals with equal aggregate values within one node.
 
@@ -304,25 +339,27 @@ func (node *Node) imerge() {
 		return
 	}
 	for j, value := range node.values {
-		if int(node.size()) > j && value == node.values[j+1] {
+		if int(node.size()) > j && equalValues(value, node.values[j+1]) {
 			node.keys = append(node.keys[:j], node.keys[j+1:]...)
 			node.values = append(node.values[:j], node.values[j+1:]...)
-			if len(node.children) > 0 {
+			if len(node.children) > j {
 				node.children = append(node.children[:j], node.children[j+1:]...)
 			}
+			node.modified()
 			break
-		} else if int(node.size()+1) == j && value == node.values[j+1] {
+		} else if int(node.size()+1) == j && equalValues(value, node.values[j+1]) {
 			node.keys = node.keys[:j]
 			node.values = node.values[:j]
+			node.modified()
 			break
 		}
 	}
 }
 
-func (node *Node) nmerge() {
+func (node *Node[V, T]) nmerge() error {
 	if node.size()+1 >= node.tree.branchingFactor/2 {
 		// only nmerge if node is less than half full
-		return
+		return nil
 	}
 	n := node.tree.branchingFactor
 	halfN := int(math.Ceil(float64(n) / float64(2)))
@@ -331,34 +368,52 @@ func (node *Node) nmerge() {
 		panic("The node must hold exactly half_n_ceiled -1 elements. Thus one below the required minimum.")
 	}
 
+	// The siblings loaded below must not evict the node
+	node.pin()
+	defer node.unpin()
+
 	if node.tree.root == node { // Case 1: node is root
 		//node has only one child
-		if len(node.children) == 1 && node.children[0] != nil {
-			node.tree.root = node.children[0]
-			node.children[0].parent = nil
-			for _, value := range node.tree.root.values {
-				value = value.Add(node.values[0])
+		if len(node.children) == 1 && node.children[0] != (link[V, T]{}) {
+			child, err := node.writableChild(0)
+			if err != nil {
+				return err
+			}
+			defer child.unpin()
+			node.tree.discard(node)
+			node.tree.root = child
+			child.parent = nil
+			for i, value := range node.tree.root.values {
+				node.tree.root.values[i] = node.tree.aggregate.combine(node.values[0], value)
 			}
+			child.modified()
 		}
 		//do nothing
-		return
+		return nil
 
 	} else { // Case 2: node is not root
 		// find the lef and right sibling
-		var right_sibling *Node
-		var left_sibling *Node
+		var right_sibling *Node[V, T]
+		var left_sibling *Node[V, T]
 		var k int
 		parent := node.parent
 		if parent == nil {
-			return
+			return nil
 		}
 		for i, _ := range parent.children {
-			if parent.children[i] == node {
+			if parent.children[i].node == node {
+				var err error
 				if i > 0 {
-					left_sibling = parent.children[i-1]
+					if left_sibling, err = parent.writableChild(i - 1); err != nil {
+						return err
+					}
+					defer left_sibling.unpin()
 				}
 				if i < int(parent.size()) {
-					right_sibling = parent.children[i+1]
+					if right_sibling, err = parent.writableChild(i + 1); err != nil {
+						return err
+					}
+					defer right_sibling.unpin()
 				}
 				k = i
 				break
@@ -366,51 +421,65 @@ func (node *Node) nmerge() {
 		}
 		// Case2.1: If N' the right sibling of node has at least more than half_n +1 intervals, steal the first one  of N'!
 		if right_sibling != nil && int(right_sibling.size()) >= halfN {
+			bounds, siblingBounds := node.bounds, right_sibling.bounds
 			for i, value := range node.values {
-				node.values[i] = parent.values[k].Add(value)
+				node.values[i] = node.tree.aggregate.combine(parent.values[k], value)
 			}
 			parent.values[k] = node.tree.aggregate.neutralElement
 			node.keys = append(node.keys, parent.keys[k])
-			node.values = append(node.values, parent.values[k+1].Add(right_sibling.values[0]))
+			node.values = append(node.values, node.tree.aggregate.combine(parent.values[k+1], right_sibling.values[0]))
 			if !node.isLeaf {
 				node.children = append(node.children, right_sibling.children[0])
+				node.adoptChildren()
 			}
 			parent.keys[k] = right_sibling.keys[0]
+			node.bounds = NewInterval(bounds.start, parent.keys[k])
+			right_sibling.bounds = NewInterval(parent.keys[k], siblingBounds.end)
 			// cleanup sibling
 			right_sibling.keys = right_sibling.keys[1:]
 			right_sibling.values = right_sibling.values[1:]
 			if len(right_sibling.children) > 0 {
 				right_sibling.children = right_sibling.children[1:]
 			}
+			node.modified()
+			right_sibling.modified()
+			parent.modified()
 
-			return
+			return nil
 		}
 
 		// Case2.2: If N' the left sibling of N has more than half_n intervals Steal the last one of N'!
 		if left_sibling != nil && int(left_sibling.size()) >= halfN {
+			bounds, siblingBounds := node.bounds, left_sibling.bounds
 			// in the paper N' the left sibling has now index k and N has index k+1 in the parent. Let's ignore this to keep things a bit more readable!
 			for i, value := range node.values {
-				node.values[i] = parent.values[k].Add(value)
+				node.values[i] = node.tree.aggregate.combine(parent.values[k], value)
 			}
 			parent.values[k] = node.tree.aggregate.neutralElement
 
-			node.keys = append([]uint32{parent.keys[k-1]}, node.keys...)
-			node.values = append([]Addable{parent.values[k-1].Add(left_sibling.values[len(left_sibling.values)-1])}, node.values...)
+			node.keys = append([]T{parent.keys[k-1]}, node.keys...)
+			node.values = append([]V{node.tree.aggregate.combine(parent.values[k-1], left_sibling.values[len(left_sibling.values)-1])}, node.values...)
 			if !node.isLeaf {
-				node.children = append([]*Node{left_sibling.children[len(left_sibling.children)-1]}, node.children...)
+				node.children = append([]link[V, T]{left_sibling.children[len(left_sibling.children)-1]}, node.children...)
+				node.adoptChildren()
 			}
 			parent.keys[k-1] = left_sibling.keys[int(left_sibling.size())-1]
+			node.bounds = NewInterval(parent.keys[k-1], bounds.end)
+			left_sibling.bounds = NewInterval(siblingBounds.start, parent.keys[k-1])
 			// cleanup sibling
 			left_sibling.keys = left_sibling.keys[:len(left_sibling.keys)-1]
 			left_sibling.values = left_sibling.values[:len(left_sibling.values)-1]
 			if len(left_sibling.children) > 0 {
 				left_sibling.children = left_sibling.children[:len(left_sibling.children)-1]
 			}
-			return
+			node.modified()
+			left_sibling.modified()
+			parent.modified()
+			return nil
 		}
 		// Case2.3: Otherwise merge N with a sibling into a new node and place it in the parent of node.
-		var n1 *Node
-		var n2 *Node
+		var n1 *Node[V, T]
+		var n2 *Node[V, T]
 		// in practice there might be the case that left right sibling is nil. in this case we should take the left.
 		if left_sibling != nil && left_sibling.size()+1 == node.tree.branchingFactor {
 			n1 = left_sibling
@@ -427,27 +496,36 @@ func (node *Node) nmerge() {
 			panic("no sibling has enough keys!")
 		}
 
-		newN := &Node{
-			keys:     make([]uint32, n1.size()+n2.size()),
-			values:   []Addable{},
-			children: make([]*Node, n1.size()+n2.size()+2),
+		newN := &Node[V, T]{
+			keys:     make([]T, n1.size()+n2.size()+1),
+			values:   []V{},
+			children: make([]link[V, T], n1.size()+n2.size()+2),
 			parent:   parent,
 			tree:     node.tree,
 			isLeaf:   node.isLeaf,
+			version:  node.tree.version,
+			bounds:   NewInterval(n1.bounds.start, n2.bounds.end),
+		}
+		// The key separating n1 and n2 in the parent becomes the key between them in the merged node.
+		copy(newN.keys, n1.keys)
+		copy(newN.keys[n1.size():], append([]T{parent.keys[k]}, n2.keys...))
+		copy(newN.children, n1.children)
+		copy(newN.children[len(n1.children):], n2.children)
+
+		if !newN.isLeaf {
+			newN.adoptChildren()
 		}
-		copy(newN.keys, append(n1.keys, n2.keys...))
-		copy(newN.children, append(n1.children, n2.children...))
-
-		newN.keys = append([]uint32{parent.keys[k]}, newN.keys...)
 
 		for _, v := range n1.values {
-			newN.values = append(newN.values, v.Add(parent.values[k]))
+			newN.values = append(newN.values, node.tree.aggregate.combine(v, parent.values[k]))
 		}
 		for _, v := range n2.values {
-			newN.values = append(newN.values, v.Add(parent.values[k+1]))
+			newN.values = append(newN.values, node.tree.aggregate.combine(v, parent.values[k+1]))
 		}
-		// delete n1, n2 - this is not needed as we have a garbage collector
-		parent.children[k] = newN
+		// delete n1, n2 - this is not needed as we have a garbage collector, but their pages have to be freed
+		node.tree.discard(n1)
+		node.tree.discard(n2)
+		parent.children[k] = link[V, T]{node: newN}
 		parent.values[k] = node.tree.aggregate.neutralElement
 
 		if int(parent.size()) > k {
@@ -463,9 +541,110 @@ func (node *Node) nmerge() {
 				parent.children = parent.children[:k+1]
 			}
 		}
+		newN.modified()
+		parent.modified()
 		// recurse: if the parent has now less then half_n nodes nmerge(parent)!
 		if int(parent.size())+1 <= halfN {
-			parent.nmerge()
+			return parent.nmerge()
 		}
 	}
+
+	return nil
+}
+
+// child returns the child at index. In a paged tree, the child is loaded if it is not in memory
+// and pinned, so that it is not evicted before it is unpinned again, see unpin.
+func (node *Node[V, T]) child(index int) (*Node[V, T], error) {
+	if node.tree.pool == nil {
+		return node.children[index].node, nil
+	}
+
+	return node.tree.pool.fetch(node, index)
+}
+
+// writableChild returns the child at index, which may then be modified by the current operation.
+// If the tree copies on write, a child shared with older versions of the tree is replaced by a copy.
+// The children of the copy are still shared, so their parent pointers are only fixed once they are
+// made writable themselves. Queries therefore must not rely on parent pointers. As child, it pins
+// the child in a paged tree.
+func (node *Node[V, T]) writableChild(index int) (*Node[V, T], error) {
+	child, err := node.child(index)
+	if err != nil {
+		return nil, err
+	}
+
+	if child.version != node.tree.version {
+		child = child.clone()
+		child.parent = node
+		node.children[index] = link[V, T]{node: child}
+	}
+
+	return child, nil
+}
+
+// clone returns a copy of the node for the current version of the tree.
+func (node *Node[V, T]) clone() *Node[V, T] {
+	clone := node.tree.newNode()
+	clone.keys = append(clone.keys, node.keys...)
+	clone.values = append(clone.values, node.values...)
+	clone.children = append(clone.children, node.children...)
+	clone.parent = node.parent
+	clone.isLeaf = node.isLeaf
+	clone.bounds = node.bounds
+
+	return clone
+}
+
+// adoptChildren sets the parent of the children in memory to the node, e.g. after they were
+// moved to it from another node.
+func (node *Node[V, T]) adoptChildren() {
+	for _, child := range node.children {
+		if child.node != nil {
+			child.node.parent = node
+		}
+	}
+}
+
+// modified has to be called whenever the keys, values or children of a node change, including
+// new nodes. The buffer pool of a paged tree then writes the node back and the summaries of the
+// node and its ancestors are recomputed at the end of the operation, see markSummaryStale.
+func (node *Node[V, T]) modified() {
+	if node.tree.pool != nil {
+		node.tree.pool.markDirty(node)
+	}
+	node.markSummaryStale()
+}
+
+// pin keeps the node of a paged tree in memory until it is unpinned, see BufferPool.
+func (node *Node[V, T]) pin() {
+	if node.tree.pool != nil {
+		node.tree.pool.pin(node)
+	}
+}
+
+// unpin releases a node pinned by pin, child or writableChild.
+func (node *Node[V, T]) unpin() {
+	if node.tree.pool != nil {
+		node.tree.pool.unpin(node)
+	}
+}
+
+func (node *Node[V, T]) evict() {
+	node.keys = nil
+	node.values = nil
+	node.children = nil
+}
+
+func (node *Node[V, T]) hasLoadedChildren() bool {
+	if node.isLeaf {
+		return false
+	}
+
+	for _, child := range node.children {
+		if child.node != nil {
+			return true
+		}
+	}
+
+	return false
 }
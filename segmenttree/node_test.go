@@ -1,7 +1,9 @@
 package segmenttree
 
 import (
+	"bytes"
 	"math"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,7 +22,7 @@ func TestFindIntervalIndex(t *testing.T) {
 		{40, 3},
 	}
 
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys: []uint32{10, 20, 30},
 	}
 
@@ -35,20 +37,20 @@ func TestFindIntervalIndex(t *testing.T) {
 
 func TestFindChildIndex(t *testing.T) {
 	// Arrange
-	parent := &Node{
-		children: make([]*Node, 2),
+	parent := &Node[Float, uint32]{
+		children: make([]link[Float, uint32], 2),
 	}
 
-	child1 := &Node{
+	child1 := &Node[Float, uint32]{
 		parent: parent,
 	}
 
-	child2 := &Node{
+	child2 := &Node[Float, uint32]{
 		parent: parent,
 	}
 
-	parent.children[0] = child1
-	parent.children[1] = child2
+	parent.children[0].node = child1
+	parent.children[1].node = child2
 
 	// Act
 	index1 := parent.findChildIndex(child1)
@@ -68,24 +70,24 @@ func TestFindChildIndexWhenNotChild(t *testing.T) {
 	}()
 
 	// Arrange
-	parent := &Node{
-		children: make([]*Node, 2),
+	parent := &Node[Float, uint32]{
+		children: make([]link[Float, uint32], 2),
 	}
 
-	child1 := &Node{
+	child1 := &Node[Float, uint32]{
 		parent: parent,
 	}
 
-	child2 := &Node{
+	child2 := &Node[Float, uint32]{
 		parent: parent,
 	}
 
-	child3 := &Node{
+	child3 := &Node[Float, uint32]{
 		parent: parent,
 	}
 
-	parent.children[0] = child1
-	parent.children[1] = child2
+	parent.children[0].node = child1
+	parent.children[1].node = child2
 
 	// Act
 	_ = parent.findChildIndex(child3)
@@ -103,11 +105,13 @@ func TestGetIntervalEndWhenRoot(t *testing.T) {
 		{3, math.MaxUint32},
 	}
 
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys: []uint32{1, 15, 30},
 	}
 
 	for _, td := range testData {
+		setBounds(node)
+
 		// Act
 		intervalEnd := node.getIntervalEnd(td.index)
 
@@ -121,7 +125,7 @@ func TestGetIntervalEndWhenNotRoot(t *testing.T) {
 	_, n1, n2, n3, n4 := SetupNodes()
 
 	testData := []struct {
-		node        *Node
+		node        *Node[Float, uint32]
 		index       uint32
 		expectedEnd uint32
 	}{
@@ -152,11 +156,13 @@ func TestGetIntervalStartWhenRoot(t *testing.T) {
 		{3, 30},
 	}
 
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys: []uint32{1, 15, 30},
 	}
 
 	for _, td := range testData {
+		setBounds(node)
+
 		// Act
 		intervalStart := node.getIntervalStart(td.index)
 
@@ -170,7 +176,7 @@ func TestGetIntervalStartWhenNotRoot(t *testing.T) {
 	_, n1, n2, n3, n4 := SetupNodes()
 
 	testData := []struct {
-		node        *Node
+		node        *Node[Float, uint32]
 		index       uint32
 		expectedEnd uint32
 	}{
@@ -194,14 +200,14 @@ func TestGetIntervals(t *testing.T) {
 	n0, n1, n2, n3, n4 := SetupNodes()
 
 	testData := []struct {
-		node              *Node
-		expectedIntervals []Interval
+		node              *Node[Float, uint32]
+		expectedIntervals []Interval[uint32]
 	}{
-		{n0, []Interval{NewInterval(0, 15), NewInterval(15, 30), NewInterval(30, 45), NewInterval(45, math.MaxUint32)}},
-		{n1, []Interval{NewInterval(0, 5), NewInterval(5, 10), NewInterval(10, 15)}},
-		{n2, []Interval{NewInterval(15, 20), NewInterval(20, 30)}},
-		{n3, []Interval{NewInterval(30, 35), NewInterval(35, 40), NewInterval(40, 45)}},
-		{n4, []Interval{NewInterval(45, 50), NewInterval(50, math.MaxUint32)}},
+		{n0, []Interval[uint32]{NewInterval[uint32](0, 15), NewInterval[uint32](15, 30), NewInterval[uint32](30, 45), NewInterval[uint32](45, math.MaxUint32)}},
+		{n1, []Interval[uint32]{NewInterval[uint32](0, 5), NewInterval[uint32](5, 10), NewInterval[uint32](10, 15)}},
+		{n2, []Interval[uint32]{NewInterval[uint32](15, 20), NewInterval[uint32](20, 30)}},
+		{n3, []Interval[uint32]{NewInterval[uint32](30, 35), NewInterval[uint32](35, 40), NewInterval[uint32](40, 45)}},
+		{n4, []Interval[uint32]{NewInterval[uint32](45, 50), NewInterval[uint32](50, math.MaxUint32)}},
 	}
 
 	for _, td := range testData {
@@ -215,15 +221,17 @@ func TestGetIntervals(t *testing.T) {
 
 func TestInsertEmptyNode(t *testing.T) {
 	// Arrange
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{},
-		values: []Addable{Float(0)},
-		tree: &SegmentTreeImpl{
-			aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		values: []Float{Float(0)},
+		tree: &SegmentTreeImpl[Float, uint32]{
+			aggregate:       SumAggregate[Float](),
 			branchingFactor: BRANCHING_FACTOR,
 		},
 	}
-	intervalTuple := ValueIntervalTuple{value: Addable(Float(7)), interval: Interval{start: 17, end: 47}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(7), interval: Interval[uint32]{start: 17, end: 47}}
+
+	setBounds(node)
 
 	// Act
 	node.insert(0, intervalTuple)
@@ -239,25 +247,27 @@ func TestInsertEmptyNode(t *testing.T) {
 
 func TestInsertSelfContained1(t *testing.T) {
 	// Arrange
-	tree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 20},
-		values: []Addable{Float(1), Float(2), Float(3)},
+		values: []Float{Float(1), Float(2), Float(3)},
 		tree:   tree,
 	}
 
-	parent := &Node{
+	parent := &Node[Float, uint32]{
 		keys:     []uint32{50},
-		values:   []Addable{Float(0), Float(0)},
-		children: []*Node{node, nil},
+		values:   []Float{Float(0), Float(0)},
+		children: []link[Float, uint32]{{node: node}, {}},
 		tree:     tree,
 	}
 
 	node.parent = parent
-	intervalTuple := ValueIntervalTuple{value: Addable(Float(7)), interval: Interval{start: 1, end: 3}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(7), interval: Interval[uint32]{start: 1, end: 3}}
+
+	setBounds(parent)
 
 	// Act
 	node.insert(0, intervalTuple)
@@ -276,25 +286,27 @@ func TestInsertSelfContained1(t *testing.T) {
 
 func TestInsertSelfContained2(t *testing.T) {
 	// Arrange
-	tree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 20},
-		values: []Addable{Float(1), Float(2), Float(3)},
+		values: []Float{Float(1), Float(2), Float(3)},
 		tree:   tree,
 	}
 
-	parent := &Node{
+	parent := &Node[Float, uint32]{
 		keys:     []uint32{50},
-		values:   []Addable{Float(0), Float(0)},
-		children: []*Node{node, nil},
+		values:   []Float{Float(0), Float(0)},
+		children: []link[Float, uint32]{{node: node}, {}},
 		tree:     tree,
 	}
 
 	node.parent = parent
-	intervalTuple := ValueIntervalTuple{value: Addable(Float(7)), interval: Interval{start: 11, end: 13}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(7), interval: Interval[uint32]{start: 11, end: 13}}
+
+	setBounds(parent)
 
 	// Act
 	node.insert(1, intervalTuple)
@@ -313,25 +325,27 @@ func TestInsertSelfContained2(t *testing.T) {
 
 func TestInsertSelfContained3(t *testing.T) {
 	// Arrange
-	tree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 20},
-		values: []Addable{Float(1), Float(2), Float(3)},
+		values: []Float{Float(1), Float(2), Float(3)},
 		tree:   tree,
 	}
 
-	parent := &Node{
+	parent := &Node[Float, uint32]{
 		keys:     []uint32{50},
-		values:   []Addable{Float(0), Float(0)},
-		children: []*Node{node, nil},
+		values:   []Float{Float(0), Float(0)},
+		children: []link[Float, uint32]{{node: node}, {}},
 		tree:     tree,
 	}
 
 	node.parent = parent
-	intervalTuple := ValueIntervalTuple{value: Addable(Float(7)), interval: Interval{start: 21, end: 23}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(7), interval: Interval[uint32]{start: 21, end: 23}}
+
+	setBounds(parent)
 
 	// Act
 	node.insert(2, intervalTuple)
@@ -350,25 +364,27 @@ func TestInsertSelfContained3(t *testing.T) {
 
 func TestInsertNodeIntervalLeftLarger1(t *testing.T) {
 	// Arrange
-	tree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 20},
-		values: []Addable{Float(1), Float(2), Float(3)},
+		values: []Float{Float(1), Float(2), Float(3)},
 		tree:   tree,
 	}
 
-	parent := &Node{
+	parent := &Node[Float, uint32]{
 		keys:     []uint32{50},
-		values:   []Addable{Float(0), Float(0)},
-		children: []*Node{node, nil},
+		values:   []Float{Float(0), Float(0)},
+		children: []link[Float, uint32]{{node: node}, {}},
 		tree:     tree,
 	}
 
 	node.parent = parent
-	intervalTuple := ValueIntervalTuple{value: Addable(Float(7)), interval: Interval{start: 1, end: 100}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(7), interval: Interval[uint32]{start: 1, end: 100}}
+
+	setBounds(parent)
 
 	// Act
 	node.insert(0, intervalTuple)
@@ -385,25 +401,27 @@ func TestInsertNodeIntervalLeftLarger1(t *testing.T) {
 
 func TestInsertNodeIntervalLeftLarger2(t *testing.T) {
 	// Arrange
-	tree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 20},
-		values: []Addable{Float(1), Float(2), Float(3)},
+		values: []Float{Float(1), Float(2), Float(3)},
 		tree:   tree,
 	}
 
-	parent := &Node{
+	parent := &Node[Float, uint32]{
 		keys:     []uint32{50},
-		values:   []Addable{Float(0), Float(0)},
-		children: []*Node{node, nil},
+		values:   []Float{Float(0), Float(0)},
+		children: []link[Float, uint32]{{node: node}, {}},
 		tree:     tree,
 	}
 
 	node.parent = parent
-	intervalTuple := ValueIntervalTuple{value: Addable(Float(7)), interval: Interval{start: 11, end: 100}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(7), interval: Interval[uint32]{start: 11, end: 100}}
+
+	setBounds(parent)
 
 	// Act
 	node.insert(1, intervalTuple)
@@ -420,25 +438,27 @@ func TestInsertNodeIntervalLeftLarger2(t *testing.T) {
 
 func TestInsertNodeIntervalLeftLarger3(t *testing.T) {
 	// Arrange
-	tree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 20},
-		values: []Addable{Float(1), Float(2), Float(3)},
+		values: []Float{Float(1), Float(2), Float(3)},
 		tree:   tree,
 	}
 
-	parent := &Node{
+	parent := &Node[Float, uint32]{
 		keys:     []uint32{50},
-		values:   []Addable{Float(0), Float(0)},
-		children: []*Node{node, nil},
+		values:   []Float{Float(0), Float(0)},
+		children: []link[Float, uint32]{{node: node}, {}},
 		tree:     tree,
 	}
 
 	node.parent = parent
-	intervalTuple := ValueIntervalTuple{value: Addable(Float(7)), interval: Interval{start: 21, end: 100}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(7), interval: Interval[uint32]{start: 21, end: 100}}
+
+	setBounds(parent)
 
 	// Act
 	node.insert(2, intervalTuple)
@@ -455,25 +475,27 @@ func TestInsertNodeIntervalLeftLarger3(t *testing.T) {
 
 func TestInsertNodeIntervalRightLarger1(t *testing.T) {
 	// Arrange
-	tree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 20},
-		values: []Addable{Float(1), Float(2), Float(3)},
+		values: []Float{Float(1), Float(2), Float(3)},
 		tree:   tree,
 	}
 
-	parent := &Node{
+	parent := &Node[Float, uint32]{
 		keys:     []uint32{4},
-		values:   []Addable{Float(0), Float(0)},
-		children: []*Node{nil, node},
+		values:   []Float{Float(0), Float(0)},
+		children: []link[Float, uint32]{{}, {node: node}},
 		tree:     tree,
 	}
 
 	node.parent = parent
-	intervalTuple := ValueIntervalTuple{value: Addable(Float(7)), interval: Interval{start: 3, end: 6}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(7), interval: Interval[uint32]{start: 3, end: 6}}
+
+	setBounds(parent)
 
 	// Act
 	node.insert(0, intervalTuple)
@@ -490,25 +512,27 @@ func TestInsertNodeIntervalRightLarger1(t *testing.T) {
 
 func TestInsertNodeIntervalRightLarger2(t *testing.T) {
 	// Arrange
-	tree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 20},
-		values: []Addable{Float(1), Float(2), Float(3)},
+		values: []Float{Float(1), Float(2), Float(3)},
 		tree:   tree,
 	}
 
-	parent := &Node{
+	parent := &Node[Float, uint32]{
 		keys:     []uint32{50},
-		values:   []Addable{Float(0), Float(0)},
-		children: []*Node{node, nil},
+		values:   []Float{Float(0), Float(0)},
+		children: []link[Float, uint32]{{node: node}, {}},
 		tree:     tree,
 	}
 
 	node.parent = parent
-	intervalTuple := ValueIntervalTuple{value: Addable(Float(7)), interval: Interval{start: 0, end: 11}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(7), interval: Interval[uint32]{start: 0, end: 11}}
+
+	setBounds(parent)
 
 	// Act
 	node.insert(1, intervalTuple)
@@ -525,25 +549,27 @@ func TestInsertNodeIntervalRightLarger2(t *testing.T) {
 
 func TestInsertNodeIntervalRightLarger3(t *testing.T) {
 	// Arrange
-	tree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 20},
-		values: []Addable{Float(1), Float(2), Float(3)},
+		values: []Float{Float(1), Float(2), Float(3)},
 		tree:   tree,
 	}
 
-	parent := &Node{
+	parent := &Node[Float, uint32]{
 		keys:     []uint32{50},
-		values:   []Addable{Float(0), Float(0)},
-		children: []*Node{node, nil},
+		values:   []Float{Float(0), Float(0)},
+		children: []link[Float, uint32]{{node: node}, {}},
 		tree:     tree,
 	}
 
 	node.parent = parent
-	intervalTuple := ValueIntervalTuple{value: Addable(Float(7)), interval: Interval{start: 0, end: 21}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(7), interval: Interval[uint32]{start: 0, end: 21}}
+
+	setBounds(parent)
 
 	// Act
 	node.insert(2, intervalTuple)
@@ -560,15 +586,17 @@ func TestInsertNodeIntervalRightLarger3(t *testing.T) {
 
 func TestInsertMatchingStartPoint1(t *testing.T) {
 	// Arrange
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 40},
-		values: []Addable{Float(0), Float(2), Float(0)},
-		tree: &SegmentTreeImpl{
-			aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		values: []Float{Float(0), Float(2), Float(0)},
+		tree: &SegmentTreeImpl[Float, uint32]{
+			aggregate:       SumAggregate[Float](),
 			branchingFactor: BRANCHING_FACTOR,
 		},
 	}
-	intervalTuple := ValueIntervalTuple{value: Float(3), interval: Interval{start: 0, end: 5}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(3), interval: Interval[uint32]{start: 0, end: 5}}
+
+	setBounds(node)
 
 	// Act
 	node.insert(0, intervalTuple)
@@ -585,15 +613,17 @@ func TestInsertMatchingStartPoint1(t *testing.T) {
 
 func TestInsertMatchingStartPoint2IntervallIndex1(t *testing.T) {
 	// Arrange
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 40},
-		values: []Addable{Float(0), Float(2), Float(0)},
-		tree: &SegmentTreeImpl{
-			aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		values: []Float{Float(0), Float(2), Float(0)},
+		tree: &SegmentTreeImpl[Float, uint32]{
+			aggregate:       SumAggregate[Float](),
 			branchingFactor: BRANCHING_FACTOR,
 		},
 	}
-	intervalTuple := ValueIntervalTuple{value: Float(3), interval: Interval{start: 10, end: 30}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(3), interval: Interval[uint32]{start: 10, end: 30}}
+
+	setBounds(node)
 
 	// Act
 	node.insert(1, intervalTuple)
@@ -610,15 +640,17 @@ func TestInsertMatchingStartPoint2IntervallIndex1(t *testing.T) {
 
 func TestInsertMatchingStartPoint3(t *testing.T) {
 	// Arrange
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 40},
-		values: []Addable{Float(0), Float(2), Float(0)},
-		tree: &SegmentTreeImpl{
-			aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		values: []Float{Float(0), Float(2), Float(0)},
+		tree: &SegmentTreeImpl[Float, uint32]{
+			aggregate:       SumAggregate[Float](),
 			branchingFactor: BRANCHING_FACTOR,
 		},
 	}
-	intervalTuple := ValueIntervalTuple{value: Float(3), interval: Interval{start: 40, end: 100}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(3), interval: Interval[uint32]{start: 40, end: 100}}
+
+	setBounds(node)
 
 	// Act
 	node.insert(2, intervalTuple)
@@ -635,15 +667,17 @@ func TestInsertMatchingStartPoint3(t *testing.T) {
 
 func TestInsertMatchingEndPoint1(t *testing.T) {
 	// Arrange
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 40},
-		values: []Addable{Float(0), Float(2), Float(0)},
-		tree: &SegmentTreeImpl{
-			aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		values: []Float{Float(0), Float(2), Float(0)},
+		tree: &SegmentTreeImpl[Float, uint32]{
+			aggregate:       SumAggregate[Float](),
 			branchingFactor: BRANCHING_FACTOR,
 		},
 	}
-	intervalTuple := ValueIntervalTuple{value: Float(3), interval: Interval{start: 3, end: 10}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(3), interval: Interval[uint32]{start: 3, end: 10}}
+
+	setBounds(node)
 
 	// Act
 	node.insert(0, intervalTuple)
@@ -660,15 +694,17 @@ func TestInsertMatchingEndPoint1(t *testing.T) {
 
 func TestInsertMatchingEndPoint2(t *testing.T) {
 	// Arrange
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 40},
-		values: []Addable{Float(0), Float(2), Float(0)},
-		tree: &SegmentTreeImpl{
-			aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		values: []Float{Float(0), Float(2), Float(0)},
+		tree: &SegmentTreeImpl[Float, uint32]{
+			aggregate:       SumAggregate[Float](),
 			branchingFactor: BRANCHING_FACTOR,
 		},
 	}
-	intervalTuple := ValueIntervalTuple{value: Float(3), interval: Interval{start: 30, end: 40}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(3), interval: Interval[uint32]{start: 30, end: 40}}
+
+	setBounds(node)
 
 	// Act
 	node.insert(1, intervalTuple)
@@ -685,15 +721,17 @@ func TestInsertMatchingEndPoint2(t *testing.T) {
 
 func TestInsertMatchingEndPoint3(t *testing.T) {
 	// Arrange
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{10, 40},
-		values: []Addable{Float(0), Float(2), Float(0)},
-		tree: &SegmentTreeImpl{
-			aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		values: []Float{Float(0), Float(2), Float(0)},
+		tree: &SegmentTreeImpl[Float, uint32]{
+			aggregate:       SumAggregate[Float](),
 			branchingFactor: BRANCHING_FACTOR,
 		},
 	}
-	intervalTuple := ValueIntervalTuple{value: Float(3), interval: Interval{start: 50, end: math.MaxUint32}}
+	intervalTuple := ValueIntervalTuple[Float, uint32]{value: Float(3), interval: Interval[uint32]{start: 50, end: math.MaxUint32}}
+
+	setBounds(node)
 
 	// Act
 	node.insert(2, intervalTuple)
@@ -710,13 +748,13 @@ func TestInsertMatchingEndPoint3(t *testing.T) {
 
 func TestSplitRootNodeWithOddNumberOfKeys(t *testing.T) {
 	// Arrange
-	SBTree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	SBTree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: 4,
 	}
-	n0 := &Node{
+	n0 := &Node[Float, uint32]{
 		keys:   []uint32{10, 20, 30, 40, 50},
-		values: []Addable{Float(0), Float(1), Float(2), Float(3), Float(4), Float(0)},
+		values: []Float{Float(0), Float(1), Float(2), Float(3), Float(4), Float(0)},
 		parent: nil,
 		isLeaf: true,
 		tree:   SBTree,
@@ -724,13 +762,15 @@ func TestSplitRootNodeWithOddNumberOfKeys(t *testing.T) {
 
 	SBTree.root = n0
 
+	setBounds(n0)
+
 	// Act
 	n0.split()
 
 	// Assert
 	n_root := SBTree.root
-	c0 := n_root.children[0]
-	c1 := n_root.children[1]
+	c0 := n_root.children[0].node
+	c1 := n_root.children[1].node
 	assert.Equal(t, 1, int(n_root.size()))
 	assert.Equal(t, 2, int(c0.size()))
 	assert.Equal(t, 2, int(c1.size()))
@@ -756,13 +796,13 @@ func TestSplitRootNodeWithOddNumberOfKeys(t *testing.T) {
 // Fig. 19, Split Root
 func TestSplitRootNodeWithEvenNumberOfKeysBook(t *testing.T) {
 	// Arrange
-	SBTree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	SBTree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: 4,
 	}
-	n0 := &Node{
+	n0 := &Node[Float, uint32]{
 		keys:   []uint32{10, 20, 30, 40},
-		values: []Addable{Float(0), Float(5), Float(6), Float(3), Float(0)},
+		values: []Float{Float(0), Float(5), Float(6), Float(3), Float(0)},
 		parent: nil,
 		isLeaf: true,
 		tree:   SBTree,
@@ -770,13 +810,15 @@ func TestSplitRootNodeWithEvenNumberOfKeysBook(t *testing.T) {
 
 	SBTree.root = n0
 
+	setBounds(n0)
+
 	// Act
 	n0.split()
 
 	// Assert
 	n_root := SBTree.root
-	c0 := n_root.children[0]
-	c1 := n_root.children[1]
+	c0 := n_root.children[0].node
+	c1 := n_root.children[1].node
 	assert.Equal(t, 1, int(n_root.size()))
 	assert.Equal(t, 2, int(c0.size()))
 	assert.Equal(t, 1, int(c1.size()))
@@ -798,54 +840,56 @@ func TestSplitRootNodeWithEvenNumberOfKeysBook(t *testing.T) {
 
 func TestSplitNonRootNodeIsLeafAndSplitsNotParent(t *testing.T) {
 	// Arrange
-	SBTree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	SBTree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: 4,
 	}
-	n0 := &Node{
+	n0 := &Node[Float, uint32]{
 		keys:     []uint32{20, 40},
-		values:   []Addable{Float(0), Float(1), Float(0)},
-		children: []*Node{nil, nil, nil},
+		values:   []Float{Float(0), Float(1), Float(0)},
+		children: []link[Float, uint32]{{}, {}, {}},
 		parent:   nil,
 		isLeaf:   false,
 		tree:     SBTree,
 	}
-	n10 := &Node{
+	n10 := &Node[Float, uint32]{
 		keys:   []uint32{5, 10},
-		values: []Addable{Float(0), Float(1), Float(2)},
+		values: []Float{Float(0), Float(1), Float(2)},
 		parent: n0,
 		isLeaf: true,
 		tree:   SBTree,
 	}
-	n11 := &Node{
+	n11 := &Node[Float, uint32]{
 		keys:   []uint32{22, 25, 30, 35},
-		values: []Addable{Float(3), Float(4), Float(5), Float(6), Float(7)},
+		values: []Float{Float(3), Float(4), Float(5), Float(6), Float(7)},
 		parent: n0,
 		isLeaf: true,
 		tree:   SBTree,
 	}
-	n12 := &Node{
+	n12 := &Node[Float, uint32]{
 		keys:   []uint32{45, 50},
-		values: []Addable{Float(8), Float(9), Float(0)},
+		values: []Float{Float(8), Float(9), Float(0)},
 		parent: n0,
 		isLeaf: true,
 		tree:   SBTree,
 	}
 
 	SBTree.root = n0
-	n0.children[0] = n10
-	n0.children[1] = n11
-	n0.children[2] = n12
+	n0.children[0].node = n10
+	n0.children[1].node = n11
+	n0.children[2].node = n12
+
+	setBounds(n0)
 
 	// Act (split node n11)
 	n11.split()
 
 	// Assert
 	n_root := SBTree.root
-	c10 := n_root.children[0]
-	c11 := n_root.children[1]
-	c12 := n_root.children[2]
-	c13 := n_root.children[3]
+	c10 := n_root.children[0].node
+	c11 := n_root.children[1].node
+	c12 := n_root.children[2].node
+	c13 := n_root.children[3].node
 
 	assert.Equal(t, uint32(20), n_root.keys[0])
 	assert.Equal(t, uint32(30), n_root.keys[1])
@@ -881,54 +925,56 @@ func TestSplitNonRootNodeIsLeafAndSplitsNotParent(t *testing.T) {
 // Fig 19 Split Leaf
 func TestSplitMostRightNonRootNodeIsLeafAndSplitsNotParent(t *testing.T) {
 	// Arrange
-	SBTree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	SBTree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: 4,
 	}
-	n0 := &Node{
+	n0 := &Node[Float, uint32]{
 		keys:     []uint32{15, 30},
-		values:   []Addable{Float(0), Float(1), Float(0)},
-		children: []*Node{nil, nil, nil},
+		values:   []Float{Float(0), Float(1), Float(0)},
+		children: []link[Float, uint32]{{}, {}, {}},
 		parent:   nil,
 		isLeaf:   false,
 		tree:     SBTree,
 	}
-	n10 := &Node{
+	n10 := &Node[Float, uint32]{
 		keys:   []uint32{5, 10},
-		values: []Addable{Float(0), Float(2), Float(8)},
+		values: []Float{Float(0), Float(2), Float(8)},
 		parent: n0,
 		isLeaf: true,
 		tree:   SBTree,
 	}
-	n11 := &Node{
+	n11 := &Node[Float, uint32]{
 		keys:   []uint32{20},
-		values: []Addable{Float(5), Float(6)},
+		values: []Float{Float(5), Float(6)},
 		parent: n0,
 		isLeaf: true,
 		tree:   SBTree,
 	}
-	n12 := &Node{
+	n12 := &Node[Float, uint32]{
 		keys:   []uint32{35, 40, 45, 50},
-		values: []Addable{Float(4), Float(8), Float(5), Float(1), Float(0)},
+		values: []Float{Float(4), Float(8), Float(5), Float(1), Float(0)},
 		parent: n0,
 		isLeaf: true,
 		tree:   SBTree,
 	}
 
 	SBTree.root = n0
-	n0.children[0] = n10
-	n0.children[1] = n11
-	n0.children[2] = n12
+	n0.children[0].node = n10
+	n0.children[1].node = n11
+	n0.children[2].node = n12
+
+	setBounds(n0)
 
 	// Act (split node n11)
 	n12.split()
 
 	// Assert
 	n_root := SBTree.root
-	c10 := n_root.children[0]
-	c11 := n_root.children[1]
-	c12 := n_root.children[2]
-	c13 := n_root.children[3]
+	c10 := n_root.children[0].node
+	c11 := n_root.children[1].node
+	c12 := n_root.children[2].node
+	c13 := n_root.children[3].node
 
 	assert.Equal(t, 15, int(n_root.keys[0]))
 	assert.Equal(t, 30, int(n_root.keys[1]))
@@ -962,45 +1008,47 @@ func TestSplitMostRightNonRootNodeIsLeafAndSplitsNotParent(t *testing.T) {
 // Fig 19 Split Leaf
 func TestSplitMostLefNonRootNodeIsLeafAndSplitsNotParent(t *testing.T) {
 	// Arrange
-	SBTree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	SBTree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: 4,
 	}
-	n0 := &Node{
+	n0 := &Node[Float, uint32]{
 		keys:     []uint32{30},
-		values:   []Addable{Float(0), Float(0)},
-		children: []*Node{nil, nil, nil},
+		values:   []Float{Float(0), Float(0)},
+		children: []link[Float, uint32]{{}, {}, {}},
 		parent:   nil,
 		isLeaf:   false,
 		tree:     SBTree,
 	}
-	n10 := &Node{
+	n10 := &Node[Float, uint32]{
 		keys:   []uint32{5, 10, 15, 20},
-		values: []Addable{Float(0), Float(2), Float(7), Float(5), Float(6)},
+		values: []Float{Float(0), Float(2), Float(7), Float(5), Float(6)},
 		parent: n0,
 		isLeaf: true,
 		tree:   SBTree,
 	}
-	n11 := &Node{
+	n11 := &Node[Float, uint32]{
 		keys:   []uint32{40},
-		values: []Addable{Float(3), Float(0)},
+		values: []Float{Float(3), Float(0)},
 		parent: n0,
 		isLeaf: true,
 		tree:   SBTree,
 	}
 
 	SBTree.root = n0
-	n0.children[0] = n10
-	n0.children[1] = n11
+	n0.children[0].node = n10
+	n0.children[1].node = n11
+
+	setBounds(n0)
 
 	// Act (split node n11)
 	n10.split()
 
 	// Assert
 	n_root := SBTree.root
-	c10 := n_root.children[0]
-	c11 := n_root.children[1]
-	c12 := n_root.children[2]
+	c10 := n_root.children[0].node
+	c11 := n_root.children[1].node
+	c12 := n_root.children[2].node
 
 	assert.Equal(t, 15, int(n_root.keys[0]))
 	assert.Equal(t, 30, int(n_root.keys[1]))
@@ -1026,67 +1074,69 @@ func TestSplitMostLefNonRootNodeIsLeafAndSplitsNotParent(t *testing.T) {
 
 func TestSplitNonRootNodeIsLeafAndSplitsParentWhichIsNoLeaf(t *testing.T) {
 	// Arrange
-	SBTree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	SBTree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: 4,
 	}
-	n0 := &Node{
+	n0 := &Node[Float, uint32]{
 		keys:     []uint32{20, 40, 60},
-		values:   []Addable{Float(0), Float(1), Float(2), Float(0)},
-		children: []*Node{nil, nil, nil, nil},
+		values:   []Float{Float(0), Float(1), Float(2), Float(0)},
+		children: []link[Float, uint32]{{}, {}, {}, {}},
 		parent:   nil,
 		isLeaf:   false,
 		tree:     SBTree,
 	}
-	n10 := &Node{
+	n10 := &Node[Float, uint32]{
 		keys:   []uint32{5, 10},
-		values: []Addable{Float(0), Float(1), Float(2)},
+		values: []Float{Float(0), Float(1), Float(2)},
 		parent: n0,
 		isLeaf: true,
 		tree:   SBTree,
 	}
-	n11 := &Node{
+	n11 := &Node[Float, uint32]{
 		keys:   []uint32{22, 25, 30, 35},
-		values: []Addable{Float(3), Float(4), Float(5), Float(6), Float(7)},
+		values: []Float{Float(3), Float(4), Float(5), Float(6), Float(7)},
 		parent: n0,
 		isLeaf: true,
 		tree:   SBTree,
 	}
-	n12 := &Node{
+	n12 := &Node[Float, uint32]{
 		keys:   []uint32{45, 50},
-		values: []Addable{Float(8), Float(9), Float(0)},
+		values: []Float{Float(8), Float(9), Float(0)},
 		parent: n0,
 		isLeaf: true,
 		tree:   SBTree,
 	}
-	n13 := &Node{
+	n13 := &Node[Float, uint32]{
 		keys:   []uint32{65},
-		values: []Addable{Float(8), Float(0)},
+		values: []Float{Float(8), Float(0)},
 		parent: n0,
 		isLeaf: true,
 		tree:   SBTree,
 	}
 
 	SBTree.root = n0
-	n0.children[0] = n10
-	n0.children[1] = n11
-	n0.children[2] = n12
-	n0.children[3] = n13
+	n0.children[0].node = n10
+	n0.children[1].node = n11
+	n0.children[2].node = n12
+	n0.children[3].node = n13
+
+	setBounds(n0)
 
 	// Act (split node n11)
 	n11.split()
 
 	// Assert
 	n_root := SBTree.root
-	c10 := n_root.children[0]
-	c11 := n_root.children[1]
+	c10 := n_root.children[0].node
+	c11 := n_root.children[1].node
 
-	c20 := c10.children[0]
-	c21 := c10.children[1]
-	c22 := c10.children[2]
+	c20 := c10.children[0].node
+	c21 := c10.children[1].node
+	c22 := c10.children[2].node
 
-	c23 := c11.children[0]
-	c24 := c11.children[1]
+	c23 := c11.children[0].node
+	c24 := c11.children[1].node
 
 	assert.Equal(t, uint32(40), n_root.keys[0])
 	assert.Equal(t, SBTree.aggregate.neutralElement, n_root.values[0])
@@ -1129,18 +1179,68 @@ func TestSplitNonRootNodeIsLeafAndSplitsParentWhichIsNoLeaf(t *testing.T) {
 	assert.Equal(t, Float(0), c24.values[1])
 }
 
+func TestSplitRootNodeWhichIsNoLeafUpdatesParentOfChildren(t *testing.T) {
+	// Arrange
+	SBTree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
+		branchingFactor: 4,
+	}
+	n0 := &Node[Float, uint32]{
+		keys:     []uint32{10, 20, 30, 40},
+		values:   []Float{Float(0), Float(1), Float(2), Float(3), Float(4)},
+		children: make([]link[Float, uint32], 5),
+		isLeaf:   false,
+		tree:     SBTree,
+	}
+	for i := range n0.children {
+		n0.children[i].node = &Node[Float, uint32]{
+			keys:   []uint32{},
+			values: []Float{Float(0)},
+			parent: n0,
+			isLeaf: true,
+			tree:   SBTree,
+		}
+	}
+	SBTree.root = n0
+
+	setBounds(n0)
+
+	// Act
+	n0.split()
+
+	// Assert
+	c0 := SBTree.root.children[0].node
+	c1 := SBTree.root.children[1].node
+
+	assert.Len(t, c0.children, 3)
+	assert.Len(t, c1.children, 2)
+	for _, child := range c0.children {
+		assert.Same(t, c0, child.node.parent)
+	}
+	for _, child := range c1.children {
+		assert.Same(t, c1, child.node.parent)
+	}
+
+	// Appending to the left node must not overwrite the children of the right node
+	firstChildOfC1 := c1.children[0].node
+	c0.children = append(c0.children, link[Float, uint32]{})
+	assert.Same(t, firstChildOfC1, c1.children[0].node)
+}
+
 func TestIMergeOnlyOneKeyMergeTwoValues(t *testing.T) {
 	// Arrange
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{12},
-		values: []Addable{Float(8), Float(8)},
+		values: []Float{Float(8), Float(8)},
 		isLeaf: true,
-		tree: &SegmentTreeImpl{
-			aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		tree: &SegmentTreeImpl[Float, uint32]{
+			aggregate:       SumAggregate[Float](),
 			branchingFactor: BRANCHING_FACTOR,
 		},
 	}
 
+	setBounds(node)
+
 	// Act
 	node.imerge()
 
@@ -1151,16 +1251,18 @@ func TestIMergeOnlyOneKeyMergeTwoValues(t *testing.T) {
 
 func TestIMergeOnlyTwoKeysMergeTwoValues(t *testing.T) {
 	// Arrange
-	node := &Node{
+	node := &Node[Float, uint32]{
 		keys:   []uint32{5, 7},
-		values: []Addable{Float(0), Float(2), Float(2)},
+		values: []Float{Float(0), Float(2), Float(2)},
 		isLeaf: true,
-		tree: &SegmentTreeImpl{
-			aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		tree: &SegmentTreeImpl[Float, uint32]{
+			aggregate:       SumAggregate[Float](),
 			branchingFactor: BRANCHING_FACTOR,
 		},
 	}
 
+	setBounds(node)
+
 	// Act
 	node.imerge()
 
@@ -1173,61 +1275,63 @@ func TestIMergeOnlyTwoKeysMergeTwoValues(t *testing.T) {
 
 func TestNMerge(t *testing.T) {
 	// Arrange
-	tree := &SegmentTreeImpl{
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
-	n0 := &Node{
+	n0 := &Node[Float, uint32]{
 		keys:   []uint32{30},
-		values: []Addable{Float(0), Float(0)},
+		values: []Float{Float(0), Float(0)},
 		tree:   tree,
 	}
-	n01 := &Node{
+	n01 := &Node[Float, uint32]{
 		keys:   []uint32{10, 15},
-		values: []Addable{Float(0), Float(0), Float(1)},
+		values: []Float{Float(0), Float(0), Float(1)},
 		parent: n0,
 		isLeaf: false,
 		tree:   tree,
 	}
-	n02 := &Node{
+	n02 := &Node[Float, uint32]{
 		keys:   []uint32{45},
-		values: []Addable{Float(0), Float(0)},
+		values: []Float{Float(0), Float(0)},
 		parent: n0,
 		isLeaf: true,
 		tree:   tree,
 	}
-	n11 := &Node{
+	n11 := &Node[Float, uint32]{
 		keys:   []uint32{5},
-		values: []Addable{Float(0), Float(2)},
+		values: []Float{Float(0), Float(2)},
 		isLeaf: true,
 		parent: n01,
 		tree:   tree,
 	}
-	n12 := &Node{
+	n12 := &Node[Float, uint32]{
 		keys:   []uint32{},
-		values: []Addable{Float(8)},
+		values: []Float{Float(8)},
 		isLeaf: true,
 		parent: n01,
 		tree:   tree,
 	}
-	n2 := &Node{
+	n2 := &Node[Float, uint32]{
 		keys:   []uint32{20},
-		values: []Addable{Float(5), Float(6)},
+		values: []Float{Float(5), Float(6)},
 		isLeaf: true,
 		parent: n01,
 		tree:   tree,
 	}
 	tree.root = n0
-	n0.children = []*Node{n01, n02}
-	n01.children = []*Node{n11, n12, n2}
+	n0.children = []link[Float, uint32]{{node: n01}, {node: n02}}
+	n01.children = []link[Float, uint32]{{node: n11}, {node: n12}, {node: n2}}
+
+	setBounds(n0)
 
 	// Act
 	n12.nmerge()
 
 	// Assert
-	n01 = tree.root.children[0]
-	n11 = n01.children[0]
-	n2n := n01.children[1]
+	n01 = tree.root.children[0].node
+	n11 = n01.children[0].node
+	n2n := n01.children[1].node
 
 	assert.Equal(t, 1, int(n01.size()))
 	assert.Equal(t, uint32(10), n01.keys[0])
@@ -1248,32 +1352,314 @@ func TestNMerge(t *testing.T) {
 
 }
 
-func SetupNodes() (*Node, *Node, *Node, *Node, *Node) {
-	n0 := &Node{
+func TestNMergeWithRightSiblingKeepsKeysSorted(t *testing.T) {
+	// Arrange
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
+		branchingFactor: BRANCHING_FACTOR,
+	}
+	n0 := &Node[Float, uint32]{
+		keys:   []uint32{30},
+		values: []Float{Float(1), Float(2)},
+		tree:   tree,
+	}
+	n1 := &Node[Float, uint32]{
+		keys:   []uint32{},
+		values: []Float{Float(3)},
+		parent: n0,
+		isLeaf: true,
+		tree:   tree,
+	}
+	n2 := &Node[Float, uint32]{
+		keys:   []uint32{40},
+		values: []Float{Float(4), Float(5)},
+		parent: n0,
+		isLeaf: true,
+		tree:   tree,
+	}
+	tree.root = n0
+	n0.children = []link[Float, uint32]{{node: n1}, {node: n2}}
+
+	setBounds(n0)
+
+	// Act
+	n1.nmerge()
+
+	// Assert
+	root := tree.root
+
+	assert.True(t, root.isLeaf)
+	assert.Nil(t, root.parent)
+	assert.Equal(t, []uint32{30, 40}, root.keys)
+	assert.Equal(t, []Float{Float(4), Float(6), Float(7)}, root.values)
+}
+
+func TestNMergeRootWithOneChildAddsRootValueToChild(t *testing.T) {
+	// Arrange
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
+		branchingFactor: BRANCHING_FACTOR,
+	}
+	n0 := &Node[Float, uint32]{
+		keys:   []uint32{},
+		values: []Float{Float(5)},
+		tree:   tree,
+	}
+	n1 := &Node[Float, uint32]{
+		keys:   []uint32{10},
+		values: []Float{Float(1), Float(2)},
+		parent: n0,
+		isLeaf: true,
+		tree:   tree,
+	}
+	tree.root = n0
+	n0.children = []link[Float, uint32]{{node: n1}}
+
+	setBounds(n0)
+
+	// Act
+	n0.nmerge()
+
+	// Assert
+	assert.Same(t, n1, tree.root)
+	assert.Nil(t, n1.parent)
+	assert.Equal(t, []Float{Float(6), Float(7)}, n1.values)
+}
+
+func TestNMergeWithMaxAggregateCombinesValues(t *testing.T) {
+	// Arrange
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       MaxAggregate(Float(math.Inf(-1))),
+		branchingFactor: BRANCHING_FACTOR,
+	}
+	n0 := &Node[Float, uint32]{
+		keys:   []uint32{30},
+		values: []Float{Float(3), Float(1)},
+		tree:   tree,
+	}
+	n1 := &Node[Float, uint32]{
+		keys:   []uint32{},
+		values: []Float{Float(2)},
+		parent: n0,
+		isLeaf: true,
+		tree:   tree,
+	}
+	n2 := &Node[Float, uint32]{
+		keys:   []uint32{40},
+		values: []Float{Float(4), Float(0)},
+		parent: n0,
+		isLeaf: true,
+		tree:   tree,
+	}
+	tree.root = n0
+	n0.children = []link[Float, uint32]{{node: n1}, {node: n2}}
+
+	setBounds(n0)
+
+	// Act
+	n1.nmerge()
+
+	// Assert
+	assert.Equal(t, []uint32{30, 40}, tree.root.keys)
+	assert.Equal(t, []Float{Float(3), Float(4), Float(1)}, tree.root.values)
+}
+
+func TestNMergeStealingFromRightSiblingUpdatesParentOfMovedChild(t *testing.T) {
+	// Arrange
+	tree, n0, leaves := setupInteriorSiblings([]uint32{}, []uint32{40, 50})
+	left, right := n0.children[0].node, n0.children[1].node
+
+	// Act
+	left.nmerge()
+
+	// Assert
+	assert.Equal(t, []uint32{30}, left.keys)
+	assert.Equal(t, []uint32{50}, right.keys)
+	assert.Equal(t, []uint32{40}, n0.keys)
+	assert.Same(t, left, leaves[1].parent)
+	assert.Same(t, right, leaves[2].parent)
+	assert.Same(t, n0, tree.root)
+}
+
+func TestNMergeStealingFromLeftSiblingUpdatesParentOfMovedChild(t *testing.T) {
+	// Arrange
+	tree, n0, leaves := setupInteriorSiblings([]uint32{10, 20}, []uint32{})
+	left, right := n0.children[0].node, n0.children[1].node
+
+	// Act
+	right.nmerge()
+
+	// Assert
+	assert.Equal(t, []uint32{10}, left.keys)
+	assert.Equal(t, []uint32{30}, right.keys)
+	assert.Equal(t, []uint32{20}, n0.keys)
+	assert.Same(t, left, leaves[1].parent)
+	assert.Same(t, right, leaves[2].parent)
+	assert.Same(t, n0, tree.root)
+}
+
+func TestNMergeOfInteriorNodesUpdatesParentOfChildren(t *testing.T) {
+	// Arrange
+	tree, n0, leaves := setupInteriorSiblings([]uint32{}, []uint32{40})
+	left := n0.children[0].node
+
+	// Act
+	left.nmerge()
+
+	// Assert
+	root := tree.root
+	assert.Equal(t, []uint32{30, 40}, root.keys)
+	assert.Nil(t, root.parent)
+	for _, leaf := range leaves {
+		assert.Same(t, root, leaf.parent)
+	}
+}
+
+// setupInteriorSiblings returns a tree whose root has two interior children separated by the key
+// 30, with the given keys and a leaf per interval, and the leaves in order.
+func setupInteriorSiblings(leftKeys []uint32, rightKeys []uint32) (*SegmentTreeImpl[Float, uint32], *Node[Float, uint32], []*Node[Float, uint32]) {
+	tree := &SegmentTreeImpl[Float, uint32]{
+		aggregate:       SumAggregate[Float](),
+		branchingFactor: 4,
+	}
+	n0 := &Node[Float, uint32]{
+		keys:   []uint32{30},
+		values: []Float{Float(0), Float(0)},
+		tree:   tree,
+	}
+	leaves := []*Node[Float, uint32]{}
+
+	for _, keys := range [][]uint32{leftKeys, rightKeys} {
+		node := &Node[Float, uint32]{
+			keys:   keys,
+			values: make([]Float, len(keys)+1),
+			parent: n0,
+			tree:   tree,
+		}
+		for range node.values {
+			leaf := &Node[Float, uint32]{
+				keys:   []uint32{},
+				values: []Float{Float(0)},
+				parent: node,
+				isLeaf: true,
+				tree:   tree,
+			}
+			node.children = append(node.children, link[Float, uint32]{node: leaf})
+			leaves = append(leaves, leaf)
+		}
+		n0.children = append(n0.children, link[Float, uint32]{node: node})
+	}
+	tree.root = n0
+
+	setBounds(n0)
+
+	return tree, n0, leaves
+}
+
+// Checks the bounds kept by every node, including the nodes of older versions, while the trees
+// are split and merged by inserts and deletes.
+func TestNodeBoundsAfterChurn(t *testing.T) {
+	// Arrange
+	random := rand.New(rand.NewSource(1))
+	persistent := NewPersistentSegmentTree[Float, uint32](4, SumAggregate[Float]())
+	trees := map[string]*SegmentTreeImpl[Float, uint32]{
+		"in-memory": persistent.tree,
+		"paged":     must(NewPagedSegmentTree[Float, uint32](4, SumAggregate[Float](), NewMemoryNodeStore[Float, uint32](), 2)),
+	}
+	inserted := []ValueIntervalTuple[Float, uint32]{}
+
+	for step := 0; step < 400; step++ {
+		// Act
+		if len(inserted) > 0 && random.Intn(3) == 0 {
+			i := random.Intn(len(inserted))
+			persistent.Delete(inserted[i])
+			trees["paged"].Delete(inserted[i])
+			inserted = append(inserted[:i], inserted[i+1:]...)
+		} else {
+			start := uint32(random.Intn(200))
+			tuple := NewValueIntervalTuple(Float(random.Intn(20)), NewInterval(start, start+1+uint32(random.Intn(40))))
+			persistent.Insert(tuple)
+			trees["paged"].Insert(tuple)
+			inserted = append(inserted, tuple)
+		}
+
+		// Assert
+		for name, tree := range trees {
+			tree.run(false, func() error {
+				assertBounds(t, tree.root, NewInterval(0, MaxInstant[uint32]()), name)
+				return nil
+			})
+		}
+	}
+	for version, root := range persistent.roots {
+		assertBounds(t, root, NewInterval(0, MaxInstant[uint32]()), version)
+	}
+
+	var buffer bytes.Buffer
+	persistent.tree.WriteTo(&buffer)
+	loaded := NewSegmentTree[Float, uint32](4, SumAggregate[Float]())
+	loaded.ReadFrom(&buffer)
+	assertBounds(t, loaded.root, NewInterval(0, MaxInstant[uint32]()), "snapshot")
+}
+
+// setBounds sets the bounds of the nodes of a tree built by hand, as the tree does for the nodes
+// it creates.
+func setBounds[V Addable[V], T Timestamp](root *Node[V, T]) {
+	var set func(node *Node[V, T], bounds Interval[T])
+	set = func(node *Node[V, T], bounds Interval[T]) {
+		node.bounds = bounds
+		for index, child := range node.children {
+			if child.node != nil {
+				set(child.node, node.getIntervalWithin(bounds, uint32(index)))
+			}
+		}
+	}
+
+	set(root, NewInterval(0, MaxInstant[T]()))
+}
+
+// assertBounds checks the bounds kept by the node and its descendants against the bounds found
+// from the root.
+func assertBounds[V Addable[V], T Timestamp](t *testing.T, node *Node[V, T], bounds Interval[T], msgAndArgs ...interface{}) {
+	assert.Equal(t, bounds, node.bounds, msgAndArgs...)
+
+	if !node.isLeaf {
+		for index, interval := range node.getIntervalsWithin(bounds) {
+			child := must(node.child(index))
+			assertBounds(t, child, interval, msgAndArgs...)
+			child.unpin()
+		}
+	}
+}
+
+func SetupNodes() (*Node[Float, uint32], *Node[Float, uint32], *Node[Float, uint32], *Node[Float, uint32], *Node[Float, uint32]) {
+	n0 := &Node[Float, uint32]{
 		keys:     []uint32{15, 30, 45},
-		children: make([]*Node, 5),
+		children: make([]link[Float, uint32], 5),
 	}
 
-	n1 := &Node{
+	n1 := &Node[Float, uint32]{
 		keys:   []uint32{5, 10},
 		parent: n0,
 	}
 
-	n2 := &Node{
+	n2 := &Node[Float, uint32]{
 		keys:   []uint32{20},
 		parent: n0,
 	}
 
-	n3 := &Node{
+	n3 := &Node[Float, uint32]{
 		keys:   []uint32{35, 40},
 		parent: n0,
 	}
 
-	n4 := &Node{
+	n4 := &Node[Float, uint32]{
 		keys:   []uint32{50},
 		parent: n0,
 	}
 
-	n0.children = []*Node{n1, n2, n3, n4}
+	n0.children = []link[Float, uint32]{{node: n1}, {node: n2}, {node: n3}, {node: n4}}
+	setBounds(n0)
+
 	return n0, n1, n2, n3, n4
 }
@@ -0,0 +1,114 @@
+package segmenttree
+
+import "errors"
+
+// PageID identifies a node within a NodeStore. The zero value refers to no page.
+type PageID uint32
+
+const NoPage PageID = 0
+
+var ErrPageNotFound = errors.New("page not found")
+
+// NodePage is the representation of a node within a NodeStore. Instead of
+// pointers, children are referenced by their page id. The parent is not stored,
+// as it is known when a node is loaded from its parent.
+type NodePage[V Addable[V], T Timestamp] struct {
+	Keys     []T
+	Values   []V
+	Children []PageID
+	IsLeaf   bool
+}
+
+// NodeStore persists the nodes of a segment tree page by page.
+// It is accessed through a BufferPool, which keeps the recently used nodes in memory.
+type NodeStore[V Addable[V], T Timestamp] interface {
+	Allocate() (PageID, error)
+	Read(id PageID) (NodePage[V, T], error)
+	Write(id PageID, page NodePage[V, T]) error
+	Free(id PageID) error
+	// Root returns the page of the root node, or NoPage if the store is empty.
+	Root() PageID
+	SetRoot(id PageID) error
+	// Flush makes all written pages durable.
+	Flush() error
+	Close() error
+}
+
+// MemoryNodeStore keeps all pages in memory. It is mainly useful for testing.
+type MemoryNodeStore[V Addable[V], T Timestamp] struct {
+	pages    map[PageID]NodePage[V, T]
+	freeList []PageID
+	nextID   PageID
+	root     PageID
+}
+
+func NewMemoryNodeStore[V Addable[V], T Timestamp]() *MemoryNodeStore[V, T] {
+	return &MemoryNodeStore[V, T]{
+		pages:  make(map[PageID]NodePage[V, T]),
+		nextID: 1,
+	}
+}
+
+func (store *MemoryNodeStore[V, T]) Allocate() (PageID, error) {
+	if len(store.freeList) > 0 {
+		id := store.freeList[len(store.freeList)-1]
+		store.freeList = store.freeList[:len(store.freeList)-1]
+		return id, nil
+	}
+
+	id := store.nextID
+	store.nextID++
+
+	return id, nil
+}
+
+func (store *MemoryNodeStore[V, T]) Read(id PageID) (NodePage[V, T], error) {
+	page, ok := store.pages[id]
+	if !ok {
+		return NodePage[V, T]{}, ErrPageNotFound
+	}
+
+	return copyNodePage(page), nil
+}
+
+func (store *MemoryNodeStore[V, T]) Write(id PageID, page NodePage[V, T]) error {
+	store.pages[id] = copyNodePage(page)
+	return nil
+}
+
+func (store *MemoryNodeStore[V, T]) Free(id PageID) error {
+	delete(store.pages, id)
+	store.freeList = append(store.freeList, id)
+	return nil
+}
+
+func (store *MemoryNodeStore[V, T]) Root() PageID {
+	return store.root
+}
+
+func (store *MemoryNodeStore[V, T]) SetRoot(id PageID) error {
+	store.root = id
+	return nil
+}
+
+func (store *MemoryNodeStore[V, T]) Flush() error {
+	return nil
+}
+
+func (store *MemoryNodeStore[V, T]) Close() error {
+	return nil
+}
+
+// PageCount returns the number of pages currently in use.
+func (store *MemoryNodeStore[V, T]) PageCount() int {
+	return len(store.pages)
+}
+
+func copyNodePage[V Addable[V], T Timestamp](page NodePage[V, T]) NodePage[V, T] {
+	return NodePage[V, T]{
+		Keys:     append([]T{}, page.Keys...),
+		Values:   append([]V{}, page.Values...),
+		Children: append([]PageID{}, page.Children...),
+		IsLeaf:   page.IsLeaf,
+	}
+}
@@ -2,31 +2,31 @@ package segmenttree
 
 import "math"
 
-func Sum(x Addable, y Addable) Addable {
+func Sum[V Addable[V]](x V, y V) V {
 	return x.Add(y)
 }
 
-func InverseSum(x Addable, y Addable) Addable {
+func InverseSum[V Addable[V]](x V, y V) V {
 	return x.Subtract(y)
 }
 
-func Count(x Addable, y Addable) Addable {
+func Count[V Addable[V]](x V, y V) V {
 	return x.Add(y)
 }
 
-func InverseCount(x Addable, y Addable) Addable {
+func InverseCount[V Addable[V]](x V, y V) V {
 	return x.Subtract(y)
 }
 
-func Average(x Addable, y Addable) Addable {
+func Average[V Addable[V]](x V, y V) V {
 	return x.Add(y)
 }
 
-func InverseAverage(x Addable, y Addable) Addable {
+func InverseAverage[V Addable[V]](x V, y V) V {
 	return x.Subtract(y)
 }
 
-func Min(x Comparable, y Comparable) Comparable {
+func Min[V Comparable[V]](x V, y V) V {
 	res := x.Compare(y)
 
 	if res < 0 {
@@ -36,7 +36,7 @@ func Min(x Comparable, y Comparable) Comparable {
 	return y
 }
 
-func Max(x Comparable, y Comparable) Comparable {
+func Max[V Comparable[V]](x V, y V) V {
 	res := x.Compare(y)
 	if res > 0 {
 		return x
@@ -45,40 +45,90 @@ func Max(x Comparable, y Comparable) Comparable {
 	return y
 }
 
-func Identity(v Addable) Addable {
+func Identity[V any](v V) V {
 	return v
 }
 
-type Comparable interface {
-	Compare(x Comparable) int
+type Comparable[V any] interface {
+	Compare(x V) int
 }
 
-type Addable interface {
-	Add(x Addable) Addable
-	Inverse() Addable
-	Subtract(x Addable) Addable
+// Addable is the constraint for values stored in a segment tree. V is the
+// implementing type itself, so that e.g. Float only ever adds other Floats.
+type Addable[V any] interface {
+	comparable
+	Add(x V) V
+	Inverse() V
+	Subtract(x V) V
 	AsFloat64() float64
 }
 
+// Linear is implemented by value types for which x.Add(y).AsFloat64() equals
+// x.AsFloat64() + y.AsFloat64(), e.g. Float, but not AverageTuple.
+type Linear interface {
+	IsLinear() bool
+}
+
+// Equatable is implemented by value types whose values are compared with a tolerance, such as
+// Float, for which deleting a tuple rarely cancels its insert exactly. The tree compares values
+// with Equal to merge adjacent intervals, to skip inserting a value which does not change a piece
+// and to recognize a value cancelling another one, e.g. when deleting a tuple (see
+// Aggregate.combine), and with == for value types which do not implement it. So a value changing a
+// piece by less than the tolerance is dropped, while a value added to the neutral element is kept,
+// however small it is. A different tolerance requires a value type of its own.
+type Equatable[V any] interface {
+	Equal(y V) bool
+}
+
+// FloatTolerance is the tolerance of Float.Equal and Float64Tolerance the one of Float64.Equal,
+// relative to the larger magnitude of both values. They are multiples of the machine epsilon of
+// float32 and float64, so that the rounding errors of a few operations are tolerated, but no
+// value added to another one with a magnitude of up to about 10^5 (Float) or 10^12 (Float64) times
+// its own.
+const (
+	FloatTolerance   = 32 * 0x1p-23
+	Float64Tolerance = 4096 * 0x1p-52
+)
+
+func equalValues[V Addable[V]](x V, y V) bool {
+	if equatable, ok := any(x).(Equatable[V]); ok {
+		return equatable.Equal(y)
+	}
+
+	return x == y
+}
+
+func equalWithin(x float64, y float64, tolerance float64) bool {
+	if x == y {
+		return true
+	}
+	// Infinite values, e.g. the neutral element of max, are only equal to themselves
+	if math.IsInf(x, 0) || math.IsInf(y, 0) {
+		return false
+	}
+
+	return math.Abs(x-y) <= tolerance*math.Max(math.Abs(x), math.Abs(y))
+}
+
 type AverageTuple struct {
 	Sum   int
 	Count int
 }
 
-func (x AverageTuple) Add(y Addable) Addable {
+func (x AverageTuple) Add(y AverageTuple) AverageTuple {
 	return AverageTuple{
-		Sum:   x.Sum + y.(AverageTuple).Sum,
-		Count: x.Count + y.(AverageTuple).Count,
+		Sum:   x.Sum + y.Sum,
+		Count: x.Count + y.Count,
 	}
 }
 
-func (x AverageTuple) Subtract(y Addable) Addable {
+func (x AverageTuple) Subtract(y AverageTuple) AverageTuple {
 	return AverageTuple{
-		Sum:   x.Sum - y.(AverageTuple).Sum,
-		Count: x.Count - y.(AverageTuple).Count,
+		Sum:   x.Sum - y.Sum,
+		Count: x.Count - y.Count,
 	}
 }
-func (x AverageTuple) Inverse() Addable {
+func (x AverageTuple) Inverse() AverageTuple {
 	return AverageTuple{
 		Sum:   -x.Sum,
 		Count: -1,
@@ -93,24 +143,139 @@ func (x AverageTuple) AsFloat64() float64 {
 	return float64(x.Sum) / float64(x.Count)
 }
 
+// MomentsTuple holds the count, sum and sum of squares of values, from which their mean, variance
+// and standard deviation follow.
+//
+// Every sum is kept together with its rounding error (compensated summation), so that inserting
+// and deleting many values does not accumulate rounding errors, and the variance is computed
+// from these without cancellation.
+type MomentsTuple struct {
+	Count        int
+	Sum          float64
+	SumOfSquares float64
+
+	sumError          float64
+	sumOfSquaresError float64
+}
+
+// NewMomentsTuple returns the moments of the single value.
+func NewMomentsTuple(value float64) MomentsTuple {
+	square, squareError := twoProduct(value, value)
+
+	return MomentsTuple{
+		Count:             1,
+		Sum:               value,
+		SumOfSquares:      square,
+		sumOfSquaresError: squareError,
+	}
+}
+
+func (x MomentsTuple) Add(y MomentsTuple) MomentsTuple {
+	result := MomentsTuple{Count: x.Count + y.Count}
+	result.Sum, result.sumError = addCompensated(x.Sum, x.sumError, y.Sum, y.sumError)
+	result.SumOfSquares, result.sumOfSquaresError = addCompensated(x.SumOfSquares, x.sumOfSquaresError, y.SumOfSquares, y.sumOfSquaresError)
+
+	return result
+}
+
+func (x MomentsTuple) Subtract(y MomentsTuple) MomentsTuple {
+	return x.Add(y.Inverse())
+}
+
+func (x MomentsTuple) Inverse() MomentsTuple {
+	return MomentsTuple{
+		Count:             -x.Count,
+		Sum:               -x.Sum,
+		SumOfSquares:      -x.SumOfSquares,
+		sumError:          -x.sumError,
+		sumOfSquaresError: -x.sumOfSquaresError,
+	}
+}
+
+// AsFloat64 returns the variance.
+func (x MomentsTuple) AsFloat64() float64 {
+	return x.Variance()
+}
+
+func (x MomentsTuple) Mean() float64 {
+	if x.Count <= 0 {
+		return math.NaN()
+	}
+
+	return (x.Sum + x.sumError) / float64(x.Count)
+}
+
+// Variance returns the population variance of the values, or NaN if there are none.
+func (x MomentsTuple) Variance() float64 {
+	if x.Count <= 0 {
+		return math.NaN()
+	}
+
+	// The variance is (n * SumOfSquares - Sum²) / n². Both terms are computed with their
+	// rounding errors, as they are almost equal for values with a small variance.
+	n := float64(x.Count)
+	scaled, scaledError := twoProduct(n, x.SumOfSquares)
+	scaledError += n * x.sumOfSquaresError
+	square, squareError := twoProduct(x.Sum, x.Sum)
+	squareError += 2 * x.Sum * x.sumError
+
+	difference, differenceError := twoSum(scaled, -square)
+	difference += differenceError + scaledError - squareError
+
+	return math.Max(difference, 0) / (n * n)
+}
+
+func (x MomentsTuple) StandardDeviation() float64 {
+	return math.Sqrt(x.Variance())
+}
+
+// addCompensated adds two sums given with their rounding errors.
+func addCompensated(x float64, xError float64, y float64, yError float64) (float64, float64) {
+	sum, sumError := twoSum(x, y)
+	return twoSum(sum, sumError+xError+yError)
+}
+
+// twoSum returns x + y and its rounding error.
+func twoSum(x float64, y float64) (float64, float64) {
+	sum := x + y
+	yPart := sum - x
+	xPart := sum - yPart
+
+	return sum, (x - xPart) + (y - yPart)
+}
+
+// twoProduct returns x * y and its rounding error.
+func twoProduct(x float64, y float64) (float64, float64) {
+	product := x * y
+	return product, math.FMA(x, y, -product)
+}
+
 type Float float32
 
-func (x Float) Add(y Addable) Addable {
-	return x + y.(Float)
+func (x Float) Add(y Float) Float {
+	return x + y
 }
 
-func (x Float) Inverse() Addable {
+func (x Float) Inverse() Float {
 	return -x
 }
 
-func (x Float) Subtract(y Addable) Addable {
-	return x - y.(Float)
+func (x Float) Subtract(y Float) Float {
+	return x - y
 }
 
 func (x Float) AsFloat64() float64 {
 	return float64(x)
 }
 
+func (x Float) IsLinear() bool {
+	return true
+}
+
+func (x Float) Equal(y Float) bool {
+	return equalWithin(float64(x), float64(y), FloatTolerance)
+}
+
 func (x Float) Compare(y Float) int {
 	if x > y {
 		return 1
@@ -121,3 +286,77 @@ func (x Float) Compare(y Float) int {
 
 	return 0
 }
+
+// Float64 is a float with double precision. Like Float, sums of many values are not exact, but
+// their rounding errors are much smaller.
+type Float64 float64
+
+func (x Float64) Add(y Float64) Float64 {
+	return x + y
+}
+
+func (x Float64) Inverse() Float64 {
+	return -x
+}
+
+func (x Float64) Subtract(y Float64) Float64 {
+	return x - y
+}
+
+func (x Float64) AsFloat64() float64 {
+	return float64(x)
+}
+
+func (x Float64) IsLinear() bool {
+	return true
+}
+
+func (x Float64) Equal(y Float64) bool {
+	return equalWithin(float64(x), float64(y), Float64Tolerance)
+}
+
+func (x Float64) Compare(y Float64) int {
+	if x > y {
+		return 1
+	}
+	if x < y {
+		return -1
+	}
+
+	return 0
+}
+
+// Int64 is an integer value. Sums of Int64 values are exact, so that intervals whose values
+// cancel each other out are merged again.
+type Int64 int64
+
+func (x Int64) Add(y Int64) Int64 {
+	return x + y
+}
+
+func (x Int64) Inverse() Int64 {
+	return -x
+}
+
+func (x Int64) Subtract(y Int64) Int64 {
+	return x - y
+}
+
+func (x Int64) AsFloat64() float64 {
+	return float64(x)
+}
+
+func (x Int64) IsLinear() bool {
+	return true
+}
+
+func (x Int64) Compare(y Int64) int {
+	if x > y {
+		return 1
+	}
+	if x < y {
+		return -1
+	}
+
+	return 0
+}
@@ -0,0 +1,109 @@
+package segmenttree
+
+import "errors"
+
+var ErrUnknownVersion = errors.New("unknown version")
+
+// PersistentSegmentTree keeps every version of a segment tree queryable.
+//
+// Insert and Delete do not modify nodes in place, but copy the nodes on the paths they touch
+// (see SegmentTreeImpl.copyOnWrite). All other nodes are shared with the previous version.
+// Every operation creates a new version, starting with version 0 for the empty tree.
+type PersistentSegmentTree[V Addable[V], T Timestamp] struct {
+	tree *SegmentTreeImpl[V, T]
+	// roots holds the root of every version, indexed by the version
+	roots []*Node[V, T]
+}
+
+// TreeSnapshot is a read-only view of one version of a persistent tree.
+type TreeSnapshot[V Addable[V], T Timestamp] struct {
+	tree    *SegmentTreeImpl[V, T]
+	root    *Node[V, T]
+	version uint64
+}
+
+func NewPersistentSegmentTree[V Addable[V], T Timestamp](branchingFactor uint32, aggregate Aggregate[V]) *PersistentSegmentTree[V, T] {
+	tree := NewSegmentTree[V, T](branchingFactor, aggregate)
+	tree.copyOnWrite = true
+
+	return &PersistentSegmentTree[V, T]{
+		tree:  tree,
+		roots: []*Node[V, T]{tree.root},
+	}
+}
+
+// Insert inserts the value and returns the new version. If it fails, no version is created.
+func (persistent *PersistentSegmentTree[V, T]) Insert(value ValueIntervalTuple[V, T]) (uint64, error) {
+	return persistent.commit(persistent.tree.Insert(value))
+}
+
+// Delete deletes the value and returns the new version. If it fails, no version is created.
+func (persistent *PersistentSegmentTree[V, T]) Delete(value ValueIntervalTuple[V, T]) (uint64, error) {
+	return persistent.commit(persistent.tree.Delete(value))
+}
+
+// InsertRange inserts all values into the empty tree and returns the new version.
+func (persistent *PersistentSegmentTree[V, T]) InsertRange(values []ValueIntervalTuple[V, T]) (uint64, error) {
+	return persistent.commit(persistent.tree.InsertRange(values))
+}
+
+// Version returns the current version.
+func (persistent *PersistentSegmentTree[V, T]) Version() uint64 {
+	return uint64(len(persistent.roots) - 1)
+}
+
+// Snapshot returns a view of the current version, which stays unchanged by later operations.
+func (persistent *PersistentSegmentTree[V, T]) Snapshot() TreeSnapshot[V, T] {
+	snapshot, _ := persistent.AtVersion(persistent.Version())
+	return snapshot
+}
+
+// AtVersion returns a view of the given version.
+func (persistent *PersistentSegmentTree[V, T]) AtVersion(version uint64) (TreeSnapshot[V, T], error) {
+	if version >= uint64(len(persistent.roots)) {
+		return TreeSnapshot[V, T]{}, ErrUnknownVersion
+	}
+
+	return TreeSnapshot[V, T]{
+		tree:    persistent.tree,
+		root:    persistent.roots[version],
+		version: version,
+	}, nil
+}
+
+func (persistent *PersistentSegmentTree[V, T]) GetAtInstant(instant T) (V, error) {
+	return persistent.Snapshot().GetAtInstant(instant)
+}
+
+func (persistent *PersistentSegmentTree[V, T]) GetWithinInterval(interval Interval[T]) ([]ValueIntervalTuple[V, T], error) {
+	return persistent.Snapshot().GetWithinInterval(interval)
+}
+
+func (persistent *PersistentSegmentTree[V, T]) Integrate(interval Interval[T]) (float64, error) {
+	return persistent.Snapshot().Integrate(interval)
+}
+
+func (persistent *PersistentSegmentTree[V, T]) commit(err error) (uint64, error) {
+	if err != nil {
+		return persistent.Version(), err
+	}
+
+	persistent.roots = append(persistent.roots, persistent.tree.root)
+	return persistent.Version(), nil
+}
+
+func (snapshot TreeSnapshot[V, T]) Version() uint64 {
+	return snapshot.version
+}
+
+func (snapshot TreeSnapshot[V, T]) GetAtInstant(instant T) (V, error) {
+	return snapshot.tree.lookupFrom(snapshot.root, instant)
+}
+
+func (snapshot TreeSnapshot[V, T]) GetWithinInterval(interval Interval[T]) ([]ValueIntervalTuple[V, T], error) {
+	return snapshot.tree.rangeQueryFrom(snapshot.root, interval)
+}
+
+func (snapshot TreeSnapshot[V, T]) Integrate(interval Interval[T]) (float64, error) {
+	return snapshot.tree.integrateFrom(snapshot.root, interval)
+}
@@ -0,0 +1,169 @@
+package segmenttree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistentTreeKeepsVersionBeforeCorrections(t *testing.T) {
+	// Arrange
+	tree := NewPersistentSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+	beforeCorrections := tree.Snapshot()
+
+	// Act
+	tree.Delete(dosageTestData[Float]()[1])
+	version := must(tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](10, 30), value: Float(1)}))
+
+	// Assert
+	assert.Equal(t, uint64(6), beforeCorrections.Version())
+	assert.Equal(t, uint64(8), version)
+	for _, td := range testDataGetAtInstant {
+		assert.Equal(t, td.expectedValue, must(beforeCorrections.GetAtInstant(td.instant)), "instant %d", td.instant)
+	}
+	assert.Equal(t, Float(4), must(tree.GetAtInstant(19)))
+	assert.Equal(t, Float(6), must(beforeCorrections.GetAtInstant(19)))
+}
+
+func TestPersistentTreeAtVersion(t *testing.T) {
+	// Arrange
+	tree := NewPersistentSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+
+	// Act
+	empty, emptyErr := tree.AtVersion(0)
+	first, firstErr := tree.AtVersion(1)
+	_, unknownErr := tree.AtVersion(7)
+
+	// Assert
+	assert.NoError(t, emptyErr)
+	assert.NoError(t, firstErr)
+	assert.ErrorIs(t, unknownErr, ErrUnknownVersion)
+	assert.Equal(t, []ValueIntervalTuple[Float, uint32]{{interval: NewInterval[uint32](0, 60), value: Float(0)}}, must(empty.GetWithinInterval(NewInterval[uint32](0, 60))))
+	assert.Equal(t, []ValueIntervalTuple[Float, uint32]{
+		{interval: NewInterval[uint32](0, 10), value: Float(0)},
+		{interval: NewInterval[uint32](10, 40), value: Float(2)},
+		{interval: NewInterval[uint32](40, 60), value: Float(0)},
+	}, must(first.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
+
+func TestPersistentTreeEveryVersionMatchesReference(t *testing.T) {
+	forEachValueType(t, testPersistentTreeEveryVersionMatchesReference[Float], testPersistentTreeEveryVersionMatchesReference[Float64], testPersistentTreeEveryVersionMatchesReference[Int64], testPersistentTreeEveryVersionMatchesReference[Decimal])
+}
+
+func testPersistentTreeEveryVersionMatchesReference[V scalarValue[V]](t *testing.T) {
+	aggregates := map[string]Aggregate[V]{
+		"sum": SumAggregate[V](),
+		"max": MaxAggregate(scalar[V](math.Inf(-1))),
+	}
+
+	for name, aggregate := range aggregates {
+		// Arrange
+		random := rand.New(rand.NewSource(1))
+		tree := NewPersistentSegmentTree[V, uint32](BRANCHING_FACTOR, aggregate)
+		reference := NewSegmentTree[V, uint32](BRANCHING_FACTOR, aggregate)
+		expected := [][]ValueIntervalTuple[V, uint32]{must(reference.GetWithinInterval(NewInterval[uint32](0, 120)))}
+		inserted := []ValueIntervalTuple[V, uint32]{}
+
+		// Act
+		for step := 0; step < 150; step++ {
+			if len(inserted) > 0 && random.Intn(3) == 0 {
+				i := random.Intn(len(inserted))
+				tree.Delete(inserted[i])
+				reference.Delete(inserted[i])
+				inserted = append(inserted[:i], inserted[i+1:]...)
+			} else {
+				start := uint32(random.Intn(100))
+				tuple := ValueIntervalTuple[V, uint32]{
+					value:    scalar[V](random.Intn(5) + 1),
+					interval: NewInterval(start, start+1+uint32(random.Intn(10))),
+				}
+				tree.Insert(tuple)
+				reference.Insert(tuple)
+				inserted = append(inserted, tuple)
+			}
+			expected = append(expected, must(reference.GetWithinInterval(NewInterval[uint32](0, 120))))
+		}
+
+		// Assert
+		for version, pieces := range expected {
+			snapshot, err := tree.AtVersion(uint64(version))
+			assert.NoError(t, err)
+			if !assert.Equal(t, pieces, must(snapshot.GetWithinInterval(NewInterval[uint32](0, 120))), "%s, version %d", name, version) {
+				return
+			}
+		}
+	}
+}
+
+func TestPersistentTreeCopiesOnlyTouchedNodes(t *testing.T) {
+	// Arrange
+	random := rand.New(rand.NewSource(1))
+	tree := NewPersistentSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	for i := 0; i < 500; i++ {
+		start := uint32(random.Intn(10000))
+		tree.Insert(ValueIntervalTuple[Float, uint32]{
+			value:    Float(random.Intn(5) + 1),
+			interval: NewInterval(start, start+1+uint32(random.Intn(10))),
+		})
+	}
+	before := tree.Snapshot()
+
+	// Act
+	tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](5000, 5001), value: Float(1)})
+
+	// Assert
+	oldNodes := map[*Node[Float, uint32]]bool{}
+	collectNodes(before.root, oldNodes)
+	newNodes := map[*Node[Float, uint32]]bool{}
+	collectNodes(tree.Snapshot().root, newNodes)
+
+	copied := 0
+	for node := range newNodes {
+		if !oldNodes[node] {
+			copied++
+		}
+	}
+	height := 0
+	for node := tree.Snapshot().root; !node.isLeaf; node = node.children[0].node {
+		height++
+	}
+
+	assert.Greater(t, len(newNodes), 100)
+	assert.LessOrEqual(t, copied, 3*(height+1))
+}
+
+func TestPersistentTreeFailedInsertCreatesNoVersion(t *testing.T) {
+	// Arrange
+	tree := NewPersistentSegmentTree[Float, uint32](BRANCHING_FACTOR, rejectingSumAggregate(99))
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+
+	// Act
+	_, err := tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](3, 62), value: Float(99)})
+	version := must(tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](0, 60), value: Float(1)}))
+
+	// Assert
+	assert.ErrorIs(t, err, ErrCorruptTree)
+	assert.Equal(t, uint64(7), version)
+	assert.Equal(t, Float(8), must(must(tree.AtVersion(6)).GetAtInstant(12)))
+	assert.Equal(t, Float(9), must(tree.GetAtInstant(12)))
+}
+
+func collectNodes[V Addable[V], T Timestamp](node *Node[V, T], nodes map[*Node[V, T]]bool) {
+	nodes[node] = true
+
+	if !node.isLeaf {
+		for _, child := range node.children {
+			collectNodes(child.node, nodes)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package segmenttree_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"segmenttree/segmenttree"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The tests in this file only use the exported API of the package.
+
+func dosages() []segmenttree.ValueIntervalTuple[segmenttree.Float, uint32] {
+	return []segmenttree.ValueIntervalTuple[segmenttree.Float, uint32]{
+		segmenttree.NewValueIntervalTuple(segmenttree.Float(2), segmenttree.NewInterval[uint32](10, 40)),
+		segmenttree.NewValueIntervalTuple(segmenttree.Float(3), segmenttree.NewInterval[uint32](10, 30)),
+		segmenttree.NewValueIntervalTuple(segmenttree.Float(1), segmenttree.NewInterval[uint32](20, 40)),
+		segmenttree.NewValueIntervalTuple(segmenttree.Float(2), segmenttree.NewInterval[uint32](5, 15)),
+		segmenttree.NewValueIntervalTuple(segmenttree.Float(4), segmenttree.NewInterval[uint32](35, 45)),
+		segmenttree.NewValueIntervalTuple(segmenttree.Float(1), segmenttree.NewInterval[uint32](10, 50)),
+	}
+}
+
+func TestPublicSumAggregate(t *testing.T) {
+	// Arrange
+	tree := segmenttree.NewSegmentTree[segmenttree.Float, uint32](4, segmenttree.SumAggregate[segmenttree.Float]())
+
+	// Act
+	for _, tuple := range dosages() {
+		tree.Insert(tuple)
+	}
+	result, err := tree.GetWithinInterval(segmenttree.NewInterval[uint32](40, 60))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, result, 3)
+	assert.Equal(t, segmenttree.Float(5), result[0].GetValue())
+	assert.Equal(t, uint32(40), result[0].GetInterval().GetStart())
+	assert.Equal(t, uint32(45), result[0].GetInterval().GetEnd())
+	assert.Equal(t, segmenttree.Float(1), result[1].GetValue())
+	assert.Equal(t, segmenttree.NewInterval[uint32](45, 50), result[1].GetInterval())
+	assert.Equal(t, segmenttree.Float(0), result[2].GetValue())
+	assert.Equal(t, uint32(10), result[2].GetInterval().GetLength())
+}
+
+func TestPublicCountAggregate(t *testing.T) {
+	// Arrange
+	tree := segmenttree.NewSegmentTree[segmenttree.Float, uint32](4, segmenttree.CountAggregate())
+
+	// Act
+	insertErr := tree.InsertRange(dosages())
+	deleteErr := tree.Delete(dosages()[0])
+
+	// Assert
+	assert.NoError(t, insertErr)
+	assert.NoError(t, deleteErr)
+	for instant, expected := range map[uint32]segmenttree.Float{0: 0, 12: 3, 25: 3, 37: 3, 49: 1} {
+		value, err := tree.GetAtInstant(instant)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, value, "instant %d", instant)
+	}
+}
+
+func TestPublicAverageAggregate(t *testing.T) {
+	// Arrange
+	tree := segmenttree.NewSegmentTree[segmenttree.AverageTuple, uint32](4, segmenttree.AverageAggregate())
+
+	// Act
+	for _, tuple := range dosages() {
+		value := segmenttree.AverageTuple{Sum: int(tuple.GetValue())}
+		assert.NoError(t, tree.Insert(segmenttree.NewValueIntervalTuple(value, tuple.GetInterval())))
+	}
+	empty, _ := tree.GetAtInstant(0)
+	overlapping, _ := tree.GetAtInstant(25)
+	last, _ := tree.GetAtInstant(42)
+
+	// Assert
+	assert.True(t, math.IsNaN(empty.AsFloat64()))
+	assert.Equal(t, 1.75, overlapping.AsFloat64())
+	assert.Equal(t, 2.5, last.AsFloat64())
+}
+
+func TestPublicCustomAggregate(t *testing.T) {
+	// Arrange
+	double := func(value segmenttree.Float) segmenttree.Float { return 2 * value }
+	aggregate := segmenttree.NewAggregate(segmenttree.Sum[segmenttree.Float], segmenttree.InverseSum[segmenttree.Float], double, segmenttree.Float(0))
+	tree := segmenttree.NewSegmentTree[segmenttree.Float, uint32](4, aggregate)
+
+	// Act
+	err := tree.InsertRange(dosages())
+	value, _ := tree.GetAtInstant(12)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, segmenttree.Float(0), aggregate.GetNeutralElement())
+	assert.NotNil(t, aggregate.GetInverseOperation())
+	assert.Equal(t, segmenttree.Float(5), aggregate.GetOperation()(2, 3))
+	assert.Equal(t, segmenttree.Float(6), aggregate.GetAdditionElement()(3))
+	assert.Equal(t, segmenttree.Float(16), value)
+}
+
+func TestPublicBitemporalTree(t *testing.T) {
+	// Arrange
+	tree := segmenttree.NewBitemporalTree[segmenttree.Float, uint32](4, segmenttree.SumAggregate[segmenttree.Float]())
+	tuple := segmenttree.NewValueIntervalTuple(segmenttree.Float(2), segmenttree.NewInterval[uint32](10, 40))
+
+	// Act
+	insertErr := tree.Insert(tuple, 100)
+	deleteErr := tree.Delete(tuple, 200)
+	facts := tree.Facts()
+
+	// Assert
+	assert.NoError(t, insertErr)
+	assert.NoError(t, deleteErr)
+	assert.Len(t, facts, 1)
+	assert.Equal(t, segmenttree.Float(2), facts[0].GetValue())
+	assert.Equal(t, segmenttree.NewInterval[uint32](10, 40), facts[0].GetValidInterval())
+	assert.Equal(t, segmenttree.NewInterval[uint32](100, 200), facts[0].GetTransactionInterval())
+}
+
+func ExampleSegmentTreeImpl_Integrate() {
+	tree := segmenttree.NewSegmentTree[segmenttree.Float, uint32](4, segmenttree.SumAggregate[segmenttree.Float]())
+	tree.InsertRange(dosages())
+
+	pieces, _ := tree.GetWithinInterval(segmenttree.NewInterval[uint32](0, 20))
+	for _, piece := range pieces {
+		fmt.Println(piece.GetInterval().GetStart(), piece.GetInterval().GetEnd(), piece.GetValue())
+	}
+
+	exposure, _ := tree.Integrate(segmenttree.NewInterval[uint32](0, 60))
+	fmt.Println("exposure:", exposure)
+
+	// Output:
+	// 0 5 0
+	// 5 10 2
+	// 10 15 8
+	// 15 20 6
+	// exposure: 240
+}
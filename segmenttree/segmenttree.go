@@ -1,9 +1,92 @@
 package segmenttree
 
-type SegmentTree interface {
-	GetAtInstant(instant uint32) float32
-	GetWithinInterval(interval Interval) []ValueIntervalTuple
-	Insert(value ValueIntervalTuple)
-	Delete(value ValueIntervalTuple)
-	InsertRange(values []ValueIntervalTuple)
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidInterval = errors.New("interval start is after its end")
+	ErrTreeNotEmpty    = errors.New("tree is not empty")
+	// ErrCorruptTree reports a tree operation which failed on a broken invariant of the tree or
+	// of a value or aggregate function. The error also wraps the cause, e.g. an I/O error.
+	ErrCorruptTree = errors.New("tree operation failed")
+	// ErrPagedTree reports an option which is not supported by a paged tree.
+	ErrPagedTree = errors.New("not supported by a paged tree")
+)
+
+// SegmentTree is implemented by all trees over a single time dimension, so that the backend can
+// be chosen depending on the workload:
+//
+//   - SegmentTreeImpl, the SB-tree, for interleaved modifications and queries
+//   - StaticSegmentTree, an array segment tree, for tuples loaded at once and queried often
+//   - NaiveSegmentTree, a reference without an index, for testing
+//
+// ConcurrentSegmentTree and DurableSegmentTree wrap an SB-tree and implement it as well.
+type SegmentTree[V Addable[V], T Timestamp] interface {
+	GetAtInstant(instant T) (V, error)
+	GetWithinInterval(interval Interval[T]) ([]ValueIntervalTuple[V, T], error)
+	Insert(value ValueIntervalTuple[V, T]) error
+	Delete(value ValueIntervalTuple[V, T]) error
+	InsertRange(values []ValueIntervalTuple[V, T]) error
+}
+
+var (
+	_ SegmentTree[Float, uint32] = (*SegmentTreeImpl[Float, uint32])(nil)
+	_ SegmentTree[Float, uint32] = (*StaticSegmentTree[Float, uint32])(nil)
+	_ SegmentTree[Float, uint32] = (*NaiveSegmentTree[Float, uint32])(nil)
+	_ SegmentTree[Float, uint32] = (*ConcurrentSegmentTree[Float, uint32])(nil)
+	_ SegmentTree[Float, uint32] = (*DurableSegmentTree[Float, uint32])(nil)
+)
+
+// catch runs the operation and returns a panic within it as ErrCorruptTree.
+func catch(operation func() error) (err error) {
+	defer func() {
+		if failure := recover(); failure != nil {
+			if cause, ok := failure.(error); ok {
+				err = corrupt(cause)
+			} else {
+				err = fmt.Errorf("%w: %v", ErrCorruptTree, failure)
+			}
+		}
+	}()
+
+	return operation()
+}
+
+// corrupt returns ErrCorruptTree wrapping the cause.
+func corrupt(cause error) error {
+	return fmt.Errorf("%w: %w", ErrCorruptTree, cause)
+}
+
+// checkIntervals returns ErrInvalidInterval if any interval is invalid.
+func checkIntervals[T Timestamp](intervals ...Interval[T]) error {
+	for _, interval := range intervals {
+		if !interval.IsValid() {
+			return fmt.Errorf("%w: [%d, %d)", ErrInvalidInterval, interval.start, interval.end)
+		}
+	}
+
+	return nil
+}
+
+func checkTuples[V Addable[V], T Timestamp](values ...ValueIntervalTuple[V, T]) error {
+	for _, value := range values {
+		if err := checkIntervals(value.interval); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendPiece appends a piece to consecutive pieces, merging it into the last piece if both are
+// adjacent and have the same value.
+func appendPiece[V Addable[V], T Timestamp](pieces []ValueIntervalTuple[V, T], piece ValueIntervalTuple[V, T]) []ValueIntervalTuple[V, T] {
+	if last := len(pieces) - 1; last >= 0 && equalValues(pieces[last].value, piece.value) && pieces[last].interval.end == piece.interval.start {
+		pieces[last].interval.end = piece.interval.end
+		return pieces
+	}
+
+	return append(pieces, piece)
 }
@@ -2,6 +2,7 @@ package segmenttree
 
 import (
 	"math"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,66 +26,82 @@ var testDataGetAtInstant = []struct {
 // Yang et. al 2003, 3.1
 // Lookup 19
 func TestGetAtInstant(t *testing.T) {
+	forEachValueType(t, testGetAtInstant[Float], testGetAtInstant[Float64], testGetAtInstant[Int64], testGetAtInstant[Decimal])
+}
+
+func testGetAtInstant[V scalarValue[V]](t *testing.T) {
 	// Arrange
 	assert := assert.New(t)
 
-	tree := setupTree()
+	tree := setupTreeWith(SumAggregate[V]())
 
 	for _, testData := range testDataGetAtInstant {
 		// Act
-		res := tree.GetAtInstant(testData.instant)
+		res := must(tree.GetAtInstant(testData.instant))
 
 		// Assert
-		assert.Equal(testData.expectedValue, res)
+		assert.Equal(scalar[V](testData.expectedValue), res)
 	}
 }
 
 // Yang et. al 2003, 3.2
 // Range [14, 28)
 func TestGetWithinInterval(t *testing.T) {
+	forEachValueType(t, testGetWithinInterval[Float], testGetWithinInterval[Float64], testGetWithinInterval[Int64], testGetWithinInterval[Decimal])
+}
+
+func testGetWithinInterval[V scalarValue[V]](t *testing.T) {
 	// Arrange
 	assert := assert.New(t)
 
-	tree := setupTree()
+	tree := setupTreeWith(SumAggregate[V]())
 
 	// Act
-	res := tree.GetWithinInterval(Interval{start: 14, end: 28})
+	res := must(tree.GetWithinInterval(Interval[uint32]{start: 14, end: 28}))
 
 	// Assert
 	assert.Len(res, 3)
-	assert.Contains(res, ValueIntervalTuple{value: Float(8), interval: Interval{start: 14, end: 15}})
-	assert.Contains(res, ValueIntervalTuple{value: Float(6), interval: Interval{start: 15, end: 20}})
-	assert.Contains(res, ValueIntervalTuple{value: Float(7), interval: Interval{start: 20, end: 28}})
+	assert.Contains(res, ValueIntervalTuple[V, uint32]{value: scalar[V](8), interval: Interval[uint32]{start: 14, end: 15}})
+	assert.Contains(res, ValueIntervalTuple[V, uint32]{value: scalar[V](6), interval: Interval[uint32]{start: 15, end: 20}})
+	assert.Contains(res, ValueIntervalTuple[V, uint32]{value: scalar[V](7), interval: Interval[uint32]{start: 20, end: 28}})
 }
 
 func TestGetWithinIntervalWholeRange(t *testing.T) {
+	forEachValueType(t, testGetWithinIntervalWholeRange[Float], testGetWithinIntervalWholeRange[Float64], testGetWithinIntervalWholeRange[Int64], testGetWithinIntervalWholeRange[Decimal])
+}
+
+func testGetWithinIntervalWholeRange[V scalarValue[V]](t *testing.T) {
 	// Arrange
-	tree := setupTree()
+	tree := setupTreeWith(SumAggregate[V]())
 
 	// Act
-	result := tree.GetWithinInterval(Interval{start: 0, end: math.MaxUint32})
+	result := must(tree.GetWithinInterval(Interval[uint32]{start: 0, end: math.MaxUint32}))
 
 	// Assert
 	assert.Len(t, result, 10)
 
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(0, 5), value: Float(0)}, result[0])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(5, 10), value: Float(2)}, result[1])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(10, 15), value: Float(8)}, result[2])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(15, 20), value: Float(6)}, result[3])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(20, 30), value: Float(7)}, result[4])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(30, 35), value: Float(4)}, result[5])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(35, 40), value: Float(8)}, result[6])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(40, 45), value: Float(5)}, result[7])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(45, 50), value: Float(1)}, result[8])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(50, math.MaxUint32), value: Float(0)}, result[9])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](0, 5), value: scalar[V](0)}, result[0])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](5, 10), value: scalar[V](2)}, result[1])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](10, 15), value: scalar[V](8)}, result[2])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](15, 20), value: scalar[V](6)}, result[3])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](20, 30), value: scalar[V](7)}, result[4])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](30, 35), value: scalar[V](4)}, result[5])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](35, 40), value: scalar[V](8)}, result[6])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](40, 45), value: scalar[V](5)}, result[7])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](45, 50), value: scalar[V](1)}, result[8])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](50, math.MaxUint32), value: scalar[V](0)}, result[9])
 }
 
 func TestGetWithinIntervalWholeRangeAsFloat64(t *testing.T) {
+	forEachValueType(t, testGetWithinIntervalWholeRangeAsFloat64[Float], testGetWithinIntervalWholeRangeAsFloat64[Float64], testGetWithinIntervalWholeRangeAsFloat64[Int64], testGetWithinIntervalWholeRangeAsFloat64[Decimal])
+}
+
+func testGetWithinIntervalWholeRangeAsFloat64[V scalarValue[V]](t *testing.T) {
 	// Arrange
-	tree := setupTree()
+	tree := setupTreeWith(SumAggregate[V]())
 
 	// Act
-	result := tree.GetWithinInterval(Interval{start: 0, end: math.MaxUint32})
+	result := must(tree.GetWithinInterval(Interval[uint32]{start: 0, end: math.MaxUint32}))
 
 	// Assert
 	assert.Len(t, result, 10)
@@ -102,102 +119,118 @@ func TestGetWithinIntervalWholeRangeAsFloat64(t *testing.T) {
 }
 
 func TestNewTree(t *testing.T) {
+	forEachValueType(t, testNewTree[Float], testNewTree[Float64], testNewTree[Int64], testNewTree[Decimal])
+}
+
+func testNewTree[V scalarValue[V]](t *testing.T) {
 	// Arrange
 	assert := assert.New(t)
 
 	// Act
-	tree := NewSegmentTree(BRANCHING_FACTOR, Aggregate{Sum, InverseSum, Identity, Float(0)})
+	tree := NewSegmentTree[V, uint32](BRANCHING_FACTOR, SumAggregate[V]())
 
 	// Assert
 	n0 := tree.root
 
 	assert.Equal(BRANCHING_FACTOR, tree.branchingFactor)
-	assert.Equal(Float(0), n0.values[0])
+	assert.Equal(scalar[V](0), n0.values[0])
 }
 
 // Yang et. al 2003, 3.3
 // Insert 1, [17, 47)
 func TestInsert1(t *testing.T) {
+	forEachValueType(t, testInsert1[Float], testInsert1[Float64], testInsert1[Int64], testInsert1[Decimal])
+}
+
+func testInsert1[V scalarValue[V]](t *testing.T) {
 	// Arrange
 	assert := assert.New(t)
 
-	tree := setupTree()
+	tree := setupTreeWith(SumAggregate[V]())
 
 	// Act
-	tree.Insert(ValueIntervalTuple{value: Float(1), interval: Interval{start: 17, end: 47}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 17, end: 47}})
 
 	// Assert
 	n0 := tree.root
-	n2 := n0.children[1]
-	n3 := n0.children[2]
-	n4 := n0.children[3]
+	n2 := n0.children[1].node
+	n3 := n0.children[2].node
+	n4 := n0.children[3].node
 
-	assert.Equal(Float(0), n0.values[0])
-	assert.Equal(Float(1), n0.values[1])
-	assert.Equal(Float(1), n0.values[2])
-	assert.Equal(Float(0), n0.values[3])
+	assert.Equal(scalar[V](0), n0.values[0])
+	assert.Equal(scalar[V](1), n0.values[1])
+	assert.Equal(scalar[V](1), n0.values[2])
+	assert.Equal(scalar[V](0), n0.values[3])
 
 	assert.Equal(uint32(2), n2.size())
 	assert.Equal(uint32(17), n2.keys[0])
 	assert.Equal(uint32(20), n2.keys[1])
-	assert.Equal(Float(5), n2.values[0])
-	assert.Equal(Float(6), n2.values[1])
-	assert.Equal(Float(7), n2.values[2])
+	assert.Equal(scalar[V](5), n2.values[0])
+	assert.Equal(scalar[V](6), n2.values[1])
+	assert.Equal(scalar[V](7), n2.values[2])
 
 	assert.Equal(uint32(2), n3.size())
 	assert.Equal(uint32(35), n3.keys[0])
 	assert.Equal(uint32(40), n3.keys[1])
-	assert.Equal(Float(4), n3.values[0])
-	assert.Equal(Float(8), n3.values[1])
-	assert.Equal(Float(5), n3.values[2])
+	assert.Equal(scalar[V](4), n3.values[0])
+	assert.Equal(scalar[V](8), n3.values[1])
+	assert.Equal(scalar[V](5), n3.values[2])
 
 	assert.Equal(uint32(2), n4.size())
 	assert.Equal(uint32(47), n4.keys[0])
 	assert.Equal(uint32(50), n4.keys[1])
-	assert.Equal(Float(2), n4.values[0])
-	assert.Equal(Float(1), n4.values[1])
-	assert.Equal(Float(0), n4.values[2])
+	assert.Equal(scalar[V](2), n4.values[0])
+	assert.Equal(scalar[V](1), n4.values[1])
+	assert.Equal(scalar[V](0), n4.values[2])
 }
 
 // Yang et. al 2003, 3.3
 // Insert 1, [24, 30)
 func TestInsert2(t *testing.T) {
+	forEachValueType(t, testInsert2[Float], testInsert2[Float64], testInsert2[Int64], testInsert2[Decimal])
+}
+
+func testInsert2[V scalarValue[V]](t *testing.T) {
 	// Arrange
 	assert := assert.New(t)
 
-	tree := setupTree()
+	tree := setupTreeWith(SumAggregate[V]())
 
 	// Act
-	tree.Insert(ValueIntervalTuple{value: Float(1), interval: Interval{start: 24, end: 30}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 24, end: 30}})
 
 	// Assert
 	n0 := tree.root
-	n2 := n0.children[1]
+	n2 := n0.children[1].node
 
 	assert.Equal(uint32(2), n2.size())
 
 	assert.Equal(uint32(20), n2.keys[0])
 	assert.Equal(uint32(24), n2.keys[1])
 
-	assert.Equal(Float(5), n2.values[0])
-	assert.Equal(Float(6), n2.values[1])
-	assert.Equal(Float(7), n2.values[2])
+	assert.Equal(scalar[V](5), n2.values[0])
+	assert.Equal(scalar[V](6), n2.values[1])
+	assert.Equal(scalar[V](7), n2.values[2])
 }
 
 // Yang et. al 2003, 3.3
 // Insert 1, [24, 28)
 func TestInsert3(t *testing.T) {
+	forEachValueType(t, testInsert3[Float], testInsert3[Float64], testInsert3[Int64], testInsert3[Decimal])
+}
+
+func testInsert3[V scalarValue[V]](t *testing.T) {
 	// Arrange
 	assert := assert.New(t)
 
-	tree := setupTree()
+	tree := setupTreeWith(SumAggregate[V]())
 
 	// Act
-	tree.Insert(ValueIntervalTuple{value: Float(1), interval: Interval{start: 24, end: 28}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 24, end: 28}})
 
 	// Assert
 	n0 := tree.root
-	n2 := n0.children[1]
+	n2 := n0.children[1].node
 
 	assert.Equal(uint32(3), n2.size())
 
@@ -205,118 +238,132 @@ func TestInsert3(t *testing.T) {
 	assert.Equal(uint32(24), n2.keys[1])
 	assert.Equal(uint32(28), n2.keys[2])
 
-	assert.Equal(Float(5), n2.values[0])
-	assert.Equal(Float(6), n2.values[1])
-	assert.Equal(Float(7), n2.values[2])
-	assert.Equal(Float(6), n2.values[3])
+	assert.Equal(scalar[V](5), n2.values[0])
+	assert.Equal(scalar[V](6), n2.values[1])
+	assert.Equal(scalar[V](7), n2.values[2])
+	assert.Equal(scalar[V](6), n2.values[3])
 }
 
 // Yang et. al 2003, fig. 9
 // Insert 1, [7, 12) & split
 func TestInsert4(t *testing.T) {
+	forEachValueType(t, testInsert4[Float], testInsert4[Float64], testInsert4[Int64], testInsert4[Decimal])
+}
+
+func testInsert4[V scalarValue[V]](t *testing.T) {
 	// Arrange
 	assert := assert.New(t)
 
-	tree := setupTree()
+	tree := setupTreeWith(SumAggregate[V]())
 
 	// Act
-	tree.Insert(ValueIntervalTuple{value: Float(1), interval: Interval{start: 7, end: 12}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 7, end: 12}})
 
 	// Assert
 	n0 := tree.root
-	n01 := n0.children[0]
-	n02 := n0.children[1]
-	n11 := n01.children[0]
-	n12 := n01.children[1]
-	n2 := n01.children[2]
-	n3 := n02.children[0]
-	n4 := n02.children[1]
+	n01 := n0.children[0].node
+	n02 := n0.children[1].node
+	n11 := n01.children[0].node
+	n12 := n01.children[1].node
+	n2 := n01.children[2].node
+	n3 := n02.children[0].node
+	n4 := n02.children[1].node
 
 	assert.Equal(uint32(1), n0.size())
 	assert.Equal(uint32(30), n0.keys[0])
-	assert.Equal(Float(0), n0.values[0])
-	assert.Equal(Float(0), n0.values[1])
+	assert.Equal(scalar[V](0), n0.values[0])
+	assert.Equal(scalar[V](0), n0.values[1])
 
 	assert.Equal(uint32(2), n01.size())
 	assert.Equal(uint32(10), n01.keys[0])
 	assert.Equal(uint32(15), n01.keys[1])
-	assert.Equal(Float(0), n01.values[0])
-	assert.Equal(Float(0), n01.values[1])
-	assert.Equal(Float(1), n01.values[2])
+	assert.Equal(scalar[V](0), n01.values[0])
+	assert.Equal(scalar[V](0), n01.values[1])
+	assert.Equal(scalar[V](1), n01.values[2])
 
 	assert.Equal(uint32(1), n02.size())
 	assert.Equal(uint32(45), n02.keys[0])
-	assert.Equal(Float(0), n02.values[0])
-	assert.Equal(Float(0), n02.values[1])
+	assert.Equal(scalar[V](0), n02.values[0])
+	assert.Equal(scalar[V](0), n02.values[1])
 
 	assert.Equal(uint32(2), n11.size())
 	assert.Equal(uint32(5), n11.keys[0])
 	assert.Equal(uint32(7), n11.keys[1])
-	assert.Equal(Float(0), n11.values[0])
-	assert.Equal(Float(2), n11.values[1])
-	assert.Equal(Float(3), n11.values[2])
+	assert.Equal(scalar[V](0), n11.values[0])
+	assert.Equal(scalar[V](2), n11.values[1])
+	assert.Equal(scalar[V](3), n11.values[2])
 
 	assert.Equal(uint32(1), n12.size())
 	assert.Equal(uint32(12), n12.keys[0])
-	assert.Equal(Float(9), n12.values[0])
-	assert.Equal(Float(8), n12.values[1])
+	assert.Equal(scalar[V](9), n12.values[0])
+	assert.Equal(scalar[V](8), n12.values[1])
 
 	assert.Equal(uint32(1), n2.size())
 	assert.Equal(uint32(20), n2.keys[0])
-	assert.Equal(Float(5), n2.values[0])
-	assert.Equal(Float(6), n2.values[1])
+	assert.Equal(scalar[V](5), n2.values[0])
+	assert.Equal(scalar[V](6), n2.values[1])
 
 	assert.Equal(uint32(2), n3.size())
 	assert.Equal(uint32(35), n3.keys[0])
 	assert.Equal(uint32(40), n3.keys[1])
-	assert.Equal(Float(4), n3.values[0])
-	assert.Equal(Float(8), n3.values[1])
-	assert.Equal(Float(5), n3.values[2])
+	assert.Equal(scalar[V](4), n3.values[0])
+	assert.Equal(scalar[V](8), n3.values[1])
+	assert.Equal(scalar[V](5), n3.values[2])
 
 	assert.Equal(uint32(1), n4.size())
 	assert.Equal(uint32(50), n4.keys[0])
-	assert.Equal(Float(1), n4.values[0])
-	assert.Equal(Float(0), n4.values[1])
+	assert.Equal(scalar[V](1), n4.values[0])
+	assert.Equal(scalar[V](0), n4.values[1])
 }
 
 func TestInsertTwiceSameRangeSimpleElement(t *testing.T) {
+	forEachValueType(t, testInsertTwiceSameRangeSimpleElement[Float], testInsertTwiceSameRangeSimpleElement[Float64], testInsertTwiceSameRangeSimpleElement[Int64], testInsertTwiceSameRangeSimpleElement[Decimal])
+}
+
+func testInsertTwiceSameRangeSimpleElement[V scalarValue[V]](t *testing.T) {
 	// Arrange
 	assert := assert.New(t)
 
-	n0 := &Node{
+	n0 := &Node[V, uint32]{
 		keys:     []uint32{},
-		values:   []Addable{Float(0)},
-		children: []*Node{},
+		values:   []V{scalar[V](0)},
+		children: []link[V, uint32]{},
 		isLeaf:   true,
 	}
 	n0.parent = nil
-	tree := &SegmentTreeImpl{
+	tree := &SegmentTreeImpl[V, uint32]{
 		root:            n0,
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		aggregate:       SumAggregate[V](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
 	n0.tree = tree
+	setBounds(n0)
 
-	tree.Insert(ValueIntervalTuple{value: Float(2), interval: Interval{start: 10, end: 40}})
-	tree.Insert(ValueIntervalTuple{value: Float(3), interval: Interval{start: 10, end: 40}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](2), interval: Interval[uint32]{start: 10, end: 40}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](3), interval: Interval[uint32]{start: 10, end: 40}})
 
 	// Assert
 	assert.Equal(2, int(n0.size()))
 }
 
 func TestInsertMatchingEndPoint4(t *testing.T) {
+	forEachValueType(t, testInsertMatchingEndPoint4[Float], testInsertMatchingEndPoint4[Float64], testInsertMatchingEndPoint4[Int64], testInsertMatchingEndPoint4[Decimal])
+}
+
+func testInsertMatchingEndPoint4[V scalarValue[V]](t *testing.T) {
 	// Arrange
-	node := &Node{
+	node := &Node[V, uint32]{
 		keys:   []uint32{10, 30, 40},
-		values: []Addable{Float(0), Float(5), Float(2), Float(0)},
+		values: []V{scalar[V](0), scalar[V](5), scalar[V](2), scalar[V](0)},
 		isLeaf: true,
-		tree: &SegmentTreeImpl{
-			aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		tree: &SegmentTreeImpl[V, uint32]{
+			aggregate:       SumAggregate[V](),
 			branchingFactor: BRANCHING_FACTOR,
 		},
 	}
 	node.tree.root = node
-	intervalTuple := ValueIntervalTuple{value: Float(1), interval: Interval{start: 20, end: 40}}
+	setBounds(node)
+	intervalTuple := ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 20, end: 40}}
 
 	// Act
 	node.tree.Insert(intervalTuple)
@@ -326,100 +373,108 @@ func TestInsertMatchingEndPoint4(t *testing.T) {
 	assert.Equal(t, uint32(20), node.keys[1])
 	assert.Equal(t, uint32(30), node.keys[2])
 	assert.Equal(t, uint32(40), node.keys[3])
-	assert.Equal(t, Float(0), node.values[0])
-	assert.Equal(t, Float(5), node.values[1])
-	assert.Equal(t, Float(6), node.values[2])
-	assert.Equal(t, Float(3), node.values[3])
-	assert.Equal(t, Float(0), node.values[4])
+	assert.Equal(t, scalar[V](0), node.values[0])
+	assert.Equal(t, scalar[V](5), node.values[1])
+	assert.Equal(t, scalar[V](6), node.values[2])
+	assert.Equal(t, scalar[V](3), node.values[3])
+	assert.Equal(t, scalar[V](0), node.values[4])
 }
 
 // Yang et. al 2003, 3.4 & 3.6
 // Delete 1, [17, 47) & interval merge
 func TestDelete1(t *testing.T) {
+	forEachValueType(t, testDelete1[Float], testDelete1[Float64], testDelete1[Int64], testDelete1[Decimal])
+}
+
+func testDelete1[V scalarValue[V]](t *testing.T) {
 	// Arrange
 	assert := assert.New(t)
 
-	tree := setupTree()
-	tree.Insert(ValueIntervalTuple{value: Float(1), interval: Interval{start: 17, end: 47}})
+	tree := setupTreeWith(SumAggregate[V]())
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 17, end: 47}})
 
 	// Act
-	tree.Delete(ValueIntervalTuple{value: Float(1), interval: Interval{start: 17, end: 47}})
+	tree.Delete(ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 17, end: 47}})
 
 	// Assert
 	n0 := tree.root
-	n1 := n0.children[0]
-	n2 := n0.children[1]
-	n3 := n0.children[2]
-	n4 := n0.children[3]
+	n1 := n0.children[0].node
+	n2 := n0.children[1].node
+	n3 := n0.children[2].node
+	n4 := n0.children[3].node
 
 	assert.Equal(uint32(2), n1.size())
 	assert.Equal(uint32(5), n1.keys[0])
 	assert.Equal(uint32(10), n1.keys[1])
-	assert.Equal(Float(0), n1.values[0])
-	assert.Equal(Float(2), n1.values[1])
-	assert.Equal(Float(8), n1.values[2])
+	assert.Equal(scalar[V](0), n1.values[0])
+	assert.Equal(scalar[V](2), n1.values[1])
+	assert.Equal(scalar[V](8), n1.values[2])
 
 	assert.Equal(uint32(1), n2.size())
 	assert.Equal(uint32(20), n2.keys[0])
-	assert.Equal(Float(5), n2.values[0])
-	assert.Equal(Float(6), n2.values[1])
+	assert.Equal(scalar[V](5), n2.values[0])
+	assert.Equal(scalar[V](6), n2.values[1])
 
 	assert.Equal(uint32(2), n3.size())
 	assert.Equal(uint32(35), n3.keys[0])
 	assert.Equal(uint32(40), n3.keys[1])
-	assert.Equal(Float(4), n3.values[0])
-	assert.Equal(Float(8), n3.values[1])
-	assert.Equal(Float(5), n3.values[2])
+	assert.Equal(scalar[V](4), n3.values[0])
+	assert.Equal(scalar[V](8), n3.values[1])
+	assert.Equal(scalar[V](5), n3.values[2])
 
 	assert.Equal(uint32(1), n4.size())
 	assert.Equal(uint32(50), n4.keys[0])
-	assert.Equal(Float(1), n4.values[0])
-	assert.Equal(Float(0), n4.values[1])
+	assert.Equal(scalar[V](1), n4.values[0])
+	assert.Equal(scalar[V](0), n4.values[1])
 }
 
 func TestDelete2(t *testing.T) {
+	forEachValueType(t, testDelete2[Float], testDelete2[Float64], testDelete2[Int64], testDelete2[Decimal])
+}
+
+func testDelete2[V scalarValue[V]](t *testing.T) {
 	// Arrange
 	assert := assert.New(t)
 
-	n11 := &Node{
+	n11 := &Node[V, uint32]{
 		keys:     []uint32{5, 7},
-		values:   []Addable{Float(0), Float(2), Float(3)},
-		children: []*Node{},
+		values:   []V{scalar[V](0), scalar[V](2), scalar[V](3)},
+		children: []link[V, uint32]{},
 		isLeaf:   true,
 	}
 
-	n12 := &Node{
+	n12 := &Node[V, uint32]{
 		keys:     []uint32{12},
-		values:   []Addable{Float(9), Float(8)},
-		children: []*Node{},
+		values:   []V{scalar[V](9), scalar[V](8)},
+		children: []link[V, uint32]{},
 		isLeaf:   true,
 	}
 
-	n13 := &Node{
+	n13 := &Node[V, uint32]{
 		keys:     []uint32{20},
-		values:   []Addable{Float(5), Float(6)},
-		children: []*Node{},
+		values:   []V{scalar[V](5), scalar[V](6)},
+		children: []link[V, uint32]{},
 		isLeaf:   true,
 	}
 
-	n1 := &Node{
+	n1 := &Node[V, uint32]{
 		keys:     []uint32{10, 15},
-		values:   []Addable{Float(0), Float(0), Float(1)},
-		children: []*Node{n11, n12, n13},
+		values:   []V{scalar[V](0), scalar[V](0), scalar[V](1)},
+		children: []link[V, uint32]{{node: n11}, {node: n12}, {node: n13}},
 		isLeaf:   false,
 	}
 
-	n2 := &Node{
+	n2 := &Node[V, uint32]{
 		keys:     []uint32{45},
-		values:   []Addable{Float(0), Float(0)},
-		children: []*Node{},
+		values:   []V{scalar[V](0), scalar[V](0)},
+		children: []link[V, uint32]{},
 		isLeaf:   true,
 	}
 
-	n0 := &Node{
+	n0 := &Node[V, uint32]{
 		keys:     []uint32{30},
-		values:   []Addable{Float(0), Float(0)},
-		children: []*Node{n1, n2},
+		values:   []V{scalar[V](0), scalar[V](0)},
+		children: []link[V, uint32]{{node: n1}, {node: n2}},
 		isLeaf:   false,
 	}
 
@@ -430,9 +485,9 @@ func TestDelete2(t *testing.T) {
 	n12.parent = n1
 	n13.parent = n1
 
-	tree := &SegmentTreeImpl{
+	tree := &SegmentTreeImpl[V, uint32]{
 		root:            n0,
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		aggregate:       SumAggregate[V](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
 
@@ -442,103 +497,113 @@ func TestDelete2(t *testing.T) {
 	n11.tree = tree
 	n12.tree = tree
 	n13.tree = tree
+	setBounds(n0)
 
 	// Act
-	tree.Delete(ValueIntervalTuple{value: Float(1), interval: Interval{start: 7, end: 12}})
+	tree.Delete(ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 7, end: 12}})
 
 	// Assert
 	r0 := tree.root
-	r1 := n0.children[0]
-	r2 := n0.children[1]
-	r11 := r1.children[0]
-	r12 := r1.children[1]
+	r1 := n0.children[0].node
+	r2 := n0.children[1].node
+	r11 := r1.children[0].node
+	r12 := r1.children[1].node
 
 	assert.Equal(uint32(1), r0.size())
 	assert.Equal(uint32(30), r0.keys[0])
-	assert.Equal(Float(0), r0.values[0])
-	assert.Equal(Float(0), r0.values[1])
+	assert.Equal(scalar[V](0), r0.values[0])
+	assert.Equal(scalar[V](0), r0.values[1])
 
 	assert.Equal(uint32(1), r1.size())
 	assert.Equal(uint32(10), r1.keys[0])
-	assert.Equal(Float(0), r1.values[0])
-	assert.Equal(Float(0), r1.values[1])
+	assert.Equal(scalar[V](0), r1.values[0])
+	assert.Equal(scalar[V](0), r1.values[1])
 
 	assert.Equal(uint32(1), r2.size())
 	assert.Equal(uint32(45), r2.keys[0])
-	assert.Equal(Float(0), r2.values[0])
-	assert.Equal(Float(0), r2.values[1])
+	assert.Equal(scalar[V](0), r2.values[0])
+	assert.Equal(scalar[V](0), r2.values[1])
 
 	assert.Equal(uint32(1), r11.size())
 	assert.Equal(uint32(5), r11.keys[0])
-	assert.Equal(Float(0), r11.values[0])
-	assert.Equal(Float(2), r11.values[1])
+	assert.Equal(scalar[V](0), r11.values[0])
+	assert.Equal(scalar[V](2), r11.values[1])
 
 	assert.Equal(uint32(2), r12.size())
 	assert.Equal(uint32(15), r12.keys[0])
 	assert.Equal(uint32(20), r12.keys[1])
-	assert.Equal(Float(8), r12.values[0])
-	assert.Equal(Float(6), r12.values[1])
-	assert.Equal(Float(7), r12.values[2])
+	assert.Equal(scalar[V](8), r12.values[0])
+	assert.Equal(scalar[V](6), r12.values[1])
+	assert.Equal(scalar[V](7), r12.values[2])
 }
 
 func TestDeleteSimpleElement(t *testing.T) {
+	forEachValueType(t, testDeleteSimpleElement[Float], testDeleteSimpleElement[Float64], testDeleteSimpleElement[Int64], testDeleteSimpleElement[Decimal])
+}
+
+func testDeleteSimpleElement[V scalarValue[V]](t *testing.T) {
 	// Arrange
 	assert := assert.New(t)
 
-	n0 := &Node{
+	n0 := &Node[V, uint32]{
 		keys:     []uint32{10, 40},
-		values:   []Addable{Float(0), Float(2), Float(0)},
-		children: []*Node{},
+		values:   []V{scalar[V](0), scalar[V](2), scalar[V](0)},
+		children: []link[V, uint32]{},
 		isLeaf:   true,
 	}
 	n0.parent = nil
-	tree := &SegmentTreeImpl{
+	tree := &SegmentTreeImpl[V, uint32]{
 		root:            n0,
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		aggregate:       SumAggregate[V](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
 	n0.tree = tree
+	setBounds(n0)
 	// Act
-	tree.Delete(ValueIntervalTuple{value: Float(2), interval: Interval{start: 10, end: 40}})
+	tree.Delete(ValueIntervalTuple[V, uint32]{value: scalar[V](2), interval: Interval[uint32]{start: 10, end: 40}})
 
 	// Assert
 	assert.Equal(0, int(n0.size()))
 }
 
+func setupTree() *SegmentTreeImpl[Float, uint32] {
+	return setupTreeWith(SumAggregate[Float]())
+}
+
 // Yang et. al 2003, Fig 4
-func setupTree() *SegmentTreeImpl {
-	n1 := &Node{
+func setupTreeWith[V scalarValue[V]](aggregate Aggregate[V]) *SegmentTreeImpl[V, uint32] {
+	n1 := &Node[V, uint32]{
 		keys:     []uint32{5, 10},
-		values:   []Addable{Float(0), Float(2), Float(8)},
-		children: []*Node{},
+		values:   []V{scalar[V](0), scalar[V](2), scalar[V](8)},
+		children: []link[V, uint32]{},
 		isLeaf:   true,
 	}
 
-	n2 := &Node{
+	n2 := &Node[V, uint32]{
 		keys:     []uint32{20},
-		values:   []Addable{Float(5), Float(6)},
-		children: []*Node{},
+		values:   []V{scalar[V](5), scalar[V](6)},
+		children: []link[V, uint32]{},
 		isLeaf:   true,
 	}
 
-	n3 := &Node{
+	n3 := &Node[V, uint32]{
 		keys:     []uint32{35, 40},
-		values:   []Addable{Float(4), Float(8), Float(5)},
-		children: []*Node{},
+		values:   []V{scalar[V](4), scalar[V](8), scalar[V](5)},
+		children: []link[V, uint32]{},
 		isLeaf:   true,
 	}
 
-	n4 := &Node{
+	n4 := &Node[V, uint32]{
 		keys:     []uint32{50},
-		values:   []Addable{Float(1), Float(0)},
-		children: []*Node{},
+		values:   []V{scalar[V](1), scalar[V](0)},
+		children: []link[V, uint32]{},
 		isLeaf:   true,
 	}
 
-	n0 := &Node{
+	n0 := &Node[V, uint32]{
 		keys:     []uint32{15, 30, 45},
-		values:   []Addable{Float(0), Float(1), Float(0), Float(0)},
-		children: []*Node{n1, n2, n3, n4},
+		values:   []V{scalar[V](0), scalar[V](1), scalar[V](0), scalar[V](0)},
+		children: []link[V, uint32]{{node: n1}, {node: n2}, {node: n3}, {node: n4}},
 		isLeaf:   false,
 	}
 
@@ -548,9 +613,9 @@ func setupTree() *SegmentTreeImpl {
 	n3.parent = n0
 	n4.parent = n0
 
-	tree := &SegmentTreeImpl{
+	tree := &SegmentTreeImpl[V, uint32]{
 		root:            n0,
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		aggregate:       aggregate,
 		branchingFactor: BRANCHING_FACTOR,
 	}
 
@@ -559,225 +624,240 @@ func setupTree() *SegmentTreeImpl {
 	n2.tree = tree
 	n3.tree = tree
 	n4.tree = tree
+	setBounds(n0)
 
 	return tree
 }
 
 // Yang et. al 2003, Fig 19
 func TestSumDosageScenarioInsert(t *testing.T) {
+	forEachValueType(t, testSumDosageScenarioInsert[Float], testSumDosageScenarioInsert[Float64], testSumDosageScenarioInsert[Int64], testSumDosageScenarioInsert[Decimal])
+}
+
+func testSumDosageScenarioInsert[V scalarValue[V]](t *testing.T) {
 
 	// Arrange
 	assert := assert.New(t)
 
-	n0 := &Node{
+	n0 := &Node[V, uint32]{
 		keys:     []uint32{},
-		values:   []Addable{Float(0)},
-		children: []*Node{},
+		values:   []V{scalar[V](0)},
+		children: []link[V, uint32]{},
 		isLeaf:   true,
 	}
 
 	n0.parent = nil
-	tree := &SegmentTreeImpl{
+	tree := &SegmentTreeImpl[V, uint32]{
 		root:            n0,
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		aggregate:       SumAggregate[V](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
 
 	n0.tree = tree
+	setBounds(n0)
 
 	// Act
-	tree.Insert(ValueIntervalTuple{value: Float(2), interval: Interval{start: 10, end: 40}})
-	tree.Insert(ValueIntervalTuple{value: Float(3), interval: Interval{start: 10, end: 30}})
-	tree.Insert(ValueIntervalTuple{value: Float(1), interval: Interval{start: 20, end: 40}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](2), interval: Interval[uint32]{start: 10, end: 40}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](3), interval: Interval[uint32]{start: 10, end: 30}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 20, end: 40}})
 	// split nodes
-	tree.Insert(ValueIntervalTuple{value: Float(2), interval: Interval{start: 5, end: 15}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](2), interval: Interval[uint32]{start: 5, end: 15}})
 	// split nodes
-	tree.Insert(ValueIntervalTuple{value: Float(4), interval: Interval{start: 35, end: 45}})
-	tree.Insert(ValueIntervalTuple{value: Float(1), interval: Interval{start: 10, end: 50}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](4), interval: Interval[uint32]{start: 35, end: 45}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 10, end: 50}})
 	// split nodes
 
 	// Assert
 	n0 = tree.root
 	assert.Len(n0.children, 4)
-	n00 := n0.children[0]
-	n01 := n0.children[1]
-	n02 := n0.children[2]
-	n03 := n0.children[3]
+	n00 := n0.children[0].node
+	n01 := n0.children[1].node
+	n02 := n0.children[2].node
+	n03 := n0.children[3].node
 
 	assert.Equal(uint32(15), n0.keys[0])
 	assert.Equal(uint32(30), n0.keys[1])
 	assert.Equal(uint32(45), n0.keys[2])
 	assert.Len(n0.keys, 3)
-	assert.Equal(Float(0), n0.values[0])
-	assert.Equal(Float(1), n0.values[1])
-	assert.Equal(Float(0), n0.values[2])
-	assert.Equal(Float(0), n0.values[3])
+	assert.Equal(scalar[V](0), n0.values[0])
+	assert.Equal(scalar[V](1), n0.values[1])
+	assert.Equal(scalar[V](0), n0.values[2])
+	assert.Equal(scalar[V](0), n0.values[3])
 	assert.Len(n0.values, 4)
 
 	assert.Equal(uint32(5), n00.keys[0])
 	assert.Equal(uint32(10), n00.keys[1])
 	assert.Len(n00.keys, 2)
-	assert.Equal(Float(0), n00.values[0])
-	assert.Equal(Float(2), n00.values[1])
-	assert.Equal(Float(8), n00.values[2])
+	assert.Equal(scalar[V](0), n00.values[0])
+	assert.Equal(scalar[V](2), n00.values[1])
+	assert.Equal(scalar[V](8), n00.values[2])
 	assert.Len(n00.values, 3)
 	assert.Len(n00.children, 0)
 
 	assert.Equal(uint32(20), n01.keys[0])
 	assert.Len(n01.keys, 1)
-	assert.Equal(Float(5), n01.values[0])
-	assert.Equal(Float(6), n01.values[1])
+	assert.Equal(scalar[V](5), n01.values[0])
+	assert.Equal(scalar[V](6), n01.values[1])
 	assert.Len(n01.values, 2)
 	assert.Len(n01.children, 0)
 
 	assert.Equal(uint32(35), n02.keys[0])
 	assert.Equal(uint32(40), n02.keys[1])
 	assert.Len(n02.keys, 2)
-	assert.Equal(Float(4), n02.values[0])
-	assert.Equal(Float(8), n02.values[1])
-	assert.Equal(Float(5), n02.values[2])
+	assert.Equal(scalar[V](4), n02.values[0])
+	assert.Equal(scalar[V](8), n02.values[1])
+	assert.Equal(scalar[V](5), n02.values[2])
 	assert.Len(n02.values, 3)
 	assert.Len(n02.children, 0)
 
 	assert.Equal(uint32(50), n03.keys[0])
 	assert.Len(n03.keys, 1)
-	assert.Equal(Float(1), n03.values[0])
-	assert.Equal(Float(0), n03.values[1])
+	assert.Equal(scalar[V](1), n03.values[0])
+	assert.Equal(scalar[V](0), n03.values[1])
 	assert.Len(n03.values, 2)
 	assert.Len(n03.children, 0)
 }
 
 // Yang et. al 2003, Fig 19
 func TestSumDosageScenarioDelete(t *testing.T) {
+	forEachValueType(t, testSumDosageScenarioDelete[Float], testSumDosageScenarioDelete[Float64], testSumDosageScenarioDelete[Int64], testSumDosageScenarioDelete[Decimal])
+}
+
+func testSumDosageScenarioDelete[V scalarValue[V]](t *testing.T) {
 
 	// Arrange
 	assert := assert.New(t)
 
-	n0 := &Node{
+	n0 := &Node[V, uint32]{
 		keys:     []uint32{},
-		values:   []Addable{Float(0)},
-		children: []*Node{},
+		values:   []V{scalar[V](0)},
+		children: []link[V, uint32]{},
 		isLeaf:   true,
 	}
 	n0.parent = nil
-	tree := &SegmentTreeImpl{
+	tree := &SegmentTreeImpl[V, uint32]{
 		root:            n0,
-		aggregate:       Aggregate{Sum, InverseSum, Identity, Float(0)},
+		aggregate:       SumAggregate[V](),
 		branchingFactor: BRANCHING_FACTOR,
 	}
 	n0.tree = tree
+	setBounds(n0)
 
-	tree.Insert(ValueIntervalTuple{value: Float(2), interval: Interval{start: 10, end: 40}})
-	tree.Insert(ValueIntervalTuple{value: Float(3), interval: Interval{start: 10, end: 30}})
-	tree.Insert(ValueIntervalTuple{value: Float(1), interval: Interval{start: 20, end: 40}})
-	tree.Insert(ValueIntervalTuple{value: Float(2), interval: Interval{start: 5, end: 15}})
-	tree.Insert(ValueIntervalTuple{value: Float(4), interval: Interval{start: 35, end: 45}})
-	tree.Insert(ValueIntervalTuple{value: Float(1), interval: Interval{start: 10, end: 50}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](2), interval: Interval[uint32]{start: 10, end: 40}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](3), interval: Interval[uint32]{start: 10, end: 30}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 20, end: 40}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](2), interval: Interval[uint32]{start: 5, end: 15}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](4), interval: Interval[uint32]{start: 35, end: 45}})
+	tree.Insert(ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 10, end: 50}})
 
 	// Act
-	tree.Delete(ValueIntervalTuple{value: Float(1), interval: Interval{start: 10, end: 50}})
+	tree.Delete(ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 10, end: 50}})
 	assert.Equal(uint32(3), tree.root.size())
 	assert.Equal(uint32(15), tree.root.keys[0])
 	assert.Equal(uint32(30), tree.root.keys[1])
 	assert.Equal(uint32(40), tree.root.keys[2])
-	assert.Equal(Float(0), tree.root.values[0])
-	assert.Equal(Float(0), tree.root.values[1])
-	assert.Equal(Float(-1), tree.root.values[2])
-	assert.Equal(Float(0), tree.root.values[3])
+	assert.Equal(scalar[V](0), tree.root.values[0])
+	assert.Equal(scalar[V](0), tree.root.values[1])
+	assert.Equal(scalar[V](-1), tree.root.values[2])
+	assert.Equal(scalar[V](0), tree.root.values[3])
 
-	assert.Equal(uint32(10), tree.root.children[0].keys[1])
-	assert.Equal(Float(7), tree.root.children[0].values[2])
+	assert.Equal(uint32(10), tree.root.children[0].node.keys[1])
+	assert.Equal(scalar[V](7), tree.root.children[0].node.values[2])
 
-	assert.Equal(uint32(45), tree.root.children[3].keys[0])
-	assert.Equal(Float(4), tree.root.children[3].values[0])
+	assert.Equal(uint32(45), tree.root.children[3].node.keys[0])
+	assert.Equal(scalar[V](4), tree.root.children[3].node.values[0])
 
-	tree.Delete(ValueIntervalTuple{value: Float(4), interval: Interval{start: 35, end: 45}})
+	tree.Delete(ValueIntervalTuple[V, uint32]{value: scalar[V](4), interval: Interval[uint32]{start: 35, end: 45}})
 
 	assert.Equal(uint32(2), tree.root.size())
 	assert.Equal(uint32(15), tree.root.keys[0])
 	assert.Equal(uint32(30), tree.root.keys[1])
-	assert.Equal(Float(0), tree.root.values[0])
-	assert.Equal(Float(0), tree.root.values[1])
-	assert.Equal(Float(0), tree.root.values[2])
+	assert.Equal(scalar[V](0), tree.root.values[0])
+	assert.Equal(scalar[V](0), tree.root.values[1])
+	assert.Equal(scalar[V](0), tree.root.values[2])
 
-	assert.Equal(uint32(1), tree.root.children[2].size())
-	assert.Equal(uint32(40), tree.root.children[2].keys[0])
-	assert.Equal(Float(3), tree.root.children[2].values[0])
-	assert.Equal(Float(0), tree.root.children[2].values[1])
+	assert.Equal(uint32(1), tree.root.children[2].node.size())
+	assert.Equal(uint32(40), tree.root.children[2].node.keys[0])
+	assert.Equal(scalar[V](3), tree.root.children[2].node.values[0])
+	assert.Equal(scalar[V](0), tree.root.children[2].node.values[1])
 
 	// merge and remove node
-	tree.Delete(ValueIntervalTuple{value: Float(2), interval: Interval{start: 5, end: 15}})
-	tree.Delete(ValueIntervalTuple{value: Float(1), interval: Interval{start: 20, end: 40}})
+	tree.Delete(ValueIntervalTuple[V, uint32]{value: scalar[V](2), interval: Interval[uint32]{start: 5, end: 15}})
+	tree.Delete(ValueIntervalTuple[V, uint32]{value: scalar[V](1), interval: Interval[uint32]{start: 20, end: 40}})
 	// merge and remove node
-	tree.Delete(ValueIntervalTuple{value: Float(3), interval: Interval{start: 10, end: 30}})
+	tree.Delete(ValueIntervalTuple[V, uint32]{value: scalar[V](3), interval: Interval[uint32]{start: 10, end: 30}})
 	// merge and remove node
-	tree.Delete(ValueIntervalTuple{value: Float(2), interval: Interval{start: 10, end: 40}})
+	tree.Delete(ValueIntervalTuple[V, uint32]{value: scalar[V](2), interval: Interval[uint32]{start: 10, end: 40}})
 	// empty tree
 
 	// Assert
 	n0 = tree.root
 
 	assert.Len(n0.keys, 0)
-	assert.Equal(Float(0), n0.values[0])
+	assert.Equal(scalar[V](0), n0.values[0])
 	assert.Len(n0.values, 1)
-	assert.Equal(Float(0), tree.root.values[0])
+	assert.Equal(scalar[V](0), tree.root.values[0])
 	assert.Len(n0.children, 1)
-	assert.Nil(tree.root.children[0])
+	assert.Nil(tree.root.children[0].node)
 }
 
 func TestInsertRange(t *testing.T) {
-	// Arrange
-	aggregate := Aggregate{Sum, InverseSum, Identity, Float(0)}
+	forEachValueType(t, testInsertRange[Float], testInsertRange[Float64], testInsertRange[Int64], testInsertRange[Decimal])
+}
 
-	var testData []ValueIntervalTuple = []ValueIntervalTuple{
-		{interval: NewInterval(10, 40), value: Float(2)},
-		{interval: NewInterval(10, 30), value: Float(3)},
-		{interval: NewInterval(20, 40), value: Float(1)},
-		{interval: NewInterval(5, 15), value: Float(2)},
-		{interval: NewInterval(35, 45), value: Float(4)},
-		{interval: NewInterval(10, 50), value: Float(1)},
+func testInsertRange[V scalarValue[V]](t *testing.T) {
+	// Arrange
+	aggregate := SumAggregate[V]()
+
+	var testData []ValueIntervalTuple[V, uint32] = []ValueIntervalTuple[V, uint32]{
+		{interval: NewInterval[uint32](10, 40), value: scalar[V](2)},
+		{interval: NewInterval[uint32](10, 30), value: scalar[V](3)},
+		{interval: NewInterval[uint32](20, 40), value: scalar[V](1)},
+		{interval: NewInterval[uint32](5, 15), value: scalar[V](2)},
+		{interval: NewInterval[uint32](35, 45), value: scalar[V](4)},
+		{interval: NewInterval[uint32](10, 50), value: scalar[V](1)},
 	}
 
-	tree := NewSegmentTree(BRANCHING_FACTOR, aggregate)
+	tree := NewSegmentTree[V, uint32](BRANCHING_FACTOR, aggregate)
 
 	// Act
 	tree.InsertRange(testData)
 
 	// Assert
-	result := tree.GetWithinInterval(NewInterval(0, math.MaxUint32))
+	result := must(tree.GetWithinInterval(NewInterval[uint32](0, math.MaxUint32)))
 
 	assert.Len(t, result, 10)
 
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(0, 5), value: Float(0)}, result[0])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(5, 10), value: Float(2)}, result[1])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(10, 15), value: Float(8)}, result[2])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(15, 20), value: Float(6)}, result[3])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(20, 30), value: Float(7)}, result[4])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(30, 35), value: Float(4)}, result[5])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(35, 40), value: Float(8)}, result[6])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(40, 45), value: Float(5)}, result[7])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(45, 50), value: Float(1)}, result[8])
-	assert.Equal(t, ValueIntervalTuple{interval: NewInterval(50, math.MaxUint32), value: Float(0)}, result[9])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](0, 5), value: scalar[V](0)}, result[0])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](5, 10), value: scalar[V](2)}, result[1])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](10, 15), value: scalar[V](8)}, result[2])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](15, 20), value: scalar[V](6)}, result[3])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](20, 30), value: scalar[V](7)}, result[4])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](30, 35), value: scalar[V](4)}, result[5])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](35, 40), value: scalar[V](8)}, result[6])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](40, 45), value: scalar[V](5)}, result[7])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](45, 50), value: scalar[V](1)}, result[8])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](50, math.MaxUint32), value: scalar[V](0)}, result[9])
 }
 
 func TestAverageDosageScenario(t *testing.T) {
 	// Arrange
-	aggregate := Aggregate{Average, InverseAverage, Identity, AverageTuple{Sum: 0, Count: 0}}
-
-	var testData []ValueIntervalTuple = []ValueIntervalTuple{
-		{interval: NewInterval(10, 40), value: AverageTuple{2, 1}},
-		{interval: NewInterval(10, 30), value: AverageTuple{3, 1}},
-		{interval: NewInterval(20, 40), value: AverageTuple{1, 1}},
-		{interval: NewInterval(5, 15), value: AverageTuple{2, 1}},
-		{interval: NewInterval(35, 45), value: AverageTuple{4, 1}},
-		{interval: NewInterval(10, 50), value: AverageTuple{1, 1}},
+	aggregate := NewAggregate(Average[AverageTuple], InverseAverage[AverageTuple], Identity[AverageTuple], AverageTuple{Sum: 0, Count: 0})
+
+	var testData []ValueIntervalTuple[AverageTuple, uint32] = []ValueIntervalTuple[AverageTuple, uint32]{
+		{interval: NewInterval[uint32](10, 40), value: AverageTuple{2, 1}},
+		{interval: NewInterval[uint32](10, 30), value: AverageTuple{3, 1}},
+		{interval: NewInterval[uint32](20, 40), value: AverageTuple{1, 1}},
+		{interval: NewInterval[uint32](5, 15), value: AverageTuple{2, 1}},
+		{interval: NewInterval[uint32](35, 45), value: AverageTuple{4, 1}},
+		{interval: NewInterval[uint32](10, 50), value: AverageTuple{1, 1}},
 	}
 
-	tree := NewSegmentTree(BRANCHING_FACTOR, aggregate)
+	tree := NewSegmentTree[AverageTuple, uint32](BRANCHING_FACTOR, aggregate)
 
 	// Act
 	tree.InsertRange(testData)
-	result := tree.GetWithinInterval(NewInterval(0, math.MaxUint32))
+	result := must(tree.GetWithinInterval(NewInterval[uint32](0, math.MaxUint32)))
 
 	// Assert
 	assert.Len(t, result, 10)
@@ -792,3 +872,584 @@ func TestAverageDosageScenario(t *testing.T) {
 	assert.Equal(t, 1.0, result[8].value.AsFloat64())
 	assert.True(t, math.IsNaN(result[9].value.AsFloat64()))
 }
+
+func TestMomentsDosageScenario(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[MomentsTuple, uint32](BRANCHING_FACTOR, MomentsAggregate())
+
+	// Act
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(ValueIntervalTuple[MomentsTuple, uint32]{interval: tuple.interval, value: MomentsTuple{Sum: float64(tuple.value)}})
+	}
+	tree.Delete(ValueIntervalTuple[MomentsTuple, uint32]{interval: NewInterval[uint32](35, 45), value: MomentsTuple{Sum: 4}})
+	result := must(tree.GetWithinInterval(NewInterval[uint32](20, 50)))
+
+	// Assert
+	// The values 2, 3, 1 and 1 are valid within [20, 30)
+	assert.Equal(t, NewInterval[uint32](20, 30), result[0].interval)
+	assert.Equal(t, 4, result[0].value.Count)
+	assert.Equal(t, 1.75, result[0].value.Mean())
+	assert.Equal(t, 0.6875, result[0].value.Variance())
+	assert.Equal(t, 0.6875, result[0].value.AsFloat64())
+	assert.InDelta(t, 0.829156, result[0].value.StandardDeviation(), 1e-6)
+	assert.Equal(t, 0.0, must(tree.GetAtInstant(47)).Variance())
+	assert.True(t, math.IsNaN(must(tree.GetAtInstant(55)).Variance()))
+}
+
+// Inserts and deletes values with a large mean and a small variance, for which the sum of squares
+// and the square of the sum only differ in their last digits.
+func TestMomentsStableUnderInsertAndDelete(t *testing.T) {
+	// Arrange
+	random := rand.New(rand.NewSource(1))
+	tree := NewSegmentTree[MomentsTuple, uint32](BRANCHING_FACTOR, MomentsAggregate())
+	inserted := []ValueIntervalTuple[MomentsTuple, uint32]{}
+
+	// Act
+	for step := 0; step < 3000; step++ {
+		if len(inserted) > 0 && random.Intn(2) == 0 {
+			i := random.Intn(len(inserted))
+			tree.Delete(inserted[i])
+			inserted = append(inserted[:i], inserted[i+1:]...)
+		} else {
+			start := uint32(random.Intn(100))
+			tuple := ValueIntervalTuple[MomentsTuple, uint32]{
+				value:    MomentsTuple{Sum: 1e9 + random.Float64()},
+				interval: NewInterval(start, start+1+uint32(random.Intn(20))),
+			}
+			tree.Insert(tuple)
+			inserted = append(inserted, tuple)
+		}
+	}
+
+	// Assert
+	for instant := uint32(0); instant < 120; instant++ {
+		values := []float64{}
+		for _, tuple := range inserted {
+			if tuple.interval.start <= instant && instant < tuple.interval.end {
+				values = append(values, tuple.value.Sum)
+			}
+		}
+
+		moments := must(tree.GetAtInstant(instant))
+		assert.Equal(t, len(values), moments.Count, "instant %d", instant)
+		if len(values) > 0 {
+			assert.InEpsilon(t, twoPassVariance(values)+1, moments.Variance()+1, 1e-9, "instant %d", instant)
+		}
+	}
+}
+
+func twoPassVariance(values []float64) float64 {
+	mean := 0.0
+	for _, value := range values {
+		mean += value
+	}
+	mean /= float64(len(values))
+
+	variance := 0.0
+	for _, value := range values {
+		variance += (value - mean) * (value - mean)
+	}
+
+	return variance / float64(len(values))
+}
+
+func TestInsertWithUint64Timestamps(t *testing.T) {
+	forEachValueType(t, testInsertWithUint64Timestamps[Float], testInsertWithUint64Timestamps[Float64], testInsertWithUint64Timestamps[Int64], testInsertWithUint64Timestamps[Decimal])
+}
+
+func testInsertWithUint64Timestamps[V scalarValue[V]](t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[V, uint64](BRANCHING_FACTOR, SumAggregate[V]())
+	start := uint64(math.MaxUint32) + 10
+
+	// Act
+	tree.Insert(ValueIntervalTuple[V, uint64]{value: scalar[V](2), interval: NewInterval(start, start+20)})
+	result := must(tree.GetWithinInterval(NewInterval(0, MaxInstant[uint64]())))
+
+	// Assert
+	assert.Len(t, result, 3)
+	assert.Equal(t, ValueIntervalTuple[V, uint64]{interval: NewInterval(0, start), value: scalar[V](0)}, result[0])
+	assert.Equal(t, ValueIntervalTuple[V, uint64]{interval: NewInterval(start, start+20), value: scalar[V](2)}, result[1])
+	assert.Equal(t, ValueIntervalTuple[V, uint64]{interval: NewInterval(start+20, math.MaxUint64), value: scalar[V](0)}, result[2])
+}
+
+func TestMaxDosageScenario(t *testing.T) {
+	forEachValueType(t, testMaxDosageScenario[Float], testMaxDosageScenario[Float64], testMaxDosageScenario[Int64], testMaxDosageScenario[Decimal])
+}
+
+func testMaxDosageScenario[V scalarValue[V]](t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[V, uint32](BRANCHING_FACTOR, MaxAggregate(scalar[V](math.Inf(-1))))
+
+	// Act
+	tree.InsertRange(dosageTestData[V]())
+	result := must(tree.GetWithinInterval(NewInterval[uint32](0, math.MaxUint32)))
+
+	// Assert
+	assert.Len(t, result, 7)
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](0, 5), value: scalar[V](math.Inf(-1))}, result[0])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](5, 10), value: scalar[V](2)}, result[1])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](10, 30), value: scalar[V](3)}, result[2])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](30, 35), value: scalar[V](2)}, result[3])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](35, 45), value: scalar[V](4)}, result[4])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](45, 50), value: scalar[V](1)}, result[5])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](50, math.MaxUint32), value: scalar[V](math.Inf(-1))}, result[6])
+}
+
+func TestMinDosageScenario(t *testing.T) {
+	forEachValueType(t, testMinDosageScenario[Float], testMinDosageScenario[Float64], testMinDosageScenario[Int64], testMinDosageScenario[Decimal])
+}
+
+func testMinDosageScenario[V scalarValue[V]](t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[V, uint32](BRANCHING_FACTOR, MinAggregate(scalar[V](math.Inf(1))))
+
+	// Act
+	tree.InsertRange(dosageTestData[V]())
+
+	// Assert
+	assert.Equal(t, scalar[V](math.Inf(1)), must(tree.GetAtInstant(0)))
+	assert.Equal(t, scalar[V](2), must(tree.GetAtInstant(7)))
+	assert.Equal(t, scalar[V](1), must(tree.GetAtInstant(10)))
+	assert.Equal(t, scalar[V](1), must(tree.GetAtInstant(39)))
+	assert.Equal(t, scalar[V](1), must(tree.GetAtInstant(49)))
+	assert.Equal(t, scalar[V](math.Inf(1)), must(tree.GetAtInstant(50)))
+}
+
+func TestMaxDelete(t *testing.T) {
+	forEachValueType(t, testMaxDelete[Float], testMaxDelete[Float64], testMaxDelete[Int64], testMaxDelete[Decimal])
+}
+
+func testMaxDelete[V scalarValue[V]](t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[V, uint32](BRANCHING_FACTOR, MaxAggregate(scalar[V](math.Inf(-1))))
+	tree.InsertRange(dosageTestData[V]())
+
+	// Act
+	tree.Delete(ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](35, 45), value: scalar[V](4)})
+	tree.Delete(ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](35, 45), value: scalar[V](4)}) // not inserted anymore
+
+	// Assert
+	result := must(tree.GetWithinInterval(NewInterval[uint32](25, 55)))
+
+	assert.Len(t, result, 4)
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](25, 30), value: scalar[V](3)}, result[0])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](30, 40), value: scalar[V](2)}, result[1])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](40, 50), value: scalar[V](1)}, result[2])
+	assert.Equal(t, ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](50, 55), value: scalar[V](math.Inf(-1))}, result[3])
+}
+
+// Deletes a tuple from a large max tree copying on write. Only the nodes overlapping the interval
+// of the tuple are copied, the others are still shared with the version before.
+func TestMaxDeleteModifiesOnlyNodesWithinInterval(t *testing.T) {
+	// Arrange
+	aggregate := MaxAggregate(Float(math.Inf(-1)))
+	tree := NewSegmentTree[Float, uint32](4, aggregate)
+	reference := NewSegmentTree[Float, uint32](4, aggregate)
+	tuples := make([]ValueIntervalTuple[Float, uint32], 1000)
+	for i := range tuples {
+		tuples[i] = NewValueIntervalTuple(Float(i%7+1), NewInterval(uint32(2*i), uint32(2*i+3)))
+		tree.Insert(tuples[i])
+		if i != 500 {
+			reference.Insert(tuples[i])
+		}
+	}
+	tree.SetCopyOnWrite(true)
+
+	// Act
+	err := tree.Delete(tuples[500])
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, must(reference.GetWithinInterval(NewInterval[uint32](0, 2010))), must(tree.GetWithinInterval(NewInterval[uint32](0, 2010))))
+	assert.LessOrEqual(t, countNodesOfVersion(tree.root, tree.version), 30)
+	assert.Greater(t, countNodes(tree.root), 300)
+	assertBounds(t, tree.root, NewInterval(0, MaxInstant[uint32]()))
+}
+
+// Inserts tuples whose start and end fall into different full leaves, so that both leaves are
+// split by the same insert. The values are added to all intervals before any node is split.
+func TestInsertSplittingLeavesAtBothEnds(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Float, uint32](4, SumAggregate[Float]())
+	naive := NewNaiveSegmentTree[Float, uint32](SumAggregate[Float]())
+	for i := uint32(0); i < 16; i++ {
+		tuple := NewValueIntervalTuple(Float(1), NewInterval(4*i, 4*i+2))
+		tree.Insert(tuple)
+		naive.Insert(tuple)
+	}
+
+	for start := uint32(1); start < 20; start++ {
+		tuple := NewValueIntervalTuple(Float(start), NewInterval(start, start+37))
+
+		// Act
+		err := tree.Insert(tuple)
+		naive.Insert(tuple)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, must(naive.GetWithinInterval(NewInterval[uint32](0, 80))), must(tree.GetWithinInterval(NewInterval[uint32](0, 80))), "start %d", start)
+		assertBackPointers(t, tree, tree.root, nil)
+		assertBounds(t, tree.root, NewInterval(0, MaxInstant[uint32]()), start)
+	}
+}
+
+// Compares the tree against a plain array holding the aggregate of every instant.
+func TestRandomInsertDeleteMatchesReference(t *testing.T) {
+	forEachValueType(t, testRandomInsertDeleteMatchesReference[Float], testRandomInsertDeleteMatchesReference[Float64], testRandomInsertDeleteMatchesReference[Int64], testRandomInsertDeleteMatchesReference[Decimal])
+}
+
+func testRandomInsertDeleteMatchesReference[V scalarValue[V]](t *testing.T) {
+	aggregates := map[string]Aggregate[V]{
+		"sum": SumAggregate[V](),
+		"max": MaxAggregate(scalar[V](math.Inf(-1))),
+		"min": MinAggregate(scalar[V](math.Inf(1))),
+	}
+
+	for name, aggregate := range aggregates {
+		for _, branchingFactor := range []uint32{4, 5, 8} {
+			random := rand.New(rand.NewSource(int64(branchingFactor)))
+			tree := NewSegmentTree[V, uint32](branchingFactor, aggregate)
+			inserted := []ValueIntervalTuple[V, uint32]{}
+
+			for step := 0; step < 300; step++ {
+				if len(inserted) > 0 && random.Intn(3) == 0 {
+					i := random.Intn(len(inserted))
+					tree.Delete(inserted[i])
+					inserted = append(inserted[:i], inserted[i+1:]...)
+				} else {
+					start := uint32(random.Intn(100))
+					tuple := ValueIntervalTuple[V, uint32]{
+						value:    scalar[V](random.Intn(5) + 1),
+						interval: NewInterval(start, start+1+uint32(random.Intn(10))),
+					}
+					tree.Insert(tuple)
+					inserted = append(inserted, tuple)
+				}
+
+				for instant := uint32(0); instant < 115; instant++ {
+					expected := aggregate.neutralElement
+					for _, tuple := range inserted {
+						if tuple.interval.start <= instant && instant < tuple.interval.end {
+							expected = aggregate.operation(expected, tuple.value)
+						}
+					}
+
+					if !assert.Equal(t, expected, must(tree.GetAtInstant(instant)), "%s, b = %d, step %d, instant %d", name, branchingFactor, step, instant) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// Inserts and deletes tuples whose values are not exactly representable as floats. Once all tuples
+// are deleted, the tree consists of a single interval again. Float is left out, as the rounding
+// errors of float32 over such a churn are as large as the smallest values it still has to add.
+func TestChurnMergesBackToSingleInterval(t *testing.T) {
+	t.Run("Float64", testChurnMergesBackToSingleInterval[Float64])
+	t.Run("Int64", testChurnMergesBackToSingleInterval[Int64])
+	t.Run("Decimal", testChurnMergesBackToSingleInterval[Decimal])
+}
+
+func testChurnMergesBackToSingleInterval[V scalarValue[V]](t *testing.T) {
+	// Arrange
+	random := rand.New(rand.NewSource(1))
+	tree := NewSegmentTree[V, uint32](BRANCHING_FACTOR, SumAggregate[V]())
+	inserted := []ValueIntervalTuple[V, uint32]{}
+
+	// Act
+	for step := 0; step < 2000; step++ {
+		if len(inserted) > 0 && random.Intn(2) == 0 {
+			i := random.Intn(len(inserted))
+			tree.Delete(inserted[i])
+			inserted = append(inserted[:i], inserted[i+1:]...)
+		} else {
+			start := uint32(random.Intn(100))
+			tuple := ValueIntervalTuple[V, uint32]{
+				value:    scalar[V](float64(random.Intn(100)+1) / 100),
+				interval: NewInterval(start, start+1+uint32(random.Intn(30))),
+			}
+			tree.Insert(tuple)
+			inserted = append(inserted, tuple)
+		}
+	}
+	for len(inserted) > 0 {
+		i := random.Intn(len(inserted))
+		tree.Delete(inserted[i])
+		inserted = append(inserted[:i], inserted[i+1:]...)
+	}
+
+	// Assert
+	result := must(tree.GetWithinInterval(NewInterval(0, MaxInstant[uint32]())))
+	assert.Len(t, result, 1)
+	assert.True(t, tree.root.isLeaf)
+	assert.Zero(t, result[0].value.AsFloat64())
+}
+
+// Adds values far below the tolerance of Equal, to a piece without value and to a piece with a large
+// value. None of them may be dropped.
+func TestSmallValuesAreAdded(t *testing.T) {
+	// Arrange
+	floatTree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	float64Tree := NewSegmentTree[Float64, uint32](BRANCHING_FACTOR, SumAggregate[Float64]())
+	manyTree := NewSegmentTree[Float64, uint32](BRANCHING_FACTOR, SumAggregate[Float64]())
+	floatTree.Insert(NewValueIntervalTuple(Float(100000), NewInterval[uint32](0, 60)))
+
+	// Act
+	floatTree.Insert(NewValueIntervalTuple(Float(1e-6), NewInterval[uint32](70, 80)))
+	for i := 0; i < 10; i++ {
+		floatTree.Insert(NewValueIntervalTuple(Float(0.5), NewInterval[uint32](10, 20)))
+	}
+	float64Tree.Insert(NewValueIntervalTuple(Float64(1e-13), NewInterval[uint32](0, 10)))
+	for i := 0; i < 1000; i++ {
+		start := uint32(i % 50)
+		manyTree.Insert(NewValueIntervalTuple(Float64(1e-9), NewInterval(start, start+50)))
+	}
+
+	// Assert
+	assert.Equal(t, Float(1e-6), must(floatTree.GetAtInstant(75)))
+	assert.Equal(t, Float(100005), must(floatTree.GetAtInstant(15)))
+	assert.Equal(t, Float(100000), must(floatTree.GetAtInstant(25)))
+	assert.Equal(t, Float64(1e-13), must(float64Tree.GetAtInstant(5)))
+	assert.InDelta(t, 1e-6, must(manyTree.GetAtInstant(49)).AsFloat64(), 1e-18)
+	assert.InDelta(t, 20e-9, must(manyTree.GetAtInstant(0)).AsFloat64(), 1e-20)
+}
+
+// Inserts values which change the pieces by less than the tolerance of Equal. They are skipped, as
+// adjacent pieces differing by as little are merged as well.
+func TestInsertSkipsValuesWithinTolerance(t *testing.T) {
+	// Arrange
+	maxTree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, MaxAggregate(Float(math.Inf(-1))))
+	maxTree.Insert(NewValueIntervalTuple(Float(1000), NewInterval[uint32](0, 60)))
+	sumTree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	sumTree.Insert(NewValueIntervalTuple(Float(1000), NewInterval[uint32](0, 60)))
+
+	// Act
+	maxTree.Insert(NewValueIntervalTuple(Float(1000.001), NewInterval[uint32](0, 60)))
+	sumTree.Insert(NewValueIntervalTuple(Float(0.001), NewInterval[uint32](0, 60)))
+
+	// Assert
+	assert.Equal(t, Float(1000), must(maxTree.GetAtInstant(30)))
+	assert.Equal(t, Float(1000), must(sumTree.GetAtInstant(30)))
+	assert.Len(t, must(maxTree.GetWithinInterval(NewInterval[uint32](0, 60))), 1)
+}
+
+func TestEqualValues(t *testing.T) {
+	// Assert
+	assert.True(t, equalValues(Float(0.3), Float(float32(0.1)+float32(0.2))))
+	assert.True(t, equalValues(Float(1000), Float(1000.001)))
+	assert.False(t, equalValues(Float(0), Float(1e-6)))
+	assert.False(t, equalValues(Float(100000), Float(100000.5)))
+	assert.True(t, equalValues(Float64(0.3), Float64(0.1)+Float64(0.2)))
+	assert.False(t, equalValues(Float64(0), Float64(1e-13)))
+	assert.False(t, equalValues(Float64(1), Float64(1+1e-12)))
+	assert.True(t, equalValues(Float(math.Inf(-1)), Float(math.Inf(-1))))
+	assert.False(t, equalValues(Float(math.Inf(-1)), Float(3)))
+	assert.False(t, equalValues(Float(math.Inf(1)), Float(math.Inf(-1))))
+	assert.False(t, equalValues(Int64(0), Int64(1)))
+}
+
+func TestInvalidIntervalIsRejected(t *testing.T) {
+	// Arrange
+	tree := setupTree()
+	expected := must(tree.GetWithinInterval(NewInterval[uint32](0, 60)))
+	invalid := ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](30, 20), value: Float(1)}
+
+	// Act
+	insertErr := tree.Insert(invalid)
+	deleteErr := tree.Delete(invalid)
+	_, queryErr := tree.GetWithinInterval(invalid.interval)
+	_, integrateErr := tree.Integrate(invalid.interval)
+	insertRangeErr := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]()).InsertRange(append(dosageTestData[Float](), invalid))
+
+	// Assert
+	assert.ErrorIs(t, insertErr, ErrInvalidInterval)
+	assert.ErrorIs(t, deleteErr, ErrInvalidInterval)
+	assert.ErrorIs(t, queryErr, ErrInvalidInterval)
+	assert.ErrorIs(t, integrateErr, ErrInvalidInterval)
+	assert.ErrorIs(t, insertRangeErr, ErrInvalidInterval)
+	assert.Equal(t, expected, must(tree.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
+
+func TestInsertRangeIntoNonEmptyTree(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](0, 10), value: Float(1)})
+
+	// Act
+	err := tree.InsertRange(dosageTestData[Float]())
+
+	// Assert
+	assert.ErrorIs(t, err, ErrTreeNotEmpty)
+	assert.Equal(t, []ValueIntervalTuple[Float, uint32]{
+		{interval: NewInterval[uint32](0, 10), value: Float(1)},
+		{interval: NewInterval[uint32](10, 20), value: Float(0)},
+	}, must(tree.GetWithinInterval(NewInterval[uint32](0, 20))))
+}
+
+func TestFailedInsertIsReverted(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, rejectingSumAggregate(99))
+	tree.SetCopyOnWrite(true)
+	tree.InsertRange(dosageTestData[Float]())
+	expected := must(tree.GetWithinInterval(NewInterval[uint32](0, 60)))
+
+	// Act
+	err := tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](3, 62), value: Float(99)})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrCorruptTree)
+	assert.ErrorContains(t, err, "rejected value")
+	assert.Equal(t, expected, must(tree.GetWithinInterval(NewInterval[uint32](0, 60))))
+	assertBackPointers(t, tree, tree.root, nil)
+
+	assert.NoError(t, tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](0, 60), value: Float(1)}))
+	assert.Equal(t, Float(9), must(tree.GetAtInstant(12)))
+	assert.Equal(t, Float(1), must(tree.GetAtInstant(55)))
+}
+
+func TestFailedInsertWithoutCopyOnWriteRejectsFurtherOperations(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, rejectingSumAggregate(99))
+	tree.InsertRange(dosageTestData[Float]())
+
+	// Act
+	err := tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](3, 62), value: Float(99)})
+	_, queryErr := tree.GetAtInstant(12)
+	insertErr := tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](0, 60), value: Float(1)})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrCorruptTree)
+	assert.Equal(t, err, queryErr)
+	assert.Equal(t, err, insertErr)
+}
+
+func TestSetCopyOnWriteOfPagedTree(t *testing.T) {
+	// Arrange
+	tree := must(NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float](), NewMemoryNodeStore[Float, uint32](), 2))
+
+	// Act
+	err := tree.SetCopyOnWrite(true)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrPagedTree)
+	assert.False(t, tree.copyOnWrite)
+}
+
+func TestFailedInsertIntoNonInvertibleTreeIsReverted(t *testing.T) {
+	// Arrange
+	failing := MaxAggregate(Float(math.Inf(-1)))
+	failing.operation = func(x Float, y Float) Float {
+		if y == 99 {
+			panic("unexpected value")
+		}
+		return Max(x, y)
+	}
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, failing)
+	tree.SetCopyOnWrite(true)
+	tree.InsertRange(dosageTestData[Float]())
+	expected := must(tree.GetWithinInterval(NewInterval[uint32](0, 60)))
+
+	// Act
+	err := tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](0, 60), value: Float(99)})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrCorruptTree)
+	assert.Equal(t, len(dosageTestData[Float]()), tree.tuples.len())
+	assert.Equal(t, expected, must(tree.GetWithinInterval(NewInterval[uint32](0, 60))))
+	assert.NoError(t, tree.Delete(dosageTestData[Float]()[4]))
+	assert.Equal(t, Float(2), must(tree.GetAtInstant(37)))
+}
+
+// rejectingSumAggregate sums the values, but panics like a broken value type when adding the rejected value.
+func rejectingSumAggregate(rejected Float) Aggregate[Float] {
+	sum := func(x Float, y Float) Float {
+		if x == rejected || y == rejected {
+			panic("rejected value")
+		}
+		return x + y
+	}
+
+	return NewAggregate(sum, InverseSum[Float], Identity[Float], Float(0), WithName("rejecting-sum"))
+}
+
+func dosageTestData[V scalarValue[V]]() []ValueIntervalTuple[V, uint32] {
+	return []ValueIntervalTuple[V, uint32]{
+		{interval: NewInterval[uint32](10, 40), value: scalar[V](2)},
+		{interval: NewInterval[uint32](10, 30), value: scalar[V](3)},
+		{interval: NewInterval[uint32](20, 40), value: scalar[V](1)},
+		{interval: NewInterval[uint32](5, 15), value: scalar[V](2)},
+		{interval: NewInterval[uint32](35, 45), value: scalar[V](4)},
+		{interval: NewInterval[uint32](10, 50), value: scalar[V](1)},
+	}
+}
+
+// scalarValue is the constraint for the scalar value types the tests are parameterized over.
+type scalarValue[V any] interface {
+	Addable[V]
+	Comparable[V]
+}
+
+// forEachValueType runs a test parameterized over the value type for every scalar value type.
+func forEachValueType(t *testing.T, float func(*testing.T), float64 func(*testing.T), int64 func(*testing.T), decimal func(*testing.T)) {
+	t.Run("Float", float)
+	t.Run("Float64", float64)
+	t.Run("Int64", int64)
+	t.Run("Decimal", decimal)
+}
+
+// scalar converts a number to the value type. Infinities are converted to the lowest and highest
+// value of the integer types.
+func scalar[V scalarValue[V], N ~int | ~float32 | ~float64](number N) V {
+	x := float64(number)
+
+	integer := int64(x)
+	if math.IsInf(x, 1) {
+		integer = math.MaxInt64
+	} else if math.IsInf(x, -1) {
+		integer = math.MinInt64
+	}
+
+	var value any
+	switch any(*new(V)).(type) {
+	case Float:
+		value = Float(x)
+	case Float64:
+		value = Float64(x)
+	case Int64:
+		value = Int64(integer)
+	case Decimal:
+		value = NewDecimal(x)
+		if math.IsInf(x, 0) {
+			value = Decimal(integer)
+		}
+	}
+
+	return value.(V)
+}
+
+// must returns the result of a tree operation which is expected to succeed.
+func must[R any](result R, err error) R {
+	if err != nil {
+		panic(err)
+	}
+
+	return result
+}
+
+func countNodesOfVersion[V Addable[V], T Timestamp](node *Node[V, T], version uint64) int {
+	if node.version != version {
+		return 0
+	}
+
+	count := 1
+	if !node.isLeaf {
+		for _, child := range node.children {
+			count += countNodesOfVersion(child.node, version)
+		}
+	}
+
+	return count
+}
@@ -1,15 +1,43 @@
 package segmenttree
 
-type SegmentTreeImpl struct {
-	root            *Node
-	aggregate       Aggregate
+import (
+	"errors"
+	"fmt"
+)
+
+type SegmentTreeImpl[V Addable[V], T Timestamp] struct {
+	root            *Node[V, T]
+	aggregate       Aggregate[V]
 	branchingFactor uint32
+	// tuples holds all inserted tuples if the aggregate is not invertible,
+	// as the tree has to be recomputed from them on delete.
+	tuples *intervalIndex[ValueIntervalTuple[V, T], T]
+	// pool is nil unless the nodes are kept in a NodeStore
+	pool *BufferPool[V, T]
+	// If copyOnWrite is set, every modifying operation creates a new version of the tree. Nodes of
+	// older versions are never modified, but copied when needed (see Node.writableChild), so that
+	// older roots remain valid. This is the case for persistent and concurrent trees and for trees
+	// for which it was enabled with SetCopyOnWrite.
+	copyOnWrite bool
+	version     uint64
+	// failure is set once a modifying operation of a tree without copyOnWrite failed, see run.
+	failure error
+	// integrals is set if the nodes keep the integrals of their subtrees, see Integrate, and
+	// extrema if they keep the extreme values of their subtrees, see FindIntervals. Paged trees
+	// keep neither, as they are not part of the stored pages.
+	integrals bool
+	extrema   bool
+	// fillFactor is the share of the branching factor to which the nodes are filled when the tree
+	// is built at once, see SetFillFactor. If it is zero, DefaultFillFactor is used.
+	fillFactor float64
 }
 
-func NewSegmentTree(branchingFactor uint32, aggregate Aggregate) *SegmentTreeImpl {
-	tree := &SegmentTreeImpl{
+func NewSegmentTree[V Addable[V], T Timestamp](branchingFactor uint32, aggregate Aggregate[V]) *SegmentTreeImpl[V, T] {
+	tree := &SegmentTreeImpl[V, T]{
 		branchingFactor: branchingFactor,
 		aggregate:       aggregate,
+		integrals:       aggregate.isAdditive(),
+		extrema:         aggregate.isMonotone(),
 	}
 
 	tree.root = tree.newNode()
@@ -18,135 +46,536 @@ func NewSegmentTree(branchingFactor uint32, aggregate Aggregate) *SegmentTreeImp
 	return tree
 }
 
-func (t *SegmentTreeImpl) newNode() *Node {
-	node := &Node{
-		keys:     make([]uint32, 0, t.branchingFactor+1),  // + 1 to account for an interval being split into three intervals
-		values:   make([]Addable, 0, t.branchingFactor+2), // + 2 to account for an interval being split into three intervals
-		children: make([]*Node, 0, t.branchingFactor+2),   // + 2 to account for an interval being split into three intervals
+// NewPagedSegmentTree creates a tree whose nodes are kept in the store. Between operations, at most
+// bufferCapacity nodes are kept in memory. If the store already contains a tree, this tree is opened
+// and branchingFactor and aggregate have to be the same as the ones it was created with.
+//
+// It returns ErrNotInvertible for an aggregate which is not invertible, as a paged tree does not
+// keep the inserted tuples, and ErrPageOverflow if a node does not fit into a page of the store.
+// It returns ErrCorruptTree if the root of the store can not be read.
+func NewPagedSegmentTree[V Addable[V], T Timestamp](branchingFactor uint32, aggregate Aggregate[V], store NodeStore[V, T], bufferCapacity int) (*SegmentTreeImpl[V, T], error) {
+	if !aggregate.isInvertible() {
+		return nil, ErrNotInvertible
+	}
+	if limited, ok := store.(interface{ MaxBranchingFactor() uint32 }); ok && branchingFactor > limited.MaxBranchingFactor() {
+		return nil, fmt.Errorf("%w: the branching factor is at most %d", ErrPageOverflow, limited.MaxBranchingFactor())
+	}
+
+	tree := &SegmentTreeImpl[V, T]{
+		branchingFactor: branchingFactor,
+		aggregate:       aggregate,
+		pool:            newBufferPool(store, bufferCapacity),
+	}
+	tree.pool.owner = tree
+
+	if store.Root() == NoPage {
+		tree.root = tree.newNode()
+		tree.root.values = append(tree.root.values, aggregate.neutralElement)
+		// Stores the root
+		if err := tree.run(true, func() error { return nil }); err != nil {
+			return nil, err
+		}
+	} else {
+		// A corrupt page may make the decoding of its values panic
+		err := catch(func() (err error) {
+			tree.root, err = tree.pool.load(store.Root(), tree, nil, NewInterval(0, MaxInstant[T]()))
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tree, nil
+}
+
+// Flush writes all modified nodes of a paged tree to its store. The nodes of a tree whose
+// modification failed are not written, as they may be partially modified.
+func (tree *SegmentTreeImpl[V, T]) Flush() error {
+	if tree.pool == nil {
+		return nil
+	}
+	if tree.failure != nil {
+		return tree.failure
+	}
+
+	return tree.pool.Flush()
+}
+
+// Close flushes and closes the store of a paged tree.
+func (tree *SegmentTreeImpl[V, T]) Close() error {
+	if tree.pool == nil {
+		return nil
+	}
+
+	if err := tree.Flush(); err != nil {
+		return errors.Join(err, tree.pool.store.Close())
+	}
+
+	return tree.pool.store.Close()
+}
+
+func (t *SegmentTreeImpl[V, T]) newNode() *Node[V, T] {
+	node := &Node[V, T]{
+		keys:     make([]T, 0, t.branchingFactor+1),          // + 1 to account for an interval being split into three intervals
+		values:   make([]V, 0, t.branchingFactor+2),          // + 2 to account for an interval being split into three intervals
+		children: make([]link[V, T], 0, t.branchingFactor+2), // + 2 to account for an interval being split into three intervals
 		isLeaf:   true,
 		parent:   nil,
 		tree:     t,
+		version:  t.version,
+		// The summaries of a new node are computed at the end of the operation.
+		summaryStale: true,
+		bounds:       NewInterval(0, MaxInstant[T]()),
+	}
+
+	if t.pool != nil {
+		t.pool.markDirty(node)
 	}
 
 	return node
 }
 
-func (tree *SegmentTreeImpl) GetAtInstant(instant uint32) Addable {
-	return tree.lookup(tree.root, instant)
+func (tree *SegmentTreeImpl[V, T]) GetAtInstant(instant T) (V, error) {
+	var value V
+
+	err := tree.run(false, func() (err error) {
+		value, err = tree.lookup(tree.root, instant)
+		return err
+	})
+
+	return value, err
 }
 
-func (tree *SegmentTreeImpl) GetWithinInterval(interval Interval) []ValueIntervalTuple {
-	return tree.rangeQuery(tree.root, interval, tree.aggregate.neutralElement)
+// GetWithinInterval returns the pieces within the interval in O(log n + k) for k pieces. The leaf
+// of the start is found from the root, and the following leaves are reached through their lowest
+// common ancestor, which visits every node spanned by the interval once, see Cursor. A piece's
+// value combines the values of all ancestors of its leaf, so the leaves are not linked to each
+// other directly.
+func (tree *SegmentTreeImpl[V, T]) GetWithinInterval(interval Interval[T]) ([]ValueIntervalTuple[V, T], error) {
+	if err := checkIntervals(interval); err != nil {
+		return nil, err
+	}
+
+	var result []ValueIntervalTuple[V, T]
+
+	err := tree.run(false, func() (err error) {
+		result, err = tree.rangeQuery(tree.root, NewInterval(0, MaxInstant[T]()), interval, tree.aggregate.neutralElement)
+		return err
+	})
+
+	return result, err
 }
 
-func (tree *SegmentTreeImpl) Insert(value ValueIntervalTuple) {
-	valueToInsert := tree.aggregate.additionElement(value.value)
+func (tree *SegmentTreeImpl[V, T]) Insert(value ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(value); err != nil {
+		return err
+	}
 
-	tree.insert(tree.root, ValueIntervalTuple{value: valueToInsert, interval: value.interval})
+	return tree.run(true, func() error {
+		return tree.insertValue(value)
+	})
 }
 
-func (tree *SegmentTreeImpl) Delete(value ValueIntervalTuple) {
+// insertValue inserts the value within a tree operation, see Insert.
+func (tree *SegmentTreeImpl[V, T]) insertValue(value ValueIntervalTuple[V, T]) error {
+	if !tree.aggregate.isInvertible() {
+		tree.tuples = tree.tuples.insert(value, value.interval)
+	}
+
 	valueToInsert := tree.aggregate.additionElement(value.value)
 
-	valueToInsert = valueToInsert.Inverse()
+	return tree.insertAndRebalance(ValueIntervalTuple[V, T]{value: valueToInsert, interval: value.interval})
+}
+
+// Delete removes one occurrence of the tuple by inserting its inverse, which takes O(log n).
+//
+// An aggregate without an inverse, such as min or max, can not be undone this way. A tree with
+// such an aggregate keeps all inserted tuples instead, so that its memory grows with every insert
+// and not only with the number of pieces. Deleting then recomputes the pieces within the interval
+// of the tuple from the k remaining tuples overlapping it, which are found in O(log m + k) for m
+// kept tuples (see intervalIndex), and replaces the p pieces within the interval, which takes
+// O(k log k + p log n). Deleting a tuple that was never inserted is a no-op for such a tree.
+func (tree *SegmentTreeImpl[V, T]) Delete(value ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(value); err != nil {
+		return err
+	}
+
+	return tree.run(true, func() error {
+		if !tree.aggregate.isInvertible() {
+			return tree.deleteAndRecompute(value)
+		}
 
-	tree.insert(tree.root, ValueIntervalTuple{value: valueToInsert, interval: value.interval})
+		valueToInsert := tree.aggregate.additionElement(value.value)
 
+		valueToInsert = valueToInsert.Inverse()
+
+		return tree.insertAndRebalance(ValueIntervalTuple[V, T]{value: valueToInsert, interval: value.interval})
+	})
 }
 
-func (tree *SegmentTreeImpl) InsertRange(values []ValueIntervalTuple) {
-	if tree.root.size() > 0 {
-		panic("Cannot insert a range into a non-empty tree")
+func (tree *SegmentTreeImpl[V, T]) InsertRange(values []ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(values...); err != nil {
+		return err
 	}
 
-	tuples := createAndSortValueTimeTuples(tree.aggregate, values)
+	return tree.run(true, func() error {
+		if tree.root.size() > 0 {
+			return ErrTreeNotEmpty
+		}
 
-	currentValue := tree.aggregate.neutralElement
+		return tree.mergeRange(values)
+	})
+}
 
-	for _, tuple := range tuples {
+// restore rebuilds an empty tree from consecutive pieces covering the whole timeline, as
+// returned by GetWithinInterval. The values of the pieces are the aggregated values.
+func (tree *SegmentTreeImpl[V, T]) restore(pieces []ValueIntervalTuple[V, T]) error {
+	return tree.run(true, func() error {
+		if tree.root.size() > 0 {
+			return ErrTreeNotEmpty
+		}
 
-		currentValue = tree.aggregate.operation(currentValue, tuple.value)
+		if len(pieces) > 0 {
+			return tree.build(pieces)
+		}
+		return nil
+	})
+}
 
-		tree.root.insertTuple(tuple.time, currentValue)
+// SetCopyOnWrite sets whether the modifying operations copy the nodes they touch instead of
+// modifying them in place. This costs a copy of the nodes on the modified paths per operation, but
+// a tree copying on write is reverted if an operation fails, e.g. as the value type panics, rather
+// than rejecting all further operations. It can not be set for a paged tree.
+func (tree *SegmentTreeImpl[V, T]) SetCopyOnWrite(copyOnWrite bool) error {
+	if tree.pool != nil {
+		return ErrPagedTree
 	}
+
+	tree.copyOnWrite = copyOnWrite
+	return nil
 }
 
-func (tree *SegmentTreeImpl) lookup(node *Node, instant uint32) Addable {
-	var intervalIndex = node.findIntervalIndex(instant)
+// run runs a tree operation. A panic within the operation is recovered and returned as
+// ErrCorruptTree.
+//
+// If a modifying operation of a tree copying on write fails, the tree is reverted to its state
+// before the operation. As every modification copies the nodes it touches (see copyOnWrite), this
+// only requires restoring the previous root. Other trees, including paged trees, are modified in
+// place, so after a failed modification, they reject all further operations with the error of the
+// failure.
+func (tree *SegmentTreeImpl[V, T]) run(modifying bool, operation func() error) error {
+	if tree.failure != nil {
+		return tree.failure
+	}
 
-	if node.isLeaf {
-		return node.values[intervalIndex]
+	root, tuples, branchingFactor := tree.root, tree.tuples, tree.branchingFactor
+
+	err := catch(func() error {
+		if err := tree.begin(modifying); err != nil {
+			return err
+		}
+		if err := operation(); err != nil {
+			return err
+		}
+		if modifying {
+			tree.refreshSummaries()
+		}
+		return nil
+	})
+
+	if tree.pool != nil && !(modifying && errors.Is(err, ErrCorruptTree)) {
+		err = errors.Join(err, catch(func() error {
+			return tree.pool.end(tree.root)
+		}))
 	}
 
-	return tree.aggregate.operation(node.values[intervalIndex], tree.lookup(node.children[intervalIndex], instant))
+	if err != nil && modifying {
+		if tree.copyOnWrite {
+			tree.root, tree.tuples, tree.branchingFactor = root, tuples, branchingFactor
+		} else if errors.Is(err, ErrCorruptTree) {
+			tree.failure = err
+		}
+	}
+
+	return err
 }
 
-func (tree *SegmentTreeImpl) rangeQuery(node *Node, interval Interval, value Addable) []ValueIntervalTuple {
-	var result []ValueIntervalTuple = make([]ValueIntervalTuple, 0)
+// begin marks the beginning of a tree operation for the buffer pool and copies the root if the
+// tree copies on write.
+func (tree *SegmentTreeImpl[V, T]) begin(modifying bool) error {
+	if modifying && tree.copyOnWrite {
+		tree.version++
+		tree.root = tree.root.clone()
+	}
 
-	for index, nodeInterval := range node.getIntervals() {
-		intersection := interval.IntersectionWith(nodeInterval)
+	if tree.pool != nil {
+		tree.pool.begin(modifying)
+	}
 
-		if intersection.GetLength() == 0 {
+	return nil
+}
+
+// discard is called for nodes which were removed from the tree.
+func (tree *SegmentTreeImpl[V, T]) discard(node *Node[V, T]) {
+	if tree.pool != nil {
+		tree.pool.release(node)
+	}
+}
+
+func (tree *SegmentTreeImpl[V, T]) insertAndRebalance(tupleToInsert ValueIntervalTuple[V, T]) error {
+	if err := tree.insert(tree.root, tupleToInsert); err != nil {
+		return err
+	}
+	if err := tree.rebalance(tupleToInsert.interval.start); err != nil {
+		return err
+	}
+
+	return tree.rebalance(tupleToInsert.interval.end)
+}
+
+func (tree *SegmentTreeImpl[V, T]) deleteAndRecompute(value ValueIntervalTuple[V, T]) error {
+	// Aggregates such as min and max can not be undone by inserting an inverse. Instead, one
+	// occurrence of the tuple is removed and the pieces within its interval are recomputed from
+	// the remaining tuples overlapping it, see Delete.
+	tuples, found := tree.tuples.delete(value, value.interval)
+	if !found {
+		return nil
+	}
+	tree.tuples = tuples
+
+	overlapping := NewSegmentTree[V, T](tree.branchingFactor, tree.aggregate)
+	for _, tuple := range tuples.overlapping(value.interval, nil) {
+		if err := overlapping.insertAndRebalance(NewValueIntervalTuple(tree.aggregate.additionElement(tuple.value), tuple.interval)); err != nil {
+			return err
+		}
+	}
+
+	within, err := overlapping.rangeQuery(overlapping.root, NewInterval(0, MaxInstant[T]()), value.interval, tree.aggregate.neutralElement)
+	if err != nil {
+		return err
+	}
+	return tree.replacePieces(within)
+}
+
+// replacePieces replaces the pieces of the tree within an interval by the given pieces, which
+// have to be consecutive. Each piece is assigned to the nodes overlapping it, see assign, so that
+// this takes O(p log n) for p pieces.
+func (tree *SegmentTreeImpl[V, T]) replacePieces(within []ValueIntervalTuple[V, T]) error {
+	for _, piece := range within {
+		var leaves []T
+		if err := tree.assign(tree.root, piece, &leaves); err != nil {
+			return err
+		}
+
+		// As after an insert, the leaves containing the start and the end of the piece may be
+		// overfull, while the others may have adjacent equal values
+		for _, instant := range append(append([]T{piece.interval.start}, leaves...), piece.interval.end) {
+			if err := tree.rebalance(instant); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// assign sets the value of the tree within the interval of the tuple to its value. The values of
+// the ancestors of the pieces within the interval are pushed down to the leaves, which then hold
+// the value, split at the bounds of the interval as by insert. The start of every leaf visited is
+// appended to leaves, which have to be rebalanced.
+func (tree *SegmentTreeImpl[V, T]) assign(node *Node[V, T], tuple ValueIntervalTuple[V, T], leaves *[]T) error {
+	intervals := node.getIntervals()
+	if node.isLeaf {
+		*leaves = append(*leaves, node.bounds.start)
+	}
+
+	for index := 0; index < len(intervals); index++ {
+		if intervals[index].IntersectionWith(tuple.interval).GetLength() == 0 {
 			continue
 		}
 
-		if node.isLeaf {
-			newTuple := ValueIntervalTuple{
-				value:    tree.aggregate.operation(node.values[index], value),
-				interval: intersection,
+		if !node.isLeaf {
+			child, err := node.writableChild(index)
+			if err != nil {
+				return err
 			}
-			result = append(result, newTuple)
-		} else {
-			childResult := tree.rangeQuery(node.children[index], interval, tree.aggregate.operation(node.values[index], value))
-			result = append(result, childResult...)
+			if !equalValues(node.values[index], tree.aggregate.neutralElement) {
+				for i := range child.values {
+					child.values[i] = tree.aggregate.combine(node.values[index], child.values[i])
+				}
+				child.modified()
+				node.values[index] = tree.aggregate.neutralElement
+				node.modified()
+			}
+			err = tree.assign(child, tuple, leaves)
+			child.unpin()
+			if err != nil {
+				return err
+			}
+		} else if !intervals[index].IsSubsetOf(tuple.interval) {
+			// Splits the piece, keeping its value, and assigns the part within the interval next
+			node.insert(index, NewValueIntervalTuple(tree.aggregate.neutralElement, tuple.interval))
+			intervals = node.getIntervals()
+			index--
+		} else if !equalValues(node.values[index], tuple.value) {
+			node.values[index] = tuple.value
+			node.modified()
 		}
 	}
 
-	return result
+	return nil
 }
 
-func (tree *SegmentTreeImpl) insert(node *Node, tupleToInsert ValueIntervalTuple) {
-	has_next_interval := true
-	index := 0
+// lookupFrom looks up the instant below a root which is not modified anymore, e.g. the root of
+// an older version of a persistent tree. Such a root is not used with a buffer pool.
+func (tree *SegmentTreeImpl[V, T]) lookupFrom(root *Node[V, T], instant T) (V, error) {
+	var value V
+
+	err := catch(func() (err error) {
+		value, err = tree.lookup(root, instant)
+		return err
+	})
+
+	return value, err
+}
+
+// rangeQueryFrom queries a root which is not modified anymore, see lookupFrom.
+func (tree *SegmentTreeImpl[V, T]) rangeQueryFrom(root *Node[V, T], interval Interval[T]) ([]ValueIntervalTuple[V, T], error) {
+	if err := checkIntervals(interval); err != nil {
+		return nil, err
+	}
+
+	var result []ValueIntervalTuple[V, T]
+
+	err := catch(func() (err error) {
+		result, err = tree.rangeQuery(root, NewInterval(0, MaxInstant[T]()), interval, tree.aggregate.neutralElement)
+		return err
+	})
+
+	return result, err
+}
+
+func (tree *SegmentTreeImpl[V, T]) lookup(node *Node[V, T], instant T) (V, error) {
+	var intervalIndex = node.findIntervalIndex(instant)
+
+	if node.isLeaf {
+		return node.values[intervalIndex], nil
+	}
+
+	child, err := node.child(int(intervalIndex))
+	if err != nil {
+		return tree.aggregate.neutralElement, err
+	}
+	value, err := tree.lookup(child, instant)
+	child.unpin()
+	if err != nil {
+		return value, err
+	}
+
+	return tree.aggregate.combine(node.values[intervalIndex], value), nil
+}
+
+func (tree *SegmentTreeImpl[V, T]) rangeQuery(node *Node[V, T], bounds Interval[T], interval Interval[T], value V) ([]ValueIntervalTuple[V, T], error) {
+	// bounds is the interval covered by the node and value the aggregated value of its ancestors.
+	// Passing them down instead of following the parent pointers allows queries on nodes shared
+	// by several versions of the tree.
+	result := make([]ValueIntervalTuple[V, T], 0)
+	cursor := tree.newCursor(node, bounds, value, interval)
+
+	// Adjacent pieces of neighbouring leaves may have equal values, which are merged, so that the
+	// result does not depend on the structure of the tree
+	for cursor.err = cursor.first(); cursor.err == nil && cursor.Valid(); cursor.err = cursor.next() {
+		result = appendPiece(result, cursor.piece())
+	}
+
+	return result, cursor.err
+}
+
+func (tree *SegmentTreeImpl[V, T]) insert(node *Node[V, T], tupleToInsert ValueIntervalTuple[V, T]) error {
+	// Adds the value to all intervals overlapping the tuple's interval. New keys are only added
+	// to the (at most two) leaves containing the start and the end of the tuple's interval.
+	// Splitting and merging nodes is left to rebalance, so that the structure of the tree does
+	// not change while we are iterating over it.
 	intervals := node.getIntervals()
-	sizeOffset := 0
-	nodeSize := int(node.size())
-	for has_next_interval {
+
+	for index := 0; index < len(intervals); index++ {
 		nodeInterval := intervals[index]
 
 		intersection := nodeInterval.IntersectionWith(tupleToInsert.interval)
 
 		if intersection.GetLength() == 0 {
 			// Do nothing
-		} else if index < len(node.values) && node.values[index] == tree.aggregate.operation(node.values[index], tupleToInsert.value) {
-			// Do nothing
+		} else if equalValues(node.values[index], tree.aggregate.operation(node.values[index], tupleToInsert.value)) {
+			// Do nothing, e.g. for a value below the maximum of max
 		} else if nodeInterval.IsSubsetOf(tupleToInsert.interval) {
-			node.values[index] = tree.aggregate.operation(node.values[index], tupleToInsert.value)
+			node.values[index] = tree.aggregate.combine(node.values[index], tupleToInsert.value)
+			node.modified()
+		} else if !node.isLeaf {
+			child, err := node.writableChild(index)
+			if err != nil {
+				return err
+			}
+			err = tree.insert(child, tupleToInsert)
+			child.unpin()
+			if err != nil {
+				return err
+			}
 		} else {
-			if !node.isLeaf {
-				if index+1 > int(node.size()) && nodeSize > int(node.size()) {
-					sizeOffset--
-					tupleToInsert.interval.start = intervals[index].start
-				}
-				tree.insert(node.children[index+sizeOffset], tupleToInsert)
-			} else {
-				index += node.insert(index, tupleToInsert)
-				intervals = node.getIntervals() // recalculate as they might have changed
+			index += node.insert(index, tupleToInsert)
+			intervals = node.getIntervals() // recalculate as they might have changed
+		}
+	}
+
+	return nil
+}
+
+func (tree *SegmentTreeImpl[V, T]) rebalance(instant T) error {
+	// Restores the invariants of the leaf containing the instant after an insert:
+	// adjacent intervals with equal values are merged, overfull nodes are split and
+	// underfull nodes are merged with a sibling. Both split and nmerge propagate
+	// upwards on their own. As a merge can produce new adjacent equal intervals,
+	// we look up the leaf again until nothing changes anymore.
+	for {
+		leaf, err := tree.findLeaf(instant)
+		if err != nil {
+			return err
+		}
+
+		for size := leaf.size(); ; size = leaf.size() {
+			leaf.imerge()
+			if leaf.size() == size {
+				break
 			}
 		}
-		if index+1 >= len(intervals) {
-			has_next_interval = false
+		// split does not load any nodes and nmerge pins the leaf itself
+		leaf.unpin()
+
+		if leaf.size()+1 > tree.branchingFactor {
+			leaf.split()
+		} else if leaf != tree.root && leaf.size()+1 < tree.branchingFactor/2 {
+			if err := leaf.nmerge(); err != nil {
+				return err
+			}
+		} else {
+			return nil
 		}
-		index++
-	}
-	if node.size()+1 > tree.branchingFactor {
-		node.split()
 	}
-	nodeSize = int(node.size())
-	for i := 0; i < nodeSize; i++ {
-		node.imerge()
+}
+
+// findLeaf returns the leaf containing the instant. In a paged tree, the leaf is pinned, see Node.child.
+func (tree *SegmentTreeImpl[V, T]) findLeaf(instant T) (*Node[V, T], error) {
+	node := tree.root
+	node.pin()
+
+	for !node.isLeaf {
+		child, err := node.writableChild(int(node.findIntervalIndex(instant)))
+		node.unpin()
+		if err != nil {
+			return nil, err
+		}
+		node = child
 	}
 
-	node.nmerge()
+	return node, nil
 }
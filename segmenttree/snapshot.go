@@ -0,0 +1,396 @@
+package segmenttree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+var (
+	ErrInvalidSnapshot    = errors.New("invalid snapshot")
+	ErrUnsupportedVersion = errors.New("unsupported snapshot version")
+	ErrAggregateMismatch  = errors.New("snapshot was written by a tree with another aggregate")
+	ErrTimestampMismatch  = errors.New("snapshot was written by a tree with another timestamp type")
+)
+
+var snapshotMagic = []byte("SBTS")
+
+const snapshotVersion uint16 = 2
+
+// Layout of a snapshot (version 2), all integers little endian:
+//
+//	magic "SBTS" | version (2 byte) | timestamp size (1 byte) | value size (2 byte) | branching factor (4 byte)
+//	aggregate kind length (2 byte) | aggregate kind (value type and name, see Aggregate.kind) | neutral element
+//	number of tuples n (8 byte) | n tuples (only for non-invertible aggregates, see SegmentTreeImpl.tuples)
+//	nodes in pre-order | checksum (4 byte)
+//
+// A node is written as
+//
+//	is leaf (1 byte) | number of keys k (4 byte) | k keys | k+1 values
+//
+// followed by its k+1 children if it is not a leaf. The checksum is a CRC-32C over everything before it.
+
+// WriteTo writes a snapshot of the tree to w. The codec registered for V is used to encode the values.
+// The aggregate of the tree has to have a name, see WithName.
+func (tree *SegmentTreeImpl[V, T]) WriteTo(w io.Writer) (int64, error) {
+	codec, err := LookupValueCodec[V]()
+	if err != nil {
+		return 0, err
+	}
+	kind, err := tree.aggregate.kind()
+	if err != nil {
+		return 0, err
+	}
+
+	writer := newSnapshotWriter(w)
+
+	if err := tree.run(false, func() error {
+		return tree.writeSnapshot(writer, codec, kind)
+	}); err != nil {
+		return writer.count, err
+	}
+
+	return writer.finish()
+}
+
+func (tree *SegmentTreeImpl[V, T]) writeSnapshot(writer *snapshotWriter, codec ValueCodec[V], kind string) error {
+	writer.write(snapshotMagic)
+	writer.writeUint16(snapshotVersion)
+	writer.writeUint8(uint8(timestampSize[T]()))
+	writer.writeUint16(uint16(codec.Size()))
+	writer.writeUint32(tree.branchingFactor)
+
+	writer.writeUint16(uint16(len(kind)))
+	writer.write([]byte(kind))
+	writeValue(writer, codec, tree.aggregate.neutralElement)
+
+	writer.writeUint64(uint64(tree.tuples.len()))
+	buffer := make([]byte, tupleSize[V, T](codec))
+	for _, tuple := range tree.tuples.all(nil) {
+		encodeTuple(buffer, tuple, codec)
+		writer.write(buffer)
+	}
+
+	return writeNode(writer, codec, tree.root)
+}
+
+func writeNode[V Addable[V], T Timestamp](writer *snapshotWriter, codec ValueCodec[V], node *Node[V, T]) error {
+	if node.isLeaf {
+		writer.writeUint8(1)
+	} else {
+		writer.writeUint8(0)
+	}
+	writer.writeUint32(node.size())
+
+	buffer := make([]byte, timestampSize[T]())
+	for _, key := range node.keys {
+		encodeTimestamp(buffer, key)
+		writer.write(buffer)
+	}
+	for _, value := range node.values {
+		writeValue(writer, codec, value)
+	}
+
+	if !node.isLeaf {
+		for index := range node.children {
+			child, err := node.child(index)
+			if err != nil {
+				return err
+			}
+			err = writeNode(writer, codec, child)
+			child.unpin()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReadFrom replaces the content of the tree with a snapshot read from r. The branching factor
+// is taken from the snapshot, the aggregate of the tree has to have the name of the one of the snapshot.
+// If the snapshot can not be read, the tree is left unchanged. A snapshot whose keys or tuples
+// do not form a valid tree is rejected with ErrCorruptTree.
+//
+// ReadFrom does not read beyond the end of the snapshot, so r should be buffered if it is e.g. a file.
+func (tree *SegmentTreeImpl[V, T]) ReadFrom(r io.Reader) (int64, error) {
+	codec, err := LookupValueCodec[V]()
+	if err != nil {
+		return 0, err
+	}
+	expectedKind, err := tree.aggregate.kind()
+	if err != nil {
+		return 0, err
+	}
+
+	reader := newSnapshotReader(r)
+
+	if magic := reader.read(len(snapshotMagic)); reader.err == nil && string(magic) != string(snapshotMagic) {
+		return reader.count, ErrInvalidSnapshot
+	}
+	if version := reader.readUint16(); reader.err == nil && version != snapshotVersion {
+		return reader.count, ErrUnsupportedVersion
+	}
+	if size := reader.read(1); reader.err == nil && int(size[0]) != timestampSize[T]() {
+		return reader.count, ErrTimestampMismatch
+	}
+	if size := reader.readUint16(); reader.err == nil && int(size) != codec.Size() {
+		return reader.count, ErrInvalidSnapshot
+	}
+
+	branchingFactor := reader.readUint32()
+	if reader.err == nil && branchingFactor < 3 {
+		return reader.count, ErrInvalidSnapshot
+	}
+
+	kind := reader.read(int(reader.readUint16()))
+	neutralElement := readValue(reader, codec)
+	if reader.err == nil && (string(kind) != expectedKind || neutralElement != tree.aggregate.neutralElement) {
+		return reader.count, ErrAggregateMismatch
+	}
+
+	tupleCount := reader.readUint64()
+	if reader.err == nil && (tree.aggregate.isInvertible() && tupleCount > 0) {
+		return reader.count, ErrInvalidSnapshot
+	}
+
+	var tuples []ValueIntervalTuple[V, T]
+	size := tupleSize[V, T](codec)
+	for i := uint64(0); i < tupleCount && reader.err == nil; i++ {
+		tuple := decodeTuple[V, T](reader.read(size), codec)
+		if err := checkTuples(tuple); reader.err == nil && err != nil {
+			reader.err = corrupt(err)
+		}
+		tuples = append(tuples, tuple)
+	}
+
+	loaded := &SegmentTreeImpl[V, T]{
+		aggregate:       tree.aggregate,
+		branchingFactor: branchingFactor,
+	}
+	root := readNode(reader, codec, loaded, nil, NewInterval(0, MaxInstant[T]()))
+
+	if err := reader.finish(); err != nil {
+		return reader.count, err
+	}
+
+	err = tree.run(true, func() error {
+		if err := tree.discardSubtree(tree.root); err != nil {
+			return err
+		}
+		tree.branchingFactor = branchingFactor
+		tree.tuples = newTupleIndex(tuples)
+		tree.root = root
+		setTree(root, tree)
+		return nil
+	})
+
+	return reader.count, err
+}
+
+func readNode[V Addable[V], T Timestamp](reader *snapshotReader, codec ValueCodec[V], tree *SegmentTreeImpl[V, T], parent *Node[V, T], bounds Interval[T]) *Node[V, T] {
+	isLeaf := reader.read(1)
+	size := reader.readUint32()
+
+	if reader.err != nil {
+		return nil
+	}
+	if size > tree.branchingFactor || isLeaf[0] > 1 {
+		reader.err = ErrInvalidSnapshot
+		return nil
+	}
+
+	node := tree.newNode()
+	node.isLeaf = isLeaf[0] == 1
+	node.parent = parent
+	node.bounds = bounds
+
+	keySize := timestampSize[T]()
+	for i := uint32(0); i < size && reader.err == nil; i++ {
+		node.keys = append(node.keys, decodeTimestamp[T](reader.read(keySize)))
+	}
+	for i := uint32(0); i <= size && reader.err == nil; i++ {
+		node.values = append(node.values, readValue(reader, codec))
+	}
+
+	// The keys have to increase strictly within the bounds inherited from the parent, so that every
+	// piece is non-empty and lies within the interval of its parent's piece.
+	previous := bounds.start
+	for _, key := range node.keys {
+		if reader.err == nil && (key <= previous || key >= bounds.end) {
+			reader.err = corrupt(fmt.Errorf("%w: key %d out of order or outside of [%d, %d)", ErrInvalidSnapshot, key, bounds.start, bounds.end))
+		}
+		previous = key
+	}
+
+	if !node.isLeaf {
+		for i := uint32(0); i <= size && reader.err == nil; i++ {
+			node.children = append(node.children, link[V, T]{node: readNode(reader, codec, tree, node, node.getIntervalWithin(bounds, i))})
+		}
+	}
+
+	return node
+}
+
+// setTree moves the nodes read from a snapshot into the tree. The buffer pool of a paged tree
+// then writes them to new pages.
+func setTree[V Addable[V], T Timestamp](node *Node[V, T], tree *SegmentTreeImpl[V, T]) {
+	node.tree = tree
+	if tree.pool != nil {
+		tree.pool.markDirty(node)
+	}
+
+	if !node.isLeaf {
+		for _, child := range node.children {
+			setTree(child.node, tree)
+		}
+	}
+}
+
+// discardSubtree removes all nodes below and including node, e.g. to free their pages.
+func (tree *SegmentTreeImpl[V, T]) discardSubtree(node *Node[V, T]) error {
+	if tree.pool == nil {
+		return nil
+	}
+
+	if !node.isLeaf {
+		for index := range node.children {
+			child, err := node.child(index)
+			if err != nil {
+				return err
+			}
+			if err := tree.discardSubtree(child); err != nil {
+				return err
+			}
+		}
+	}
+	tree.discard(node)
+
+	return nil
+}
+
+// snapshotWriter counts and checksums everything written. After the first error, nothing is written anymore.
+type snapshotWriter struct {
+	writer *bufio.Writer
+	hash   hash.Hash32
+	count  int64
+	err    error
+}
+
+func newSnapshotWriter(w io.Writer) *snapshotWriter {
+	return &snapshotWriter{
+		writer: bufio.NewWriter(w),
+		hash:   crc32.New(crcTable),
+	}
+}
+
+func (writer *snapshotWriter) write(buffer []byte) {
+	if writer.err != nil {
+		return
+	}
+
+	n, err := writer.writer.Write(buffer)
+	writer.hash.Write(buffer[:n])
+	writer.count += int64(n)
+	writer.err = err
+}
+
+func (writer *snapshotWriter) writeUint8(value uint8) {
+	writer.write([]byte{value})
+}
+
+func (writer *snapshotWriter) writeUint16(value uint16) {
+	writer.write(binary.LittleEndian.AppendUint16(nil, value))
+}
+
+func (writer *snapshotWriter) writeUint32(value uint32) {
+	writer.write(binary.LittleEndian.AppendUint32(nil, value))
+}
+
+func (writer *snapshotWriter) writeUint64(value uint64) {
+	writer.write(binary.LittleEndian.AppendUint64(nil, value))
+}
+
+func writeValue[V any](writer *snapshotWriter, codec ValueCodec[V], value V) {
+	buffer := make([]byte, codec.Size())
+	codec.Encode(buffer, value)
+	writer.write(buffer)
+}
+
+func (writer *snapshotWriter) finish() (int64, error) {
+	checksum := writer.hash.Sum32()
+	writer.writeUint32(checksum)
+
+	if writer.err == nil {
+		writer.err = writer.writer.Flush()
+	}
+
+	return writer.count, writer.err
+}
+
+// snapshotReader counts and checksums everything read. After the first error, only zeros are returned.
+type snapshotReader struct {
+	reader io.Reader
+	hash   hash.Hash32
+	count  int64
+	err    error
+}
+
+func newSnapshotReader(r io.Reader) *snapshotReader {
+	return &snapshotReader{
+		reader: r,
+		hash:   crc32.New(crcTable),
+	}
+}
+
+func (reader *snapshotReader) read(size int) []byte {
+	buffer := make([]byte, size)
+	if reader.err != nil {
+		return buffer
+	}
+
+	n, err := io.ReadFull(reader.reader, buffer)
+	reader.hash.Write(buffer[:n])
+	reader.count += int64(n)
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = ErrInvalidSnapshot
+	}
+	reader.err = err
+
+	return buffer
+}
+
+func (reader *snapshotReader) readUint16() uint16 {
+	return binary.LittleEndian.Uint16(reader.read(2))
+}
+
+func (reader *snapshotReader) readUint32() uint32 {
+	return binary.LittleEndian.Uint32(reader.read(4))
+}
+
+func (reader *snapshotReader) readUint64() uint64 {
+	return binary.LittleEndian.Uint64(reader.read(8))
+}
+
+func readValue[V any](reader *snapshotReader, codec ValueCodec[V]) V {
+	return codec.Decode(reader.read(codec.Size()))
+}
+
+func (reader *snapshotReader) finish() error {
+	if reader.err != nil {
+		return reader.err
+	}
+
+	expected := reader.hash.Sum32()
+	if checksum := reader.readUint32(); reader.err == nil && checksum != expected {
+		return ErrInvalidSnapshot
+	}
+
+	return reader.err
+}
@@ -0,0 +1,387 @@
+package segmenttree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// counter is a user defined value type for the codec registry tests.
+type counter int64
+
+func (x counter) Add(y counter) counter      { return x + y }
+func (x counter) Inverse() counter           { return -x }
+func (x counter) Subtract(y counter) counter { return x - y }
+func (x counter) AsFloat64() float64         { return float64(x) }
+
+type counterCodec struct{}
+
+func (counterCodec) Size() int { return 8 }
+func (counterCodec) Encode(buffer []byte, value counter) {
+	binary.LittleEndian.PutUint64(buffer, uint64(value))
+}
+func (counterCodec) Decode(buffer []byte) counter {
+	return counter(binary.LittleEndian.Uint64(buffer))
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	// Arrange
+	tree := setupTree()
+	var buffer bytes.Buffer
+
+	// Act
+	written, writeErr := tree.WriteTo(&buffer)
+	loaded := NewSegmentTree[Float, uint32](10, SumAggregate[Float]())
+	read, readErr := loaded.ReadFrom(&buffer)
+
+	// Assert
+	assert.NoError(t, writeErr)
+	assert.NoError(t, readErr)
+	assert.Equal(t, written, read)
+	assert.Equal(t, BRANCHING_FACTOR, loaded.branchingFactor)
+	assertSameNodes(t, tree.root, loaded.root)
+	assertBackPointers(t, loaded, loaded.root, nil)
+	assert.Equal(t, must(tree.GetWithinInterval(NewInterval[uint32](0, math.MaxUint32))), must(loaded.GetWithinInterval(NewInterval[uint32](0, math.MaxUint32))))
+}
+
+func TestSnapshotRoundTripWithMoments(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[MomentsTuple, uint32](BRANCHING_FACTOR, MomentsAggregate())
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(ValueIntervalTuple[MomentsTuple, uint32]{interval: tuple.interval, value: MomentsTuple{Sum: float64(tuple.value) / 3}})
+	}
+	var buffer bytes.Buffer
+
+	// Act
+	tree.WriteTo(&buffer)
+	loaded := NewSegmentTree[MomentsTuple, uint32](BRANCHING_FACTOR, MomentsAggregate())
+	_, err := loaded.ReadFrom(&buffer)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, must(tree.GetWithinInterval(NewInterval[uint32](0, 60))), must(loaded.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
+
+func TestSnapshotRoundTripWithDecimal(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Decimal, uint32](BRANCHING_FACTOR, SumAggregate[Decimal]())
+	tree.InsertRange(dosageTestData[Decimal]())
+	var buffer bytes.Buffer
+
+	// Act
+	tree.WriteTo(&buffer)
+	loaded := NewSegmentTree[Decimal, uint32](BRANCHING_FACTOR, SumAggregate[Decimal]())
+	_, err := loaded.ReadFrom(&buffer)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, must(tree.GetWithinInterval(NewInterval[uint32](0, 60))), must(loaded.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
+
+func TestSnapshotLoadedTreeCanBeModified(t *testing.T) {
+	// Arrange
+	tree := setupTree()
+	var buffer bytes.Buffer
+	tree.WriteTo(&buffer)
+	loaded := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+	loaded.ReadFrom(&buffer)
+
+	// Act
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Delete(tuple)
+		loaded.Delete(tuple)
+	}
+	tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](3, 33), value: Float(3)})
+	loaded.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](3, 33), value: Float(3)})
+
+	// Assert
+	assert.Equal(t, must(tree.GetWithinInterval(NewInterval[uint32](0, 60))), must(loaded.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
+
+func TestSnapshotWithNonInvertibleAggregate(t *testing.T) {
+	// Arrange
+	aggregate := MaxAggregate(Float(math.Inf(-1)))
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, aggregate)
+	for _, tuple := range dosageTestData[Float]() {
+		tree.Insert(tuple)
+	}
+	var buffer bytes.Buffer
+	tree.WriteTo(&buffer)
+
+	// Act
+	loaded := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, aggregate)
+	_, err := loaded.ReadFrom(&buffer)
+	tuples := loaded.tuples.all(nil)
+	loaded.Delete(dosageTestData[Float]()[4])
+
+	// Assert
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, dosageTestData[Float](), tuples)
+	assert.Equal(t, Float(1), must(loaded.GetAtInstant(42)))
+}
+
+func TestSnapshotWithAverageTuple(t *testing.T) {
+	// Arrange
+	aggregate := NewAggregate(Average[AverageTuple], InverseAverage[AverageTuple], Identity[AverageTuple], AverageTuple{}, WithName("average"))
+	tree := NewSegmentTree[AverageTuple, uint64](BRANCHING_FACTOR, aggregate)
+	for i, tuple := range dosageTestData[Float]() {
+		tree.Insert(ValueIntervalTuple[AverageTuple, uint64]{
+			value:    AverageTuple{Sum: int(tuple.value), Count: 1},
+			interval: NewInterval(uint64(tuple.interval.start), uint64(tuple.interval.end)+uint64(i)<<40),
+		})
+	}
+	var buffer bytes.Buffer
+	tree.WriteTo(&buffer)
+
+	// Act
+	loaded := NewSegmentTree[AverageTuple, uint64](BRANCHING_FACTOR, aggregate)
+	_, err := loaded.ReadFrom(&buffer)
+
+	// Assert
+	assert.NoError(t, err)
+	assertSameNodes(t, tree.root, loaded.root)
+}
+
+func TestSnapshotWithRegisteredUserCodec(t *testing.T) {
+	// Arrange
+	aggregate := NewAggregate(Count[counter], InverseCount[counter], Identity[counter], counter(0), WithName("count"))
+	tree := NewSegmentTree[counter, uint16](BRANCHING_FACTOR, aggregate)
+	tree.Insert(ValueIntervalTuple[counter, uint16]{value: 1, interval: NewInterval[uint16](5, 10)})
+	tree.Insert(ValueIntervalTuple[counter, uint16]{value: 1, interval: NewInterval[uint16](7, 12)})
+	var buffer bytes.Buffer
+
+	// Act
+	_, errWithoutCodec := tree.WriteTo(&buffer)
+	RegisterValueCodec[counter](counterCodec{})
+	_, writeErr := tree.WriteTo(&buffer)
+	loaded := NewSegmentTree[counter, uint16](BRANCHING_FACTOR, aggregate)
+	_, readErr := loaded.ReadFrom(&buffer)
+
+	// Assert
+	assert.ErrorIs(t, errWithoutCodec, ErrNoValueCodec)
+	assert.NoError(t, writeErr)
+	assert.NoError(t, readErr)
+	assert.Equal(t, counter(2), must(loaded.GetAtInstant(8)))
+	assert.Equal(t, counter(1), must(loaded.GetAtInstant(11)))
+}
+
+func TestSnapshotWithOtherAggregate(t *testing.T) {
+	// Arrange
+	var buffer bytes.Buffer
+	setupTree().WriteTo(&buffer)
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, MaxAggregate(Float(math.Inf(-1))))
+
+	// Act
+	_, err := tree.ReadFrom(&buffer)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrAggregateMismatch)
+	assert.Equal(t, uint32(0), tree.root.size())
+}
+
+func TestSnapshotIntoTreeWithSameAggregateName(t *testing.T) {
+	// Arrange
+	var buffer bytes.Buffer
+	source := setupTree()
+	source.WriteTo(&buffer)
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, NewAggregate(Sum[Float], InverseSum[Float], Identity[Float], Float(0), WithName("sum")))
+
+	// Act
+	_, err := tree.ReadFrom(&buffer)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, must(source.GetWithinInterval(NewInterval[uint32](0, 60))), must(tree.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
+
+func TestSnapshotWithUnnamedAggregate(t *testing.T) {
+	// Arrange
+	var buffer bytes.Buffer
+	aggregate := NewAggregate(Sum[Float], InverseSum[Float], Identity[Float], Float(0))
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, aggregate)
+	tree.Insert(NewValueIntervalTuple(Float(1), NewInterval[uint32](5, 10)))
+	setupTree().WriteTo(&buffer)
+
+	// Act
+	_, writeErr := tree.WriteTo(io.Discard)
+	_, readErr := tree.ReadFrom(&buffer)
+
+	// Assert
+	assert.ErrorIs(t, writeErr, ErrUnnamedAggregate)
+	assert.ErrorIs(t, readErr, ErrUnnamedAggregate)
+	assert.Equal(t, Float(1), must(tree.GetAtInstant(7)))
+}
+
+func TestSnapshotWithOtherTimestampType(t *testing.T) {
+	// Arrange
+	var buffer bytes.Buffer
+	setupTree().WriteTo(&buffer)
+	tree := NewSegmentTree[Float, uint64](BRANCHING_FACTOR, SumAggregate[Float]())
+
+	// Act
+	_, err := tree.ReadFrom(&buffer)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrTimestampMismatch)
+}
+
+func TestSnapshotWithUnsupportedVersion(t *testing.T) {
+	// Arrange
+	var buffer bytes.Buffer
+	setupTree().WriteTo(&buffer)
+	snapshot := buffer.Bytes()
+	binary.LittleEndian.PutUint16(snapshot[4:], snapshotVersion+1)
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+
+	// Act
+	_, err := tree.ReadFrom(bytes.NewReader(snapshot))
+
+	// Assert
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+}
+
+func TestSnapshotWithCorruptedData(t *testing.T) {
+	// Arrange
+	var buffer bytes.Buffer
+	setupTree().WriteTo(&buffer)
+	snapshot := buffer.Bytes()
+
+	corrupted := append([]byte{}, snapshot...)
+	corrupted[len(corrupted)-10] ^= 1
+	truncated := snapshot[:len(snapshot)-10]
+
+	for _, data := range [][]byte{corrupted, truncated, []byte("no snapshot")} {
+		tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+
+		// Act
+		_, err := tree.ReadFrom(bytes.NewReader(data))
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidSnapshot)
+		assert.Equal(t, uint32(0), tree.root.size())
+	}
+}
+
+func TestSnapshotWithKeyOutsideParentBounds(t *testing.T) {
+	// Arrange
+	written := setupTree()
+	// The keys of the first child stay increasing, but exceed the first key of the root
+	child := written.root.children[0].node
+	child.keys[len(child.keys)-1] = written.root.keys[0] + 1
+	var buffer bytes.Buffer
+	written.WriteTo(&buffer)
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, SumAggregate[Float]())
+
+	// Act
+	_, err := tree.ReadFrom(&buffer)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrCorruptTree)
+	assert.Equal(t, uint32(0), tree.root.size())
+}
+
+func TestSnapshotWithInvalidTupleInterval(t *testing.T) {
+	// Arrange
+	aggregate := MaxAggregate(Float(math.Inf(-1)))
+	written := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, aggregate)
+	written.Insert(NewValueIntervalTuple(Float(1), NewInterval[uint32](10, 20)))
+	invalid := ValueIntervalTuple[Float, uint32]{interval: Interval[uint32]{start: 20, end: 10}, value: Float(2)}
+	written.tuples = written.tuples.insert(invalid, invalid.interval)
+	var buffer bytes.Buffer
+	written.WriteTo(&buffer)
+	tree := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, aggregate)
+
+	// Act
+	_, err := tree.ReadFrom(&buffer)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrCorruptTree)
+	assert.Equal(t, 0, tree.tuples.len())
+}
+
+func TestSnapshotIntoPagedTree(t *testing.T) {
+	// Arrange
+	var buffer bytes.Buffer
+	setupTree().WriteTo(&buffer)
+	aggregate := SumAggregate[Float]()
+	store, _ := OpenFileNodeStore[Float, uint32](filepath.Join(t.TempDir(), "tree.db"), DefaultPageSize, FloatCodec{})
+	tree := must(NewPagedSegmentTree[Float, uint32](BRANCHING_FACTOR, aggregate, store, 2))
+	tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](100, 200), value: Float(1)})
+
+	// Act
+	_, err := tree.ReadFrom(&buffer)
+	var written bytes.Buffer
+	tree.WriteTo(&written)
+	loaded := NewSegmentTree[Float, uint32](BRANCHING_FACTOR, aggregate)
+	loaded.ReadFrom(&written)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, Float(0), must(tree.GetAtInstant(150)))
+	for _, td := range testDataGetAtInstant {
+		assert.Equal(t, td.expectedValue, must(tree.GetAtInstant(td.instant)), "instant %d", td.instant)
+	}
+	assertSameNodes(t, setupTree().root, loaded.root)
+	assert.LessOrEqual(t, tree.pool.Len(), 2)
+	assert.NoError(t, tree.Close())
+}
+
+func assertSameNodes[V Addable[V], T Timestamp](t *testing.T, expected *Node[V, T], actual *Node[V, T]) {
+	assert.Equal(t, expected.isLeaf, actual.isLeaf)
+	assert.Equal(t, expected.keys, actual.keys)
+	assert.Equal(t, expected.values, actual.values)
+
+	if !expected.isLeaf && assert.Equal(t, len(expected.children), len(actual.children)) {
+		for i := range expected.children {
+			assertSameNodes(t, expected.children[i].node, actual.children[i].node)
+		}
+	}
+}
+
+func assertBackPointers[V Addable[V], T Timestamp](t *testing.T, tree *SegmentTreeImpl[V, T], node *Node[V, T], parent *Node[V, T]) {
+	assert.Same(t, tree, node.tree)
+	assert.Same(t, parent, node.parent)
+
+	if !node.isLeaf {
+		for _, child := range node.children {
+			assertBackPointers(t, tree, child.node, node)
+		}
+	}
+}
+
+func TestSnapshotRoundTripWithDistinctSketch(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[DistinctSketch, uint32](BRANCHING_FACTOR, DistinctCountAggregate())
+	tree.InsertRange(medicationTestData())
+	var buffer bytes.Buffer
+
+	// Act
+	tree.WriteTo(&buffer)
+	loaded := NewSegmentTree[DistinctSketch, uint32](BRANCHING_FACTOR, DistinctCountAggregate())
+	_, err := loaded.ReadFrom(&buffer)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, must(tree.GetWithinInterval(NewInterval[uint32](0, 60))), must(loaded.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
+
+func TestSnapshotRoundTripWithHistogram(t *testing.T) {
+	// Arrange
+	tree := NewSegmentTree[Histogram, uint32](BRANCHING_FACTOR, HistogramAggregate())
+	tree.InsertRange(prescriptionTestData())
+	var buffer bytes.Buffer
+
+	// Act
+	tree.WriteTo(&buffer)
+	loaded := NewSegmentTree[Histogram, uint32](BRANCHING_FACTOR, HistogramAggregate())
+	_, err := loaded.ReadFrom(&buffer)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, must(tree.GetWithinInterval(NewInterval[uint32](0, 60))), must(loaded.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
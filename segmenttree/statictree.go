@@ -0,0 +1,241 @@
+package segmenttree
+
+import (
+	"errors"
+	"slices"
+	"sort"
+)
+
+// StaticSegmentTree is the textbook segment tree kept in an array. The endpoints of all inserted
+// intervals split the timeline into elementary intervals, which are the leaves of a complete
+// binary tree. A tuple adds its value to the O(log m) nodes covering its interval and a lookup
+// aggregates the values on the path from a leaf to the root.
+//
+// Inserting or deleting a tuple whose endpoints are already known takes O(log m). A new endpoint
+// splits an elementary interval, so the array is rebuilt from all tuples in O(n log m). The tree
+// thus suits workloads which load most tuples with InsertRange and mostly query them afterwards.
+type StaticSegmentTree[V Addable[V], T Timestamp] struct {
+	aggregate Aggregate[V]
+	// boundaries holds the sorted start instants of the elementary intervals, followed by MaxInstant.
+	boundaries []T
+	// values holds the nodes of the tree, the root at index 1 and the children of node i at 2i
+	// and 2i + 1. The leaf of elementary interval i is at index len(boundaries) - 1 + i.
+	values []V
+	// entries holds the inserted tuples with their values transformed by the addition element.
+	entries []ValueIntervalTuple[V, T]
+}
+
+func NewStaticSegmentTree[V Addable[V], T Timestamp](aggregate Aggregate[V]) *StaticSegmentTree[V, T] {
+	static := &StaticSegmentTree[V, T]{
+		aggregate:  aggregate,
+		boundaries: []T{0, MaxInstant[T]()},
+	}
+
+	static.rebuild()
+
+	return static
+}
+
+func (static *StaticSegmentTree[V, T]) GetAtInstant(instant T) (V, error) {
+	var value V
+
+	err := catch(func() error {
+		value = static.lookup(static.elementaryIndex(instant))
+		return nil
+	})
+
+	return value, err
+}
+
+func (static *StaticSegmentTree[V, T]) GetWithinInterval(interval Interval[T]) ([]ValueIntervalTuple[V, T], error) {
+	if err := checkIntervals(interval); err != nil {
+		return nil, err
+	}
+
+	var result []ValueIntervalTuple[V, T]
+
+	err := catch(func() error {
+		result = []ValueIntervalTuple[V, T]{}
+
+		if interval.GetLength() == 0 {
+			return nil
+		}
+
+		for i := static.elementaryIndex(interval.start); i < static.leaves() && static.boundaries[i] < interval.end; i++ {
+			result = appendPiece(result, ValueIntervalTuple[V, T]{
+				value:    static.lookup(i),
+				interval: interval.IntersectionWith(NewInterval(static.boundaries[i], static.boundaries[i+1])),
+			})
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+func (static *StaticSegmentTree[V, T]) Insert(value ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(value); err != nil {
+		return err
+	}
+
+	return static.modify(func() error {
+		entry := ValueIntervalTuple[V, T]{
+			value:    static.aggregate.additionElement(value.value),
+			interval: value.interval,
+		}
+
+		static.entries = append(static.entries, entry)
+		static.add(entry)
+		return nil
+	})
+}
+
+// Delete removes one occurrence of the tuple. As in SegmentTreeImpl, deleting a tuple that was
+// never inserted subtracts it if the aggregate is invertible and is a no-op otherwise.
+func (static *StaticSegmentTree[V, T]) Delete(value ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(value); err != nil {
+		return err
+	}
+
+	return static.modify(func() error {
+		entry := ValueIntervalTuple[V, T]{
+			value:    static.aggregate.additionElement(value.value),
+			interval: value.interval,
+		}
+		i := slices.Index(static.entries, entry)
+
+		if !static.aggregate.isInvertible() {
+			if i >= 0 {
+				static.entries = append(static.entries[:i:i], static.entries[i+1:]...)
+				static.rebuild()
+			}
+			return nil
+		}
+
+		entry.value = entry.value.Inverse()
+		if i >= 0 {
+			static.entries = append(static.entries[:i:i], static.entries[i+1:]...)
+		} else {
+			static.entries = append(static.entries, entry)
+		}
+		static.add(entry)
+		return nil
+	})
+}
+
+// InsertRange loads the tuples into an empty tree in O(n log n).
+func (static *StaticSegmentTree[V, T]) InsertRange(values []ValueIntervalTuple[V, T]) error {
+	if err := checkTuples(values...); err != nil {
+		return err
+	}
+	if len(static.entries) > 0 {
+		return ErrTreeNotEmpty
+	}
+
+	return static.modify(func() error {
+		for _, value := range values {
+			static.entries = append(static.entries, ValueIntervalTuple[V, T]{
+				value:    static.aggregate.additionElement(value.value),
+				interval: value.interval,
+			})
+			static.boundaries = append(static.boundaries, value.interval.start, value.interval.end)
+		}
+
+		slices.Sort(static.boundaries)
+		static.boundaries = slices.Compact(static.boundaries)
+		static.rebuild()
+		return nil
+	})
+}
+
+// modify runs a modification. If it fails, the tree is rebuilt from the tuples it contained before.
+func (static *StaticSegmentTree[V, T]) modify(modification func() error) error {
+	entries := static.entries
+
+	err := catch(modification)
+	if err != nil {
+		static.entries = entries
+		err = errors.Join(err, catch(func() error {
+			static.rebuild()
+			return nil
+		}))
+	}
+
+	return err
+}
+
+// add adds an entry which is already part of the entries to the tree.
+func (static *StaticSegmentTree[V, T]) add(entry ValueIntervalTuple[V, T]) {
+	if entry.interval.GetLength() == 0 {
+		return
+	}
+
+	start, startFound := slices.BinarySearch(static.boundaries, entry.interval.start)
+	end, endFound := slices.BinarySearch(static.boundaries, entry.interval.end)
+
+	if !startFound || !endFound {
+		static.boundaries = append(static.boundaries, entry.interval.start, entry.interval.end)
+		slices.Sort(static.boundaries)
+		static.boundaries = slices.Compact(static.boundaries)
+		static.rebuild()
+		return
+	}
+
+	static.update(start, end, entry.value)
+}
+
+// rebuild creates the array for the current boundaries and adds all entries to it.
+func (static *StaticSegmentTree[V, T]) rebuild() {
+	static.values = make([]V, 2*static.leaves())
+	for i := range static.values {
+		static.values[i] = static.aggregate.neutralElement
+	}
+
+	for _, entry := range static.entries {
+		if entry.interval.GetLength() == 0 {
+			continue
+		}
+
+		start, _ := slices.BinarySearch(static.boundaries, entry.interval.start)
+		end, _ := slices.BinarySearch(static.boundaries, entry.interval.end)
+		static.update(start, end, entry.value)
+	}
+}
+
+// update adds the value to the nodes covering the elementary intervals from start up to end.
+func (static *StaticSegmentTree[V, T]) update(start int, end int, value V) {
+	for start, end = start+static.leaves(), end+static.leaves(); start < end; start, end = start/2, end/2 {
+		if start%2 == 1 {
+			static.values[start] = static.aggregate.operation(static.values[start], value)
+			start++
+		}
+		if end%2 == 1 {
+			end--
+			static.values[end] = static.aggregate.operation(static.values[end], value)
+		}
+	}
+}
+
+// lookup aggregates the values on the path from the leaf of the elementary interval to the root.
+func (static *StaticSegmentTree[V, T]) lookup(index int) V {
+	value := static.aggregate.neutralElement
+
+	for node := index + static.leaves(); node > 0; node /= 2 {
+		value = static.aggregate.operation(value, static.values[node])
+	}
+
+	return value
+}
+
+// elementaryIndex returns the index of the elementary interval containing the instant.
+func (static *StaticSegmentTree[V, T]) elementaryIndex(instant T) int {
+	index := sort.Search(len(static.boundaries), func(i int) bool {
+		return static.boundaries[i] > instant
+	}) - 1
+
+	return min(index, static.leaves()-1)
+}
+
+func (static *StaticSegmentTree[V, T]) leaves() int {
+	return len(static.boundaries) - 1
+}
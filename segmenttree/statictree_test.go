@@ -0,0 +1,107 @@
+package segmenttree
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticTreeDosageScenario(t *testing.T) {
+	forEachValueType(t, testStaticTreeDosageScenario[Float], testStaticTreeDosageScenario[Float64], testStaticTreeDosageScenario[Int64], testStaticTreeDosageScenario[Decimal])
+}
+
+func testStaticTreeDosageScenario[V scalarValue[V]](t *testing.T) {
+	// Arrange
+	inserted := NewStaticSegmentTree[V, uint32](SumAggregate[V]())
+	loaded := NewStaticSegmentTree[V, uint32](SumAggregate[V]())
+
+	// Act
+	for _, tuple := range dosageTestData[V]() {
+		inserted.Insert(tuple)
+	}
+	err := loaded.InsertRange(dosageTestData[V]())
+
+	// Assert
+	assert.NoError(t, err)
+	for _, td := range testDataGetAtInstant {
+		assert.Equal(t, scalar[V](td.expectedValue), must(inserted.GetAtInstant(td.instant)), "instant %d", td.instant)
+		assert.Equal(t, scalar[V](td.expectedValue), must(loaded.GetAtInstant(td.instant)), "instant %d", td.instant)
+	}
+	assert.Equal(t, must(inserted.GetWithinInterval(NewInterval[uint32](0, 60))), must(loaded.GetWithinInterval(NewInterval[uint32](0, 60))))
+	assert.Equal(t, []ValueIntervalTuple[V, uint32]{
+		{interval: NewInterval[uint32](12, 15), value: scalar[V](8)},
+		{interval: NewInterval[uint32](15, 18), value: scalar[V](6)},
+	}, must(loaded.GetWithinInterval(NewInterval[uint32](12, 18))))
+}
+
+func TestStaticTreeRebuildsOnlyForNewEndpoints(t *testing.T) {
+	// Arrange
+	tree := NewStaticSegmentTree[Float, uint32](SumAggregate[Float]())
+	tree.InsertRange(dosageTestData[Float]())
+	values := tree.values
+
+	// Act
+	tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](10, 35), value: Float(1)})
+	tree.Delete(dosageTestData[Float]()[1])
+	unchanged := &values[0] == &tree.values[0]
+	tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](12, 35), value: Float(1)})
+
+	// Assert
+	assert.True(t, unchanged)
+	assert.Equal(t, []uint32{0, 5, 10, 12, 15, 20, 30, 35, 40, 45, 50, MaxInstant[uint32]()}, tree.boundaries)
+	assert.Equal(t, Float(7), must(tree.GetAtInstant(12)))
+	assert.Equal(t, Float(6), must(tree.GetAtInstant(28)))
+}
+
+func TestStaticTreeMaxDelete(t *testing.T) {
+	forEachValueType(t, testStaticTreeMaxDelete[Float], testStaticTreeMaxDelete[Float64], testStaticTreeMaxDelete[Int64], testStaticTreeMaxDelete[Decimal])
+}
+
+func testStaticTreeMaxDelete[V scalarValue[V]](t *testing.T) {
+	// Arrange
+	tree := NewStaticSegmentTree[V, uint32](MaxAggregate(scalar[V](math.Inf(-1))))
+	tree.InsertRange(dosageTestData[V]())
+
+	// Act
+	tree.Delete(dosageTestData[V]()[4])
+	tree.Delete(ValueIntervalTuple[V, uint32]{interval: NewInterval[uint32](0, 60), value: scalar[V](9)})
+
+	// Assert
+	assert.Equal(t, scalar[V](3), must(tree.GetAtInstant(12)))
+	assert.Equal(t, scalar[V](2), must(tree.GetAtInstant(37)))
+	assert.Equal(t, scalar[V](1), must(tree.GetAtInstant(42)))
+	assert.Equal(t, scalar[V](math.Inf(-1)), must(tree.GetAtInstant(55)))
+}
+
+func TestStaticTreeFailedInsertIsReverted(t *testing.T) {
+	// Arrange
+	tree := NewStaticSegmentTree[Float, uint32](rejectingSumAggregate(99))
+	tree.InsertRange(dosageTestData[Float]())
+	expected := must(tree.GetWithinInterval(NewInterval[uint32](0, 60)))
+
+	// Act
+	known := tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](10, 40), value: Float(99)})
+	unknown := tree.Insert(ValueIntervalTuple[Float, uint32]{interval: NewInterval[uint32](3, 62), value: Float(99)})
+
+	// Assert
+	assert.ErrorIs(t, known, ErrCorruptTree)
+	assert.ErrorIs(t, unknown, ErrCorruptTree)
+	assert.Len(t, tree.entries, len(dosageTestData[Float]()))
+	assert.Equal(t, expected, must(tree.GetWithinInterval(NewInterval[uint32](0, 60))))
+}
+
+func TestStaticTreeInsertRangeIntoNonEmptyTree(t *testing.T) {
+	// Arrange
+	tree := NewStaticSegmentTree[Float, uint32](SumAggregate[Float]())
+	tree.Insert(dosageTestData[Float]()[0])
+
+	// Act
+	err := tree.InsertRange(dosageTestData[Float]())
+	_, invalid := tree.GetWithinInterval(NewInterval[uint32](30, 20))
+
+	// Assert
+	assert.ErrorIs(t, err, ErrTreeNotEmpty)
+	assert.ErrorIs(t, invalid, ErrInvalidInterval)
+	assert.Equal(t, Float(2), must(tree.GetAtInstant(12)))
+}
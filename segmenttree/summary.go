@@ -0,0 +1,57 @@
+package segmenttree
+
+// refreshSummaries recomputes the summaries of all nodes modified by the current operation.
+func (tree *SegmentTreeImpl[V, T]) refreshSummaries() {
+	if tree.integrals || tree.extrema {
+		tree.root.refreshSummary(NewInterval(0, MaxInstant[T]()))
+	}
+}
+
+// refreshSummary recomputes the integral and the extrema of a stale node from its values and the
+// summaries of its children. The ancestors of a stale node are stale as well, so all other nodes
+// are skipped.
+func (node *Node[V, T]) refreshSummary(bounds Interval[T]) {
+	if !node.summaryStale {
+		return
+	}
+
+	tree := node.tree
+	integral := 0.0
+	minimum, maximum := tree.aggregate.neutralElement, tree.aggregate.neutralElement
+
+	for index, nodeInterval := range node.getIntervalsWithin(bounds) {
+		value := node.values[index]
+		smallest, largest := value, value
+		integral += value.AsFloat64() * float64(nodeInterval.GetLength())
+
+		if !node.isLeaf {
+			child := node.children[index].node
+			child.refreshSummary(nodeInterval)
+			integral += child.integral
+			smallest, largest = tree.aggregate.operation(value, child.minimum), tree.aggregate.operation(value, child.maximum)
+		}
+
+		if index == 0 || smallest.AsFloat64() < minimum.AsFloat64() {
+			minimum = smallest
+		}
+		if index == 0 || largest.AsFloat64() > maximum.AsFloat64() {
+			maximum = largest
+		}
+	}
+
+	if tree.integrals {
+		node.integral = integral
+	}
+	if tree.extrema {
+		node.minimum, node.maximum = minimum, maximum
+	}
+	node.summaryStale = false
+}
+
+// markSummaryStale marks the node and its ancestors, so that their summaries are recomputed at
+// the end of the operation, see modified.
+func (node *Node[V, T]) markSummaryStale() {
+	for ; node != nil; node = node.parent {
+		node.summaryStale = true
+	}
+}
@@ -1,6 +1,6 @@
 package segmenttree
 
-func MinUint32(a uint32, b uint32) uint32 {
+func MinTime[T Timestamp](a T, b T) T {
 	if a < b {
 		return a
 	}
@@ -8,10 +8,16 @@ func MinUint32(a uint32, b uint32) uint32 {
 	return b
 }
 
-func MaxUint32(a uint32, b uint32) uint32 {
+func MaxTime[T Timestamp](a T, b T) T {
 	if a > b {
 		return a
 	}
 
 	return b
 }
+
+// MaxInstant returns the largest representable instant of T, which marks the
+// open end of the timeline.
+func MaxInstant[T Timestamp]() T {
+	return ^T(0)
+}
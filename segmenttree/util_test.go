@@ -1,12 +1,13 @@
 package segmenttree
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-var testDataMinUint32 = []struct {
+var testDataMinTime = []struct {
 	a        uint32
 	b        uint32
 	expected uint32
@@ -16,7 +17,7 @@ var testDataMinUint32 = []struct {
 	{10, 10, 10},
 }
 
-var testDataMaxUint32 = []struct {
+var testDataMaxTime = []struct {
 	a        uint32
 	b        uint32
 	expected uint32
@@ -26,28 +27,35 @@ var testDataMaxUint32 = []struct {
 	{10, 10, 10},
 }
 
-func TestMinUint32(t *testing.T) {
-	for _, testData := range testDataMinUint32 {
+func TestMinTime(t *testing.T) {
+	for _, testData := range testDataMinTime {
 		// Arrange
 		assert := assert.New(t)
 
 		// Act
-		res := MinUint32(testData.a, testData.b)
+		res := MinTime(testData.a, testData.b)
 
 		// Assert
 		assert.Equal(testData.expected, res)
 	}
 }
 
-func TestMaxUint32(t *testing.T) {
-	for _, testData := range testDataMaxUint32 {
+func TestMaxTime(t *testing.T) {
+	for _, testData := range testDataMaxTime {
 		// Arrange
 		assert := assert.New(t)
 
 		// Act
-		res := MaxUint32(testData.a, testData.b)
+		res := MaxTime(testData.a, testData.b)
 
 		// Assert
 		assert.Equal(testData.expected, res)
 	}
 }
+
+func TestMaxInstant(t *testing.T) {
+	assert.Equal(t, uint8(math.MaxUint8), MaxInstant[uint8]())
+	assert.Equal(t, uint16(math.MaxUint16), MaxInstant[uint16]())
+	assert.Equal(t, uint32(math.MaxUint32), MaxInstant[uint32]())
+	assert.Equal(t, uint64(math.MaxUint64), MaxInstant[uint64]())
+}
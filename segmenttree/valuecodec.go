@@ -0,0 +1,259 @@
+package segmenttree
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"sync"
+)
+
+var ErrNoValueCodec = errors.New("no codec registered for the value type")
+
+// ValueCodec encodes values into a fixed number of bytes, so that a node fits into a page.
+type ValueCodec[V any] interface {
+	Size() int
+	Encode(buffer []byte, value V)
+	Decode(buffer []byte) V
+}
+
+var valueCodecs = struct {
+	sync.RWMutex
+	codecs map[reflect.Type]any
+}{codecs: make(map[reflect.Type]any)}
+
+func init() {
+	RegisterValueCodec[Float](FloatCodec{})
+	RegisterValueCodec[AverageTuple](AverageTupleCodec{})
+	RegisterValueCodec[MomentsTuple](MomentsTupleCodec{})
+	RegisterValueCodec[Float64](Float64Codec{})
+	RegisterValueCodec[Int64](Int64Codec{})
+	RegisterValueCodec[Decimal](DecimalCodec{})
+	RegisterValueCodec[DistinctSketch](DistinctSketchCodec{})
+	RegisterValueCodec[Histogram](HistogramCodec{})
+}
+
+// RegisterValueCodec makes the codec the one used to encode values of type V in snapshots.
+// A codec registered before for V is replaced.
+func RegisterValueCodec[V any](codec ValueCodec[V]) {
+	valueCodecs.Lock()
+	defer valueCodecs.Unlock()
+
+	valueCodecs.codecs[valueType[V]()] = codec
+}
+
+// LookupValueCodec returns the codec registered for values of type V.
+func LookupValueCodec[V any]() (ValueCodec[V], error) {
+	valueCodecs.RLock()
+	defer valueCodecs.RUnlock()
+
+	codec, ok := valueCodecs.codecs[valueType[V]()]
+	if !ok {
+		return nil, ErrNoValueCodec
+	}
+
+	return codec.(ValueCodec[V]), nil
+}
+
+func valueType[V any]() reflect.Type {
+	return reflect.TypeOf((*V)(nil)).Elem()
+}
+
+type FloatCodec struct{}
+
+func (FloatCodec) Size() int {
+	return 4
+}
+
+func (FloatCodec) Encode(buffer []byte, value Float) {
+	binary.LittleEndian.PutUint32(buffer, math.Float32bits(float32(value)))
+}
+
+func (FloatCodec) Decode(buffer []byte) Float {
+	return Float(math.Float32frombits(binary.LittleEndian.Uint32(buffer)))
+}
+
+type Float64Codec struct{}
+
+func (Float64Codec) Size() int {
+	return 8
+}
+
+func (Float64Codec) Encode(buffer []byte, value Float64) {
+	binary.LittleEndian.PutUint64(buffer, math.Float64bits(float64(value)))
+}
+
+func (Float64Codec) Decode(buffer []byte) Float64 {
+	return Float64(math.Float64frombits(binary.LittleEndian.Uint64(buffer)))
+}
+
+type Int64Codec struct{}
+
+func (Int64Codec) Size() int {
+	return 8
+}
+
+func (Int64Codec) Encode(buffer []byte, value Int64) {
+	binary.LittleEndian.PutUint64(buffer, uint64(value))
+}
+
+func (Int64Codec) Decode(buffer []byte) Int64 {
+	return Int64(int64(binary.LittleEndian.Uint64(buffer)))
+}
+
+type DecimalCodec struct{}
+
+func (DecimalCodec) Size() int {
+	return 8
+}
+
+func (DecimalCodec) Encode(buffer []byte, value Decimal) {
+	binary.LittleEndian.PutUint64(buffer, uint64(value))
+}
+
+func (DecimalCodec) Decode(buffer []byte) Decimal {
+	return Decimal(int64(binary.LittleEndian.Uint64(buffer)))
+}
+
+type AverageTupleCodec struct{}
+
+func (AverageTupleCodec) Size() int {
+	return 16
+}
+
+func (AverageTupleCodec) Encode(buffer []byte, value AverageTuple) {
+	binary.LittleEndian.PutUint64(buffer, uint64(value.Sum))
+	binary.LittleEndian.PutUint64(buffer[8:], uint64(value.Count))
+}
+
+func (AverageTupleCodec) Decode(buffer []byte) AverageTuple {
+	return AverageTuple{
+		Sum:   int(int64(binary.LittleEndian.Uint64(buffer))),
+		Count: int(int64(binary.LittleEndian.Uint64(buffer[8:]))),
+	}
+}
+
+type MomentsTupleCodec struct{}
+
+func (MomentsTupleCodec) Size() int {
+	return 40
+}
+
+func (MomentsTupleCodec) Encode(buffer []byte, value MomentsTuple) {
+	binary.LittleEndian.PutUint64(buffer, uint64(value.Count))
+	for i, part := range []float64{value.Sum, value.sumError, value.SumOfSquares, value.sumOfSquaresError} {
+		binary.LittleEndian.PutUint64(buffer[8+8*i:], math.Float64bits(part))
+	}
+}
+
+func (MomentsTupleCodec) Decode(buffer []byte) MomentsTuple {
+	part := func(i int) float64 {
+		return math.Float64frombits(binary.LittleEndian.Uint64(buffer[8+8*i:]))
+	}
+
+	return MomentsTuple{
+		Count:             int(int64(binary.LittleEndian.Uint64(buffer))),
+		Sum:               part(0),
+		sumError:          part(1),
+		SumOfSquares:      part(2),
+		sumOfSquaresError: part(3),
+	}
+}
+
+type DistinctSketchCodec struct{}
+
+func (DistinctSketchCodec) Size() int {
+	return 4 * DistinctSketchSize
+}
+
+func (DistinctSketchCodec) Encode(buffer []byte, value DistinctSketch) {
+	for i, counter := range value.counters {
+		binary.LittleEndian.PutUint32(buffer[4*i:], uint32(counter))
+	}
+}
+
+func (DistinctSketchCodec) Decode(buffer []byte) DistinctSketch {
+	var value DistinctSketch
+	for i := range value.counters {
+		value.counters[i] = int32(binary.LittleEndian.Uint32(buffer[4*i:]))
+	}
+
+	return value
+}
+
+type HistogramCodec struct{}
+
+func (HistogramCodec) Size() int {
+	return 8 * HistogramSize
+}
+
+func (HistogramCodec) Encode(buffer []byte, value Histogram) {
+	for i, count := range value.counts {
+		binary.LittleEndian.PutUint64(buffer[8*i:], uint64(count))
+	}
+}
+
+func (HistogramCodec) Decode(buffer []byte) Histogram {
+	var value Histogram
+	for i := range value.counts {
+		value.counts[i] = int64(binary.LittleEndian.Uint64(buffer[8*i:]))
+	}
+
+	return value
+}
+
+// timestampSize returns the number of bytes needed to encode a T.
+func timestampSize[T Timestamp]() int {
+	return binary.Size(T(0))
+}
+
+func encodeTimestamp[T Timestamp](buffer []byte, value T) {
+	switch timestampSize[T]() {
+	case 1:
+		buffer[0] = uint8(value)
+	case 2:
+		binary.LittleEndian.PutUint16(buffer, uint16(value))
+	case 4:
+		binary.LittleEndian.PutUint32(buffer, uint32(value))
+	default:
+		binary.LittleEndian.PutUint64(buffer, uint64(value))
+	}
+}
+
+func decodeTimestamp[T Timestamp](buffer []byte) T {
+	switch timestampSize[T]() {
+	case 1:
+		return T(buffer[0])
+	case 2:
+		return T(binary.LittleEndian.Uint16(buffer))
+	case 4:
+		return T(binary.LittleEndian.Uint32(buffer))
+	default:
+		return T(binary.LittleEndian.Uint64(buffer))
+	}
+}
+
+// tupleSize returns the number of bytes needed to encode a ValueIntervalTuple.
+func tupleSize[V Addable[V], T Timestamp](codec ValueCodec[V]) int {
+	return 2*timestampSize[T]() + codec.Size()
+}
+
+func encodeTuple[V Addable[V], T Timestamp](buffer []byte, tuple ValueIntervalTuple[V, T], codec ValueCodec[V]) {
+	keySize := timestampSize[T]()
+
+	encodeTimestamp(buffer, tuple.interval.start)
+	encodeTimestamp(buffer[keySize:], tuple.interval.end)
+	codec.Encode(buffer[2*keySize:], tuple.value)
+}
+
+func decodeTuple[V Addable[V], T Timestamp](buffer []byte, codec ValueCodec[V]) ValueIntervalTuple[V, T] {
+	keySize := timestampSize[T]()
+
+	return ValueIntervalTuple[V, T]{
+		interval: Interval[T]{
+			start: decodeTimestamp[T](buffer),
+			end:   decodeTimestamp[T](buffer[keySize:]),
+		},
+		value: codec.Decode(buffer[2*keySize:]),
+	}
+}
@@ -1,6 +1,21 @@
 package segmenttree
 
-type ValueIntervalTuple struct {
-	value    Addable
-	interval Interval
+type ValueIntervalTuple[V Addable[V], T Timestamp] struct {
+	value    V
+	interval Interval[T]
+}
+
+func NewValueIntervalTuple[V Addable[V], T Timestamp](value V, interval Interval[T]) ValueIntervalTuple[V, T] {
+	return ValueIntervalTuple[V, T]{
+		value:    value,
+		interval: interval,
+	}
+}
+
+func (tuple ValueIntervalTuple[V, T]) GetValue() V {
+	return tuple.value
+}
+
+func (tuple ValueIntervalTuple[V, T]) GetInterval() Interval[T] {
+	return tuple.interval
 }
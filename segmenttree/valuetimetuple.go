@@ -1,6 +1,6 @@
 package segmenttree
 
-type ValueTimeTuple struct {
-	value Addable
-	time  uint32
+type ValueTimeTuple[V Addable[V], T Timestamp] struct {
+	value V
+	time  T
 }
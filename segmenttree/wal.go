@@ -0,0 +1,258 @@
+package segmenttree
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+var ErrInvalidLogFile = errors.New("not a segment tree log file")
+
+var walMagic = []byte("SBWAL001")
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type LogOperation uint8
+
+const (
+	LogInsert LogOperation = iota + 1
+	LogDelete
+)
+
+// SyncPolicy is the number of appended records after which the log is synced to disk.
+// With SyncOnCheckpoint, the log is only synced by Sync, at checkpoints and on Close.
+type SyncPolicy int
+
+const (
+	SyncOnCheckpoint SyncPolicy = 0
+	SyncAlways       SyncPolicy = 1
+)
+
+// LogRecord is one logical operation on a tree. The log sequence number (LSN) increases
+// by one for every record.
+type LogRecord[V Addable[V], T Timestamp] struct {
+	LSN       uint64
+	Operation LogOperation
+	Tuple     ValueIntervalTuple[V, T]
+}
+
+// WriteAheadLog appends the operations on a tree to a file before they are applied.
+//
+// The file starts with a magic number followed by fixed-size records:
+//
+//	checksum (4 byte) | LSN (8 byte) | operation (1 byte) | start | end | value
+//
+// The checksum is a CRC-32C over the rest of the record. A record which is incomplete
+// or whose checksum does not match was torn by a crash and ends the log.
+type WriteAheadLog[V Addable[V], T Timestamp] struct {
+	file     logFile
+	codec    ValueCodec[V]
+	policy   SyncPolicy
+	nextLSN  uint64
+	unsynced int
+}
+
+// logFile is the part of *os.File used by the log.
+type logFile interface {
+	io.ReadWriteSeeker
+	io.WriterAt
+	io.Closer
+	Truncate(size int64) error
+	Sync() error
+}
+
+// OpenWriteAheadLog opens or creates the log at path and returns all intact records in it.
+// A torn tail is cut off, so that new records are appended after the last intact one.
+func OpenWriteAheadLog[V Addable[V], T Timestamp](path string, codec ValueCodec[V], policy SyncPolicy) (*WriteAheadLog[V, T], []LogRecord[V, T], error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log := &WriteAheadLog[V, T]{
+		file:    file,
+		codec:   codec,
+		policy:  policy,
+		nextLSN: 1,
+	}
+
+	records, err := log.recover()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return log, records, nil
+}
+
+// Append writes a record for the operation and returns its LSN.
+func (log *WriteAheadLog[V, T]) Append(operation LogOperation, tuple ValueIntervalTuple[V, T]) (uint64, error) {
+	record := LogRecord[V, T]{LSN: log.nextLSN, Operation: operation, Tuple: tuple}
+
+	if _, err := log.file.Write(log.encode(record)); err != nil {
+		// Remove a partially written record, records appended later would be lost on recovery otherwise
+		return 0, errors.Join(err, log.truncate(record.LSN))
+	}
+	log.nextLSN++
+	log.unsynced++
+
+	if log.policy != SyncOnCheckpoint && log.unsynced >= int(log.policy) {
+		if err := log.Sync(); err != nil {
+			// The record is not durable and the operation is not applied, so it must not be
+			// replayed on recovery either
+			log.unsynced--
+			return 0, errors.Join(err, log.truncate(record.LSN))
+		}
+	}
+
+	return record.LSN, nil
+}
+
+// Sync makes all appended records durable.
+func (log *WriteAheadLog[V, T]) Sync() error {
+	if err := log.file.Sync(); err != nil {
+		return err
+	}
+	log.unsynced = 0
+
+	return nil
+}
+
+// NextLSN returns the LSN the next appended record gets.
+func (log *WriteAheadLog[V, T]) NextLSN() uint64 {
+	return log.nextLSN
+}
+
+// Reset removes all records from the log. Records appended afterwards get LSNs starting at nextLSN.
+func (log *WriteAheadLog[V, T]) Reset(nextLSN uint64) error {
+	if err := log.file.Truncate(int64(len(walMagic))); err != nil {
+		return err
+	}
+	if _, err := log.file.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	log.nextLSN = nextLSN
+
+	return log.Sync()
+}
+
+// truncate removes the records starting at lsn from the end of the log.
+func (log *WriteAheadLog[V, T]) truncate(lsn uint64) error {
+	end, err := log.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	position := end - int64(log.nextLSN-lsn)*int64(log.recordSize())
+	// A partially written record is not counted by nextLSN
+	position -= (position - int64(len(walMagic))) % int64(log.recordSize())
+
+	if err := log.file.Truncate(position); err != nil {
+		return err
+	}
+	if _, err := log.file.Seek(position, io.SeekStart); err != nil {
+		return err
+	}
+	log.nextLSN = lsn
+
+	return nil
+}
+
+func (log *WriteAheadLog[V, T]) Close() error {
+	if err := log.Sync(); err != nil {
+		log.file.Close()
+		return err
+	}
+
+	return log.file.Close()
+}
+
+func (log *WriteAheadLog[V, T]) recordSize() int {
+	return 4 + 8 + 1 + tupleSize[V, T](log.codec)
+}
+
+func (log *WriteAheadLog[V, T]) recover() ([]LogRecord[V, T], error) {
+	content, err := io.ReadAll(log.file)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) < len(walMagic) {
+		// A new log, or the creation was torn
+		if err := log.file.Truncate(0); err != nil {
+			return nil, err
+		}
+		if _, err := log.file.WriteAt(walMagic, 0); err != nil {
+			return nil, err
+		}
+		if _, err := log.file.Seek(0, io.SeekEnd); err != nil {
+			return nil, err
+		}
+		return nil, log.Sync()
+	}
+
+	if string(content[:len(walMagic)]) != string(walMagic) {
+		return nil, ErrInvalidLogFile
+	}
+
+	records := []LogRecord[V, T]{}
+	position := len(walMagic)
+	size := log.recordSize()
+
+	for ; position+size <= len(content); position += size {
+		record, ok := log.decode(content[position : position+size])
+		if !ok || (len(records) > 0 && record.LSN != records[len(records)-1].LSN+1) {
+			break
+		}
+		records = append(records, record)
+	}
+
+	if position < len(content) {
+		if err := log.file.Truncate(int64(position)); err != nil {
+			return nil, err
+		}
+		if err := log.file.Sync(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := log.file.Seek(int64(position), io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if len(records) > 0 {
+		log.nextLSN = records[len(records)-1].LSN + 1
+	}
+
+	return records, nil
+}
+
+func (log *WriteAheadLog[V, T]) encode(record LogRecord[V, T]) []byte {
+	buffer := make([]byte, log.recordSize())
+
+	binary.LittleEndian.PutUint64(buffer[4:], record.LSN)
+	buffer[12] = byte(record.Operation)
+	encodeTuple(buffer[13:], record.Tuple, log.codec)
+	binary.LittleEndian.PutUint32(buffer, crc32.Checksum(buffer[4:], crcTable))
+
+	return buffer
+}
+
+func (log *WriteAheadLog[V, T]) decode(buffer []byte) (LogRecord[V, T], bool) {
+	if binary.LittleEndian.Uint32(buffer) != crc32.Checksum(buffer[4:], crcTable) {
+		return LogRecord[V, T]{}, false
+	}
+
+	record := LogRecord[V, T]{
+		LSN:       binary.LittleEndian.Uint64(buffer[4:]),
+		Operation: LogOperation(buffer[12]),
+		Tuple:     decodeTuple[V, T](buffer[13:], log.codec),
+	}
+
+	if record.Operation != LogInsert && record.Operation != LogDelete {
+		return LogRecord[V, T]{}, false
+	}
+
+	return record, true
+}
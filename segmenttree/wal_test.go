@@ -0,0 +1,223 @@
+package segmenttree
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAheadLogReopen(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "wal")
+	log, records, _ := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncAlways)
+	assert.Empty(t, records)
+
+	for _, tuple := range dosageTestData[Float]() {
+		log.Append(LogInsert, tuple)
+	}
+	log.Append(LogDelete, dosageTestData[Float]()[0])
+	assert.NoError(t, log.Close())
+
+	// Act
+	reopened, records, err := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncAlways)
+	defer reopened.Close()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, records, 7)
+	for i, tuple := range dosageTestData[Float]() {
+		assert.Equal(t, LogRecord[Float, uint32]{LSN: uint64(i + 1), Operation: LogInsert, Tuple: tuple}, records[i])
+	}
+	assert.Equal(t, LogRecord[Float, uint32]{LSN: 7, Operation: LogDelete, Tuple: dosageTestData[Float]()[0]}, records[6])
+	assert.Equal(t, uint64(8), reopened.NextLSN())
+}
+
+func TestWriteAheadLogWithTornWrite(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "wal")
+	writeTestLog(t, path, 3)
+
+	// The last record was only written partially
+	info, _ := os.Stat(path)
+	os.Truncate(path, info.Size()-5)
+
+	// Act
+	log, records, err := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncAlways)
+	log.Append(LogInsert, dosageTestData[Float]()[5])
+	log.Close()
+	_, recordsAfterAppend, _ := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncAlways)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Len(t, recordsAfterAppend, 3)
+	assert.Equal(t, uint64(3), recordsAfterAppend[2].LSN)
+	assert.Equal(t, dosageTestData[Float]()[5], recordsAfterAppend[2].Tuple)
+}
+
+func TestWriteAheadLogWithTruncatedTail(t *testing.T) {
+	for cut := 1; cut <= 3; cut++ {
+		// Arrange
+		path := filepath.Join(t.TempDir(), "wal")
+		writeTestLog(t, path, 4)
+
+		info, _ := os.Stat(path)
+		recordSize := (info.Size() - int64(len(walMagic))) / 4
+		os.Truncate(path, info.Size()-int64(cut)*recordSize)
+
+		// Act
+		log, records, err := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncAlways)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, records, 4-cut)
+		assert.Equal(t, uint64(5-cut), log.NextLSN())
+		log.Close()
+	}
+}
+
+func TestWriteAheadLogWithCorruptedRecord(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "wal")
+	writeTestLog(t, path, 3)
+
+	info, _ := os.Stat(path)
+	recordSize := (info.Size() - int64(len(walMagic))) / 3
+
+	// Flip a bit in the value of the second record
+	file, _ := os.OpenFile(path, os.O_RDWR, 0644)
+	buffer := make([]byte, 1)
+	offset := int64(len(walMagic)) + 2*recordSize - 1
+	file.ReadAt(buffer, offset)
+	buffer[0] ^= 1
+	file.WriteAt(buffer, offset)
+	file.Close()
+
+	// Act
+	log, records, err := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncAlways)
+	defer log.Close()
+	info, _ = os.Stat(path)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, int64(len(walMagic))+recordSize, info.Size())
+}
+
+func TestWriteAheadLogReset(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "wal")
+	log, _, _ := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncOnCheckpoint)
+	log.Append(LogInsert, dosageTestData[Float]()[0])
+
+	// Act
+	assert.NoError(t, log.Reset(10))
+	log.Append(LogInsert, dosageTestData[Float]()[1])
+	log.Close()
+	_, records, _ := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncOnCheckpoint)
+
+	// Assert
+	assert.Len(t, records, 1)
+	assert.Equal(t, LogRecord[Float, uint32]{LSN: 10, Operation: LogInsert, Tuple: dosageTestData[Float]()[1]}, records[0])
+}
+
+func TestWriteAheadLogTruncate(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "wal")
+	log, _, _ := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncAlways)
+	for _, tuple := range dosageTestData[Float]()[:3] {
+		log.Append(LogInsert, tuple)
+	}
+
+	// Act
+	assert.NoError(t, log.truncate(2))
+	lsn, _ := log.Append(LogDelete, dosageTestData[Float]()[3])
+	log.Close()
+	_, records, _ := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncAlways)
+
+	// Assert
+	assert.Equal(t, uint64(2), lsn)
+	assert.Equal(t, []LogRecord[Float, uint32]{
+		{LSN: 1, Operation: LogInsert, Tuple: dosageTestData[Float]()[0]},
+		{LSN: 2, Operation: LogDelete, Tuple: dosageTestData[Float]()[3]},
+	}, records)
+}
+
+func TestWriteAheadLogWithFailingSync(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "wal")
+	log, _, _ := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncAlways)
+	log.Append(LogInsert, dosageTestData[Float]()[0])
+	file := &failingSyncFile{File: log.file.(*os.File), fail: true}
+	log.file = file
+
+	// Act
+	lsn, err := log.Append(LogInsert, dosageTestData[Float]()[1])
+	file.fail = false
+	nextLSN := log.NextLSN()
+	log.Close()
+	_, records, _ := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncAlways)
+
+	// Assert
+	assert.ErrorIs(t, err, errSync)
+	assert.Zero(t, lsn)
+	assert.Equal(t, uint64(2), nextLSN)
+	assert.Equal(t, []LogRecord[Float, uint32]{{LSN: 1, Operation: LogInsert, Tuple: dosageTestData[Float]()[0]}}, records)
+}
+
+func TestWriteAheadLogSyncPolicy(t *testing.T) {
+	// Arrange
+	log, _, _ := OpenWriteAheadLog[Float, uint32](filepath.Join(t.TempDir(), "wal"), FloatCodec{}, SyncPolicy(3))
+	defer log.Close()
+
+	for i, tuple := range dosageTestData[Float]()[:4] {
+		// Act
+		log.Append(LogInsert, tuple)
+
+		// Assert
+		assert.Equal(t, (i+1)%3, log.unsynced)
+	}
+}
+
+func TestOpenWriteAheadLogWithInvalidFile(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "wal")
+	os.WriteFile(path, []byte("no segment tree log"), 0644)
+
+	// Act
+	_, _, err := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncAlways)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidLogFile)
+}
+
+func writeTestLog(t *testing.T, path string, count int) {
+	log, _, err := OpenWriteAheadLog[Float, uint32](path, FloatCodec{}, SyncAlways)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tuple := range dosageTestData[Float]()[:count] {
+		log.Append(LogInsert, tuple)
+	}
+	log.Close()
+}
+
+var errSync = errors.New("sync failed")
+
+// failingSyncFile fails to sync while fail is set.
+type failingSyncFile struct {
+	*os.File
+	fail bool
+}
+
+func (file *failingSyncFile) Sync() error {
+	if file.fail {
+		return errSync
+	}
+
+	return file.File.Sync()
+}